@@ -22,8 +22,15 @@ type Expression interface {
 	expressionNode()
 }
 
+// Pattern Binding target of let statements, function parameters and match arms
+type Pattern interface {
+	Expression
+	patternNode()
+}
+
 type ProgramRoot struct {
 	Statements []Statement
+	Comments   []token.Token // Comments in source order, they aren't part of the tree
 }
 
 func (p *ProgramRoot) TokenLiteral() string {
@@ -45,9 +52,11 @@ func (p *ProgramRoot) String() string {
 }
 
 type LetStatement struct {
-	Token token.Token // Token.LET
-	Name  *Identifier // Identifier ("x" for an example) is itself an expression
-	Value Expression
+	Token   token.Token    // Token.LET
+	Name    *Identifier    // Identifier ("x" for an example) is itself an expression
+	Pattern Pattern        // Destructuring target, set instead of Name
+	Type    TypeExpression // Annotation of Name, optional
+	Value   Expression
 }
 
 func (ls *LetStatement) statementNode() {}
@@ -60,7 +69,10 @@ func (ls *LetStatement) String() string {
 	var out bytes.Buffer
 
 	out.WriteString(ls.TokenLiteral() + " ")
-	out.WriteString(ls.Name.String())
+	out.WriteString(ls.Target().String())
+	if ls.Type != nil {
+		out.WriteString(": " + ls.Type.String())
+	}
 	out.WriteString(" = ")
 
 	if ls.Value != nil {
@@ -72,6 +84,15 @@ func (ls *LetStatement) String() string {
 	return out.String()
 }
 
+// Target Returns pattern bound by the statement
+func (ls *LetStatement) Target() Pattern {
+	if ls.Pattern != nil {
+		return ls.Pattern
+	}
+
+	return ls.Name
+}
+
 type Identifier struct {
 	Token token.Token // Token.IDENT
 	Value string
@@ -79,6 +100,8 @@ type Identifier struct {
 
 func (i *Identifier) expressionNode() {}
 
+func (i *Identifier) patternNode() {}
+
 func (i *Identifier) TokenLiteral() string {
 	return i.Token.Literal
 }
@@ -224,6 +247,20 @@ func (b *Boolean) String() string {
 	return b.Token.Literal
 }
 
+type NullLiteral struct {
+	Token token.Token
+}
+
+func (nl *NullLiteral) expressionNode() {}
+
+func (nl *NullLiteral) TokenLiteral() string {
+	return nl.Token.Literal
+}
+
+func (nl *NullLiteral) String() string {
+	return nl.Token.Literal
+}
+
 type IfExpression struct {
 	Token       token.Token
 	Condition   Expression
@@ -256,6 +293,7 @@ func (ie *IfExpression) String() string {
 type BlockStatement struct {
 	Token      token.Token
 	Statements []Statement
+	Rbrace     token.Token // The closing '}' token
 }
 
 func (bs *BlockStatement) statementNode() {}
@@ -275,9 +313,12 @@ func (bs *BlockStatement) String() string {
 }
 
 type FunctionLiteral struct {
-	Token      token.Token
-	Parameters []*Identifier
-	Body       *BlockStatement
+	Token          token.Token
+	Parameters     []Pattern
+	ParameterTypes []TypeExpression // Annotations of Parameters by index, nil for unannotated ones
+	Rest           *Identifier      // Variadic parameter collecting extra arguments, optional
+	ReturnType     TypeExpression   // Annotation of returned value, optional
+	Body           *BlockStatement
 }
 
 func (fl *FunctionLiteral) expressionNode() {}
@@ -287,18 +328,74 @@ func (fl *FunctionLiteral) TokenLiteral() string {
 }
 
 func (fl *FunctionLiteral) String() string {
+	return fl.TokenLiteral() + fl.signature() + fl.Body.String()
+}
+
+// ParameterType Returns annotation of i-th parameter, nil when it has none
+func (fl *FunctionLiteral) ParameterType(i int) TypeExpression {
+	if i >= len(fl.ParameterTypes) {
+		return nil
+	}
+
+	return fl.ParameterTypes[i]
+}
+
+// signature Returns parameter list with annotations and return type
+func (fl *FunctionLiteral) signature() string {
+	params := []string{}
+	for i, p := range fl.Parameters {
+		param := p.String()
+		if paramType := fl.ParameterType(i); paramType != nil {
+			// Default value follows the annotation in source
+			if defaultPattern, ok := p.(*DefaultPattern); ok {
+				param = defaultPattern.Target.String() + ": " + paramType.String() + " = " + defaultPattern.Default.String()
+			} else {
+				param += ": " + paramType.String()
+			}
+		}
+
+		params = append(params, param)
+	}
+
+	if fl.Rest != nil {
+		params = append(params, "..."+fl.Rest.String())
+	}
+
+	out := "(" + strings.Join(params, ",") + ")"
+	if fl.ReturnType != nil {
+		out += " -> " + fl.ReturnType.String() + " "
+	}
+
+	return out
+}
+
+// MacroLiteral Macro body receives its arguments quoted and returns a quote
+// replacing the macro call before evaluation
+type MacroLiteral struct {
+	Token      token.Token // The 'macro' token
+	Parameters []*Identifier
+	Body       *BlockStatement
+}
+
+func (ml *MacroLiteral) expressionNode() {}
+
+func (ml *MacroLiteral) TokenLiteral() string {
+	return ml.Token.Literal
+}
+
+func (ml *MacroLiteral) String() string {
 	var out bytes.Buffer
 
 	params := []string{}
-	for _, p := range fl.Parameters {
+	for _, p := range ml.Parameters {
 		params = append(params, p.String())
 	}
 
-	out.WriteString(fl.TokenLiteral())
+	out.WriteString(ml.TokenLiteral())
 	out.WriteString("(")
 	out.WriteString(strings.Join(params, ","))
 	out.WriteString(")")
-	out.WriteString(fl.Body.String())
+	out.WriteString(ml.Body.String())
 
 	return out.String()
 }
@@ -307,6 +404,7 @@ type CallExpression struct {
 	Token     token.Token
 	Function  Expression
 	Arguments []Expression
+	Optional  bool // f?.() evaluates to null when f is null
 }
 
 func (ce *CallExpression) expressionNode() {}
@@ -324,6 +422,9 @@ func (ce *CallExpression) String() string {
 	}
 
 	out.WriteString(ce.Function.String())
+	if ce.Optional {
+		out.WriteString("?.")
+	}
 	out.WriteString("(")
 	out.WriteString(strings.Join(args, ", "))
 	out.WriteString(")")
@@ -331,6 +432,32 @@ func (ce *CallExpression) String() string {
 	return out.String()
 }
 
+// PipeExpression Calls Right with Left as the first argument. Right is
+// either a call expression or an expression evaluating to a function.
+type PipeExpression struct {
+	Token token.Token // The '|>' token
+	Left  Expression
+	Right Expression
+}
+
+func (pe *PipeExpression) expressionNode() {}
+
+func (pe *PipeExpression) TokenLiteral() string {
+	return pe.Token.Literal
+}
+
+func (pe *PipeExpression) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("(")
+	out.WriteString(pe.Left.String())
+	out.WriteString(" |> ")
+	out.WriteString(pe.Right.String())
+	out.WriteString(")")
+
+	return out.String()
+}
+
 type ArrayLiteral struct {
 	Token    token.Token
 	Elements []Expression
@@ -358,9 +485,10 @@ func (al *ArrayLiteral) String() string {
 }
 
 type IndexExpression struct {
-	Token token.Token
-	Left  Expression
-	Index Expression
+	Token    token.Token
+	Left     Expression
+	Index    Expression
+	Optional bool // a?.[i] evaluates to null when a is null
 }
 
 func (ie *IndexExpression) expressionNode() {}
@@ -374,6 +502,9 @@ func (ie *IndexExpression) String() string {
 
 	out.WriteString("(")
 	out.WriteString(ie.Left.String())
+	if ie.Optional {
+		out.WriteString("?.")
+	}
 	out.WriteString("[")
 	out.WriteString(ie.Index.String())
 	out.WriteString("])")
@@ -381,9 +512,79 @@ func (ie *IndexExpression) String() string {
 	return out.String()
 }
 
+// MemberExpression Reads dict key, module export or method e.g. person.name
+type MemberExpression struct {
+	Token    token.Token // The '.' or '?.' token
+	Object   Expression
+	Property *Identifier
+	Optional bool // a?.b evaluates to null when a is null
+}
+
+func (me *MemberExpression) expressionNode() {}
+
+func (me *MemberExpression) TokenLiteral() string {
+	return me.Token.Literal
+}
+
+func (me *MemberExpression) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("(")
+	out.WriteString(me.Object.String())
+	if me.Optional {
+		out.WriteString("?.")
+	} else {
+		out.WriteString(".")
+	}
+	out.WriteString(me.Property.String())
+	out.WriteString(")")
+
+	return out.String()
+}
+
+// SliceIndex Index of a[start:end:step] where every bound is optional
+type SliceIndex struct {
+	Token token.Token // The first ':' token
+	Start Expression
+	End   Expression
+	Step  Expression
+}
+
+func (si *SliceIndex) expressionNode() {}
+
+func (si *SliceIndex) TokenLiteral() string {
+	return si.Token.Literal
+}
+
+func (si *SliceIndex) String() string {
+	var out bytes.Buffer
+
+	if si.Start != nil {
+		out.WriteString(si.Start.String())
+	}
+
+	out.WriteString(":")
+
+	if si.End != nil {
+		out.WriteString(si.End.String())
+	}
+
+	if si.Step != nil {
+		out.WriteString(":")
+		out.WriteString(si.Step.String())
+	}
+
+	return out.String()
+}
+
+type DictLiteralElement struct {
+	Key   Expression
+	Value Expression
+}
+
 type DictLiteral struct {
 	Token    token.Token
-	Elements map[Expression]Expression
+	Elements []DictLiteralElement // Kept in source order
 }
 
 func (dl *DictLiteral) expressionNode() {}
@@ -396,8 +597,8 @@ func (dl *DictLiteral) String() string {
 	var out bytes.Buffer
 
 	elements := []string{}
-	for key, value := range dl.Elements {
-		elements = append(elements, key.String()+":"+value.String())
+	for _, element := range dl.Elements {
+		elements = append(elements, element.Key.String()+":"+element.Value.String())
 	}
 
 	out.WriteString("{")
@@ -406,3 +607,443 @@ func (dl *DictLiteral) String() string {
 
 	return out.String()
 }
+
+type AssignExpression struct {
+	Token  token.Token // The '=' token
+	Target Expression  // Assignable expression e.g. arr[0]
+	Value  Expression
+}
+
+func (ae *AssignExpression) expressionNode() {}
+
+func (ae *AssignExpression) TokenLiteral() string {
+	return ae.Token.Literal
+}
+
+func (ae *AssignExpression) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("(")
+	out.WriteString(ae.Target.String())
+	out.WriteString(" = ")
+	out.WriteString(ae.Value.String())
+	out.WriteString(")")
+
+	return out.String()
+}
+
+type ImportStatement struct {
+	Token token.Token    // The 'import' or 'from' token
+	Path  *StringLiteral // Module path as written in source
+	Alias *Identifier    // Name bound to the whole module, nil for from imports
+	Names []*Identifier  // Exported names bound by from imports
+}
+
+func (is *ImportStatement) statementNode() {}
+
+func (is *ImportStatement) TokenLiteral() string {
+	return is.Token.Literal
+}
+
+func (is *ImportStatement) String() string {
+	var out bytes.Buffer
+
+	if is.Token.Type == token.FROM {
+		names := []string{}
+		for _, name := range is.Names {
+			names = append(names, name.String())
+		}
+
+		out.WriteString("from \"" + is.Path.String() + "\" import ")
+		out.WriteString(strings.Join(names, ", "))
+	} else {
+		out.WriteString("import \"" + is.Path.String() + "\"")
+		if is.Alias != nil {
+			out.WriteString(" as " + is.Alias.String())
+		}
+	}
+
+	out.WriteString(";")
+
+	return out.String()
+}
+
+type ExportStatement struct {
+	Token     token.Token // The 'export' token
+	Statement *LetStatement
+}
+
+func (es *ExportStatement) statementNode() {}
+
+func (es *ExportStatement) TokenLiteral() string {
+	return es.Token.Literal
+}
+
+func (es *ExportStatement) String() string {
+	return es.TokenLiteral() + " " + es.Statement.String()
+}
+
+type StructMethod struct {
+	Name     *Identifier
+	Function *FunctionLiteral
+}
+
+func (sm *StructMethod) String() string {
+	return "fn " + sm.Name.String() + sm.Function.signature() + sm.Function.Body.String()
+}
+
+// StructStatement Declares struct type with named fields and methods
+type StructStatement struct {
+	Token   token.Token // The 'struct' token
+	Name    *Identifier
+	Fields  []Pattern // Field names, optionally with default values
+	Methods []*StructMethod
+}
+
+func (ss *StructStatement) statementNode() {}
+
+func (ss *StructStatement) TokenLiteral() string {
+	return ss.Token.Literal
+}
+
+func (ss *StructStatement) String() string {
+	var out bytes.Buffer
+
+	members := []string{}
+	for _, field := range ss.Fields {
+		members = append(members, field.String())
+	}
+
+	for _, method := range ss.Methods {
+		members = append(members, method.String())
+	}
+
+	out.WriteString("struct ")
+	out.WriteString(ss.Name.String())
+	out.WriteString(" { ")
+	out.WriteString(strings.Join(members, ", "))
+	out.WriteString(" }")
+
+	return out.String()
+}
+
+type ThrowStatement struct {
+	Token token.Token // The 'throw' token
+	Value Expression
+}
+
+func (ts *ThrowStatement) statementNode() {}
+
+func (ts *ThrowStatement) TokenLiteral() string {
+	return ts.Token.Literal
+}
+
+func (ts *ThrowStatement) String() string {
+	var out bytes.Buffer
+
+	out.WriteString(ts.TokenLiteral() + " ")
+
+	if ts.Value != nil {
+		out.WriteString(ts.Value.String())
+	}
+
+	out.WriteString(";")
+
+	return out.String()
+}
+
+type TryExpression struct {
+	Token      token.Token // The 'try' token
+	Block      *BlockStatement
+	CatchParam *Identifier // Name bound to the caught error
+	Catch      *BlockStatement
+	Finally    *BlockStatement
+}
+
+func (te *TryExpression) expressionNode() {}
+
+func (te *TryExpression) TokenLiteral() string {
+	return te.Token.Literal
+}
+
+func (te *TryExpression) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("try ")
+	out.WriteString(te.Block.String())
+
+	if te.Catch != nil {
+		out.WriteString(" catch(")
+		out.WriteString(te.CatchParam.String())
+		out.WriteString(") ")
+		out.WriteString(te.Catch.String())
+	}
+
+	if te.Finally != nil {
+		out.WriteString(" finally ")
+		out.WriteString(te.Finally.String())
+	}
+
+	return out.String()
+}
+
+type MatchArm struct {
+	Pattern Pattern
+	Guard   Expression // Arm is skipped unless guard is truthy, optional
+	Body    Expression
+}
+
+type MatchExpression struct {
+	Token   token.Token // The 'match' token
+	Subject Expression
+	Arms    []MatchArm
+}
+
+func (me *MatchExpression) expressionNode() {}
+
+func (me *MatchExpression) TokenLiteral() string {
+	return me.Token.Literal
+}
+
+func (me *MatchExpression) String() string {
+	var out bytes.Buffer
+
+	arms := []string{}
+	for _, arm := range me.Arms {
+		armString := arm.Pattern.String()
+		if arm.Guard != nil {
+			armString += " if " + arm.Guard.String()
+		}
+
+		arms = append(arms, armString+" => "+arm.Body.String())
+	}
+
+	out.WriteString("match (")
+	out.WriteString(me.Subject.String())
+	out.WriteString(") { ")
+	out.WriteString(strings.Join(arms, ", "))
+	out.WriteString(" }")
+
+	return out.String()
+}
+
+type ArrayPattern struct {
+	Token    token.Token // The '[' token
+	Elements []Pattern
+	Rest     *Identifier // Collects remaining elements, optional
+}
+
+func (ap *ArrayPattern) expressionNode() {}
+
+func (ap *ArrayPattern) patternNode() {}
+
+func (ap *ArrayPattern) TokenLiteral() string {
+	return ap.Token.Literal
+}
+
+func (ap *ArrayPattern) String() string {
+	var out bytes.Buffer
+
+	elements := []string{}
+	for _, element := range ap.Elements {
+		elements = append(elements, element.String())
+	}
+
+	if ap.Rest != nil {
+		elements = append(elements, "..."+ap.Rest.String())
+	}
+
+	out.WriteString("[")
+	out.WriteString(strings.Join(elements, ", "))
+	out.WriteString("]")
+
+	return out.String()
+}
+
+type DictPatternEntry struct {
+	Key   *StringLiteral
+	Value Pattern
+}
+
+type DictPattern struct {
+	Token   token.Token // The '{' token
+	Entries []DictPatternEntry
+	Rest    *Identifier // Collects remaining keys, optional
+}
+
+func (dp *DictPattern) expressionNode() {}
+
+func (dp *DictPattern) patternNode() {}
+
+func (dp *DictPattern) TokenLiteral() string {
+	return dp.Token.Literal
+}
+
+func (dp *DictPattern) String() string {
+	var out bytes.Buffer
+
+	entries := []string{}
+	for _, entry := range dp.Entries {
+		entries = append(entries, entry.Key.String()+":"+entry.Value.String())
+	}
+
+	if dp.Rest != nil {
+		entries = append(entries, "..."+dp.Rest.String())
+	}
+
+	out.WriteString("{")
+	out.WriteString(strings.Join(entries, ", "))
+	out.WriteString("}")
+
+	return out.String()
+}
+
+// DefaultPattern Binds Default when destructured element is missing
+type DefaultPattern struct {
+	Token   token.Token // The '=' token
+	Target  Pattern
+	Default Expression
+}
+
+func (dp *DefaultPattern) expressionNode() {}
+
+func (dp *DefaultPattern) patternNode() {}
+
+func (dp *DefaultPattern) TokenLiteral() string {
+	return dp.Token.Literal
+}
+
+func (dp *DefaultPattern) String() string {
+	return dp.Target.String() + " = " + dp.Default.String()
+}
+
+// LiteralPattern Matches values equal to the literal, used by match arms
+type LiteralPattern struct {
+	Token token.Token // The first token of the literal
+	Value Expression
+}
+
+func (lp *LiteralPattern) expressionNode() {}
+
+func (lp *LiteralPattern) patternNode() {}
+
+func (lp *LiteralPattern) TokenLiteral() string {
+	return lp.Token.Literal
+}
+
+func (lp *LiteralPattern) String() string {
+	return lp.Value.String()
+}
+
+// WildcardPattern Matches any value without binding it
+type WildcardPattern struct {
+	Token token.Token // The '_' token
+}
+
+func (wp *WildcardPattern) expressionNode() {}
+
+func (wp *WildcardPattern) patternNode() {}
+
+func (wp *WildcardPattern) TokenLiteral() string {
+	return wp.Token.Literal
+}
+
+func (wp *WildcardPattern) String() string {
+	return wp.Token.Literal
+}
+
+// TypePattern Matches values of the named type e.g. int or int(n)
+type TypePattern struct {
+	Token   token.Token // The type name token
+	Name    string
+	Binding Pattern // Pattern matched against the value, optional
+}
+
+func (tp *TypePattern) expressionNode() {}
+
+func (tp *TypePattern) patternNode() {}
+
+func (tp *TypePattern) TokenLiteral() string {
+	return tp.Token.Literal
+}
+
+func (tp *TypePattern) String() string {
+	if tp.Binding == nil {
+		return tp.Name
+	}
+
+	return tp.Name + "(" + tp.Binding.String() + ")"
+}
+
+// PatternIdentifiers Returns all names bound by the pattern in source order
+func PatternIdentifiers(pattern Pattern) []*Identifier {
+	switch pattern := pattern.(type) {
+	case *Identifier:
+		return []*Identifier{pattern}
+	case *DefaultPattern:
+		return PatternIdentifiers(pattern.Target)
+	case *TypePattern:
+		if pattern.Binding == nil {
+			return nil
+		}
+
+		return PatternIdentifiers(pattern.Binding)
+	case *ArrayPattern:
+		identifiers := []*Identifier{}
+		for _, element := range pattern.Elements {
+			identifiers = append(identifiers, PatternIdentifiers(element)...)
+		}
+
+		if pattern.Rest != nil {
+			identifiers = append(identifiers, pattern.Rest)
+		}
+
+		return identifiers
+	case *DictPattern:
+		identifiers := []*Identifier{}
+		for _, entry := range pattern.Entries {
+			identifiers = append(identifiers, PatternIdentifiers(entry.Value)...)
+		}
+
+		if pattern.Rest != nil {
+			identifiers = append(identifiers, pattern.Rest)
+		}
+
+		return identifiers
+	default:
+		return nil
+	}
+}
+
+// SpreadExpression Expands array into call arguments or array literal elements
+type SpreadExpression struct {
+	Token token.Token // The '...' token
+	Value Expression
+}
+
+func (se *SpreadExpression) expressionNode() {}
+
+func (se *SpreadExpression) TokenLiteral() string {
+	return se.Token.Literal
+}
+
+func (se *SpreadExpression) String() string {
+	return "..." + se.Value.String()
+}
+
+// KeywordArgument Call argument passed by parameter name e.g. f(y: 2)
+type KeywordArgument struct {
+	Token token.Token // The parameter name token
+	Name  *Identifier
+	Value Expression
+}
+
+func (ka *KeywordArgument) expressionNode() {}
+
+func (ka *KeywordArgument) TokenLiteral() string {
+	return ka.Token.Literal
+}
+
+func (ka *KeywordArgument) String() string {
+	return ka.Name.String() + ": " + ka.Value.String()
+}
@@ -1,6 +1,12 @@
 package ast
 
 import (
+	goast "go/ast"
+	goparser "go/parser"
+	gotoken "go/token"
+	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/aeremic/cgo/token"
@@ -36,3 +42,478 @@ func TestString(t *testing.T) {
 		t.Errorf("program.String() not correct. Got %q", program.String())
 	}
 }
+
+func TestDictLiteralStringOrder(t *testing.T) {
+	key := func(literal string) Expression {
+		return &StringLiteral{Token: token.Token{Type: token.STRING, Literal: literal}, Value: literal}
+	}
+	value := func(literal string) Expression {
+		return &IntegerLiteral{Token: token.Token{Type: token.INT, Literal: literal}}
+	}
+
+	dict := &DictLiteral{
+		Token: token.Token{Type: token.LBRACE, Literal: "{"},
+		Elements: []DictLiteralElement{
+			{Key: key("z"), Value: value("1")},
+			{Key: key("a"), Value: value("2")},
+			{Key: key("m"), Value: value("3")},
+		},
+	}
+
+	for i := 0; i < 10; i++ {
+		if dict.String() != "{z:1, a:2, m:3}" {
+			t.Fatalf("dict.String() not in source order. Got %q", dict.String())
+		}
+	}
+}
+
+func TestModify(t *testing.T) {
+	one := func() Expression { return &IntegerLiteral{Value: 1} }
+	two := func() Expression { return &IntegerLiteral{Value: 2} }
+
+	turnOneIntoTwo := func(node Node) Node {
+		integer, ok := node.(*IntegerLiteral)
+		if !ok || integer.Value != 1 {
+			return node
+		}
+
+		integer.Value = 2
+
+		return integer
+	}
+
+	tests := []struct {
+		input    Node
+		expected Node
+	}{
+		{one(), two()},
+		{
+			&ProgramRoot{Statements: []Statement{&ExpressionStatement{Expression: one()}}},
+			&ProgramRoot{Statements: []Statement{&ExpressionStatement{Expression: two()}}},
+		},
+		{&InfixExpression{Left: one(), Operator: "+", Right: two()}, &InfixExpression{Left: two(), Operator: "+", Right: two()}},
+		{&PrefixExpression{Operator: "-", Right: one()}, &PrefixExpression{Operator: "-", Right: two()}},
+		{&IndexExpression{Left: one(), Index: one()}, &IndexExpression{Left: two(), Index: two()}},
+		{
+			&IfExpression{
+				Condition:   one(),
+				Consequence: &BlockStatement{Statements: []Statement{&ExpressionStatement{Expression: one()}}},
+				Alternative: &BlockStatement{Statements: []Statement{&ExpressionStatement{Expression: one()}}},
+			},
+			&IfExpression{
+				Condition:   two(),
+				Consequence: &BlockStatement{Statements: []Statement{&ExpressionStatement{Expression: two()}}},
+				Alternative: &BlockStatement{Statements: []Statement{&ExpressionStatement{Expression: two()}}},
+			},
+		},
+		{&ReturnStatement{ReturnValue: one()}, &ReturnStatement{ReturnValue: two()}},
+		{&LetStatement{Value: one()}, &LetStatement{Value: two()}},
+		{
+			&FunctionLiteral{
+				Parameters: []Pattern{},
+				Body:       &BlockStatement{Statements: []Statement{&ExpressionStatement{Expression: one()}}},
+			},
+			&FunctionLiteral{
+				Parameters: []Pattern{},
+				Body:       &BlockStatement{Statements: []Statement{&ExpressionStatement{Expression: two()}}},
+			},
+		},
+		{&ArrayLiteral{Elements: []Expression{one(), one()}}, &ArrayLiteral{Elements: []Expression{two(), two()}}},
+		{
+			&DictLiteral{Elements: []DictLiteralElement{{Key: one(), Value: one()}}},
+			&DictLiteral{Elements: []DictLiteralElement{{Key: two(), Value: two()}}},
+		},
+		{&CallExpression{Function: one(), Arguments: []Expression{one()}}, &CallExpression{Function: two(), Arguments: []Expression{two()}}},
+		{&SliceIndex{Start: one(), Step: one()}, &SliceIndex{Start: two(), Step: two()}},
+		{
+			&MatchExpression{Subject: one(), Arms: []MatchArm{{Pattern: &LiteralPattern{Value: one()}, Body: one()}}},
+			&MatchExpression{Subject: two(), Arms: []MatchArm{{Pattern: &LiteralPattern{Value: two()}, Body: two()}}},
+		},
+		{
+			&TryExpression{Block: &BlockStatement{Statements: []Statement{&ThrowStatement{Value: one()}}}},
+			&TryExpression{Block: &BlockStatement{Statements: []Statement{&ThrowStatement{Value: two()}}}},
+		},
+	}
+
+	for _, test := range tests {
+		modified, err := Modify(test.input, turnOneIntoTwo)
+		if err != nil {
+			t.Fatalf("modify failed: %s", err)
+		}
+
+		if !reflect.DeepEqual(modified, test.expected) {
+			t.Errorf("not equal. got=%#v, want=%#v", modified, test.expected)
+		}
+	}
+}
+
+func TestInspect(t *testing.T) {
+	call := &CallExpression{
+		Function:  &Identifier{Value: "f"},
+		Arguments: []Expression{&Identifier{Value: "b"}},
+	}
+	program := &ProgramRoot{
+		Statements: []Statement{
+			&ExpressionStatement{Expression: &InfixExpression{Left: &Identifier{Value: "a"}, Operator: "+", Right: call}},
+			&ExpressionStatement{Expression: &Identifier{Value: "c"}},
+		},
+	}
+
+	tests := []struct {
+		skip     Node
+		expected []string
+	}{
+		{nil, []string{"a", "f", "b", "c"}},
+		{call, []string{"a", "c"}},
+	}
+
+	for _, test := range tests {
+		identifiers := []string{}
+		Inspect(program, func(node Node) bool {
+			if identifier, ok := node.(*Identifier); ok {
+				identifiers = append(identifiers, identifier.Value)
+			}
+
+			return node != test.skip
+		})
+
+		if !reflect.DeepEqual(identifiers, test.expected) {
+			t.Errorf("wrong identifiers visited. got=%v, want=%v", identifiers, test.expected)
+		}
+	}
+}
+
+type depthVisitor struct {
+	depth    int
+	maxDepth *int
+	exits    *int
+}
+
+func (v depthVisitor) Visit(node Node) Visitor {
+	if node == nil {
+		*v.exits++
+		return nil
+	}
+
+	if v.depth > *v.maxDepth {
+		*v.maxDepth = v.depth
+	}
+
+	return depthVisitor{depth: v.depth + 1, maxDepth: v.maxDepth, exits: v.exits}
+}
+
+func TestWalk(t *testing.T) {
+	program := &ProgramRoot{
+		Statements: []Statement{
+			&LetStatement{
+				Name: &Identifier{Value: "x"},
+				Value: &PrefixExpression{
+					Operator: "-",
+					Right:    &IntegerLiteral{Value: 1},
+				},
+			},
+		},
+	}
+
+	maxDepth, exits := 0, 0
+	Walk(program, depthVisitor{maxDepth: &maxDepth, exits: &exits})
+
+	if maxDepth != 3 {
+		t.Errorf("wrong depth. got=%d, want=%d", maxDepth, 3)
+	}
+
+	if exits != 5 {
+		t.Errorf("wrong number of Visit(nil) calls. got=%d, want=%d", exits, 5)
+	}
+}
+
+// nodeSamples Returns one node of every type declared in the package
+func nodeSamples() []Node {
+	return []Node{
+		&ProgramRoot{}, &LetStatement{}, &Identifier{}, &ReturnStatement{}, &ExpressionStatement{},
+		&PrefixExpression{}, &InfixExpression{}, &IntegerLiteral{}, &StringLiteral{}, &Boolean{},
+		&NullLiteral{}, &IfExpression{}, &BlockStatement{}, &FunctionLiteral{}, &MacroLiteral{},
+		&CallExpression{}, &PipeExpression{}, &ArrayLiteral{}, &IndexExpression{}, &MemberExpression{},
+		&SliceIndex{}, &DictLiteral{}, &AssignExpression{}, &ImportStatement{}, &ExportStatement{},
+		&StructStatement{}, &ThrowStatement{}, &TryExpression{}, &MatchExpression{}, &ArrayPattern{},
+		&DictPattern{}, &DefaultPattern{}, &LiteralPattern{}, &WildcardPattern{}, &TypePattern{},
+		&SpreadExpression{}, &KeywordArgument{}, &NamedType{}, &ArrayType{}, &DictType{}, &FunctionType{},
+	}
+}
+
+func TestNodeSamplesCoverAllNodeTypes(t *testing.T) {
+	files, err := filepath.Glob("*.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	declared := map[string]bool{}
+	fileSet := gotoken.NewFileSet()
+	for _, file := range files {
+		if strings.HasSuffix(file, "_test.go") {
+			continue
+		}
+
+		parsed, err := goparser.ParseFile(fileSet, file, nil, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, declaration := range parsed.Decls {
+			function, ok := declaration.(*goast.FuncDecl)
+			if !ok || function.Recv == nil || function.Name.Name != "TokenLiteral" {
+				continue
+			}
+
+			if receiver, ok := function.Recv.List[0].Type.(*goast.StarExpr); ok {
+				declared[receiver.X.(*goast.Ident).Name] = true
+			}
+		}
+	}
+
+	sampled := map[string]bool{}
+	for _, node := range nodeSamples() {
+		sampled[reflect.TypeOf(node).Elem().Name()] = true
+	}
+
+	for name := range declared {
+		if !sampled[name] {
+			t.Errorf("node type %s is missing from nodeSamples, Walk and Modify aren't tested for it", name)
+		}
+	}
+}
+
+var (
+	statementType = reflect.TypeOf((*Statement)(nil)).Elem()
+	patternType   = reflect.TypeOf((*Pattern)(nil)).Elem()
+	typeExprType  = reflect.TypeOf((*TypeExpression)(nil)).Elem()
+	nodeType      = reflect.TypeOf((*Node)(nil)).Elem()
+	tokenType     = reflect.TypeOf(token.Token{})
+)
+
+// fillChildren Sets every nil child of the node held by value, statements
+// patterns and types get leaf nodes and other expressions integer literals
+func fillChildren(value reflect.Value) {
+	switch value.Kind() {
+	case reflect.Interface:
+		switch value.Type() {
+		case statementType:
+			value.Set(reflect.ValueOf(&ExpressionStatement{Expression: &IntegerLiteral{}}))
+		case patternType:
+			value.Set(reflect.ValueOf(&Identifier{}))
+		case typeExprType:
+			value.Set(reflect.ValueOf(&NamedType{}))
+		default:
+			value.Set(reflect.ValueOf(&IntegerLiteral{}))
+		}
+	case reflect.Pointer:
+		if value.IsNil() {
+			value.Set(reflect.New(value.Type().Elem()))
+		}
+
+		fillChildren(value.Elem())
+	case reflect.Slice:
+		value.Set(reflect.MakeSlice(value.Type(), 1, 1))
+		fillChildren(value.Index(0))
+	case reflect.Struct:
+		if value.Type() == tokenType {
+			return
+		}
+
+		for i := 0; i < value.NumField(); i++ {
+			fillChildren(value.Field(i))
+		}
+	}
+}
+
+// directChildren Returns nodes held by fields of value in declaration order.
+// Names held by *Identifier and *StringLiteral fields are reported as bindings.
+func directChildren(value reflect.Value) (children []Node, bindings []bool) {
+	switch value.Kind() {
+	case reflect.Interface:
+		if !value.IsNil() {
+			return []Node{value.Interface().(Node)}, []bool{false}
+		}
+	case reflect.Pointer:
+		if value.IsNil() {
+			return nil, nil
+		}
+
+		if value.Type().Implements(nodeType) {
+			binding := value.Type() == reflect.TypeOf(&Identifier{}) || value.Type() == reflect.TypeOf(&StringLiteral{})
+			return []Node{value.Interface().(Node)}, []bool{binding}
+		}
+
+		return directChildren(value.Elem())
+	case reflect.Slice:
+		for i := 0; i < value.Len(); i++ {
+			nodes, isBinding := directChildren(value.Index(i))
+			children, bindings = append(children, nodes...), append(bindings, isBinding...)
+		}
+	case reflect.Struct:
+		if value.Type() == tokenType {
+			return nil, nil
+		}
+
+		for i := 0; i < value.NumField(); i++ {
+			nodes, isBinding := directChildren(value.Field(i))
+			children, bindings = append(children, nodes...), append(bindings, isBinding...)
+		}
+	}
+
+	return children, bindings
+}
+
+func TestWalkVisitsEveryChild(t *testing.T) {
+	for _, node := range nodeSamples() {
+		fillChildren(reflect.ValueOf(node))
+		expected, _ := directChildren(reflect.ValueOf(node).Elem())
+
+		visited := []Node{}
+		Inspect(node, func(n Node) bool {
+			if n == node {
+				return true
+			}
+
+			if n != nil {
+				visited = append(visited, n)
+			}
+
+			return false
+		})
+
+		if len(visited) != len(expected) {
+			t.Errorf("%T: wrong number of children visited. got=%d, want=%d", node, len(visited), len(expected))
+			continue
+		}
+
+		for i := range expected {
+			if visited[i] != expected[i] {
+				t.Errorf("%T: child %d not visited in order. got=%T, want=%T", node, i, visited[i], expected[i])
+			}
+		}
+	}
+}
+
+func TestModifyReplacesEveryChild(t *testing.T) {
+	for _, node := range nodeSamples() {
+		fillChildren(reflect.ValueOf(node))
+		before, bindings := directChildren(reflect.ValueOf(node).Elem())
+
+		replaced := map[Node]bool{}
+		for i, child := range before {
+			if !bindings[i] {
+				replaced[child] = true
+			}
+		}
+
+		_, err := Modify(node, func(n Node) Node {
+			if !replaced[n] {
+				return n
+			}
+
+			clone := reflect.New(reflect.TypeOf(n).Elem())
+			clone.Elem().Set(reflect.ValueOf(n).Elem())
+
+			return clone.Interface().(Node)
+		})
+		if err != nil {
+			t.Errorf("%T: modify failed: %s", node, err)
+		}
+
+		after, _ := directChildren(reflect.ValueOf(node).Elem())
+		if len(after) != len(before) {
+			t.Errorf("%T: children dropped by Modify. got=%d, want=%d", node, len(after), len(before))
+			continue
+		}
+
+		for i := range before {
+			if bindings[i] && after[i] != before[i] {
+				t.Errorf("%T: binding child %d (%T) was modified", node, i, before[i])
+			}
+
+			if !bindings[i] && after[i] == before[i] {
+				t.Errorf("%T: child %d (%T) not replaced by Modify", node, i, before[i])
+			}
+		}
+	}
+}
+
+func TestModifyWrongKind(t *testing.T) {
+	program := &ProgramRoot{Statements: []Statement{
+		&ExpressionStatement{Expression: &IntegerLiteral{Value: 1}},
+	}}
+
+	// Block statement can't take place of an expression
+	modified, err := Modify(program, func(n Node) Node {
+		if _, ok := n.(*IntegerLiteral); ok {
+			return &BlockStatement{}
+		}
+
+		return n
+	})
+
+	expected := "cannot replace *ast.IntegerLiteral with *ast.BlockStatement, expression expected"
+	if err == nil || err.Error() != expected {
+		t.Errorf("wrong error. got=%v, want=%q", err, expected)
+	}
+
+	kept := modified.(*ProgramRoot).Statements[0].(*ExpressionStatement).Expression
+	if integer, ok := kept.(*IntegerLiteral); !ok || integer.Value != 1 {
+		t.Errorf("replaced child wasn't kept. got=%#v", kept)
+	}
+}
+
+func TestCopy(t *testing.T) {
+	for _, node := range nodeSamples() {
+		fillChildren(reflect.ValueOf(node))
+
+		copied := Copy(node)
+		if copied == node || !reflect.DeepEqual(copied, node) {
+			t.Errorf("%T: copy isn't equal and distinct. got=%#v", node, copied)
+			continue
+		}
+
+		original, _ := directChildren(reflect.ValueOf(node).Elem())
+		children, _ := directChildren(reflect.ValueOf(copied).Elem())
+		for i := range original {
+			if children[i] == original[i] {
+				t.Errorf("%T: child %d (%T) is shared with the copy", node, i, original[i])
+			}
+		}
+	}
+}
+
+func TestSpanCoversEveryNode(t *testing.T) {
+	for _, node := range nodeSamples() {
+		field := reflect.ValueOf(node).Elem().FieldByName("Token")
+		if !field.IsValid() {
+			continue
+		}
+
+		expected := token.Token{Type: token.IDENT, Literal: "x", Line: 2, Column: 3}
+		field.Set(reflect.ValueOf(expected))
+
+		first, last := Span(node)
+		if first != expected || last != expected {
+			t.Errorf("%T: wrong span. got=%+v..%+v, want=%+v", node, first, last, expected)
+		}
+	}
+
+	block := &BlockStatement{
+		Token: token.Token{Type: token.LBRACE, Literal: "{", Line: 1, Column: 10},
+		Statements: []Statement{
+			&ExpressionStatement{Expression: &InfixExpression{
+				Token: token.Token{Type: token.PLUS, Literal: "+", Line: 2, Column: 7},
+				Left:  &Identifier{Token: token.Token{Type: token.IDENT, Literal: "a", Line: 2, Column: 5}},
+				Right: &Identifier{},
+			}},
+		},
+		Rbrace: token.Token{Type: token.RBRACE, Literal: "}", Line: 3, Column: 1},
+	}
+
+	first, last := Span(block)
+	if first.Line != 1 || first.Column != 10 || last.Line != 3 || last.Column != 1 {
+		t.Errorf("wrong block span. got=%+v..%+v", first, last)
+	}
+}
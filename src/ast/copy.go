@@ -0,0 +1,62 @@
+package ast
+
+import "reflect"
+
+// Copy Returns deep copy of the tree, which can be modified without changing
+// the original. Tokens and names are copied by value.
+func Copy(node Node) Node {
+	if node == nil {
+		return nil
+	}
+
+	return copyValue(reflect.ValueOf(node)).Interface().(Node)
+}
+
+// copyValue Copies nodes held by pointers, interfaces, slices and struct
+// fields, other values are shared
+func copyValue(original reflect.Value) reflect.Value {
+	switch original.Kind() {
+	case reflect.Pointer:
+		if original.IsNil() {
+			return original
+		}
+
+		copied := reflect.New(original.Type().Elem())
+		copied.Elem().Set(copyValue(original.Elem()))
+
+		return copied
+	case reflect.Interface:
+		if original.IsNil() {
+			return original
+		}
+
+		copied := reflect.New(original.Type()).Elem()
+		copied.Set(copyValue(original.Elem()))
+
+		return copied
+	case reflect.Slice:
+		if original.IsNil() {
+			return original
+		}
+
+		copied := reflect.MakeSlice(original.Type(), original.Len(), original.Len())
+		for i := 0; i < original.Len(); i++ {
+			copied.Index(i).Set(copyValue(original.Index(i)))
+		}
+
+		return copied
+	case reflect.Struct:
+		copied := reflect.New(original.Type()).Elem()
+		copied.Set(original)
+
+		for i := 0; i < original.NumField(); i++ {
+			if copied.Field(i).CanSet() {
+				copied.Field(i).Set(copyValue(original.Field(i)))
+			}
+		}
+
+		return copied
+	default:
+		return original
+	}
+}
@@ -0,0 +1,254 @@
+package ast
+
+import "fmt"
+
+type ModifierFunc func(Node) Node
+
+// Modify Replaces nodes of the tree bottom-up with results of modifier.
+// Children are modified before their parent is passed to modifier. A child
+// replaced by a node of the wrong kind for its position is kept and error
+// is returned once the whole tree is modified. Binding names (let names,
+// catch params, struct and member names) are left untouched.
+func Modify(node Node, modifier ModifierFunc) (Node, error) {
+	m := &modification{modifier: modifier}
+	modified := m.node(node)
+
+	return modified, m.err
+}
+
+// modification Modifier applied to a tree, holding the first replacement
+// of the wrong kind
+type modification struct {
+	modifier ModifierFunc
+	err      error
+}
+
+func (m *modification) node(node Node) Node {
+	switch node := node.(type) {
+	case *ProgramRoot:
+		m.statements(node.Statements)
+	case *ExpressionStatement:
+		node.Expression = m.expression(node.Expression)
+	case *BlockStatement:
+		m.statements(node.Statements)
+	case *LetStatement:
+		if node.Pattern != nil {
+			node.Pattern = m.pattern(node.Pattern)
+		}
+
+		node.Type = m.typeExpression(node.Type)
+		node.Value = m.expression(node.Value)
+	case *ReturnStatement:
+		if node.ReturnValue != nil {
+			node.ReturnValue = m.expression(node.ReturnValue)
+		}
+	case *ExportStatement:
+		modified := m.node(node.Statement)
+		if statement, ok := modified.(*LetStatement); ok {
+			node.Statement = statement
+		} else {
+			m.wrongKind(node.Statement, modified, "let statement")
+		}
+	case *ThrowStatement:
+		node.Value = m.expression(node.Value)
+	case *StructStatement:
+		m.patterns(node.Fields)
+
+		for _, method := range node.Methods {
+			modified := m.node(method.Function)
+			if function, ok := modified.(*FunctionLiteral); ok {
+				method.Function = function
+			} else {
+				m.wrongKind(method.Function, modified, "function")
+			}
+		}
+	case *PrefixExpression:
+		node.Right = m.expression(node.Right)
+	case *InfixExpression:
+		node.Left = m.expression(node.Left)
+		node.Right = m.expression(node.Right)
+	case *IfExpression:
+		node.Condition = m.expression(node.Condition)
+		node.Consequence = m.block(node.Consequence)
+		node.Alternative = m.block(node.Alternative)
+	case *FunctionLiteral:
+		m.patterns(node.Parameters)
+		for i, t := range node.ParameterTypes {
+			node.ParameterTypes[i] = m.typeExpression(t)
+		}
+
+		node.ReturnType = m.typeExpression(node.ReturnType)
+		node.Body = m.block(node.Body)
+	case *MacroLiteral:
+		node.Body = m.block(node.Body)
+	case *CallExpression:
+		node.Function = m.expression(node.Function)
+		m.expressions(node.Arguments)
+	case *PipeExpression:
+		node.Left = m.expression(node.Left)
+		node.Right = m.expression(node.Right)
+	case *ArrayLiteral:
+		m.expressions(node.Elements)
+	case *IndexExpression:
+		node.Left = m.expression(node.Left)
+		node.Index = m.expression(node.Index)
+	case *MemberExpression:
+		node.Object = m.expression(node.Object)
+	case *SliceIndex:
+		node.Start = m.optional(node.Start)
+		node.End = m.optional(node.End)
+		node.Step = m.optional(node.Step)
+	case *DictLiteral:
+		for i, element := range node.Elements {
+			node.Elements[i].Key = m.expression(element.Key)
+			node.Elements[i].Value = m.expression(element.Value)
+		}
+	case *AssignExpression:
+		node.Target = m.expression(node.Target)
+		node.Value = m.expression(node.Value)
+	case *TryExpression:
+		node.Block = m.block(node.Block)
+		node.Catch = m.block(node.Catch)
+		node.Finally = m.block(node.Finally)
+	case *MatchExpression:
+		node.Subject = m.expression(node.Subject)
+
+		for i, arm := range node.Arms {
+			node.Arms[i].Pattern = m.pattern(arm.Pattern)
+			node.Arms[i].Guard = m.optional(arm.Guard)
+			node.Arms[i].Body = m.expression(arm.Body)
+		}
+	case *ArrayPattern:
+		m.patterns(node.Elements)
+	case *DictPattern:
+		for i, entry := range node.Entries {
+			node.Entries[i].Value = m.pattern(entry.Value)
+		}
+	case *DefaultPattern:
+		node.Target = m.pattern(node.Target)
+		node.Default = m.expression(node.Default)
+	case *LiteralPattern:
+		node.Value = m.expression(node.Value)
+	case *TypePattern:
+		if node.Binding != nil {
+			node.Binding = m.pattern(node.Binding)
+		}
+	case *SpreadExpression:
+		node.Value = m.expression(node.Value)
+	case *KeywordArgument:
+		node.Value = m.expression(node.Value)
+	case *ArrayType:
+		node.Element = m.typeExpression(node.Element)
+	case *DictType:
+		node.Key = m.typeExpression(node.Key)
+		node.Value = m.typeExpression(node.Value)
+	case *FunctionType:
+		for i, t := range node.Parameters {
+			node.Parameters[i] = m.typeExpression(t)
+		}
+
+		node.Return = m.typeExpression(node.Return)
+	}
+
+	return m.modifier(node)
+}
+
+// wrongKind Records error for child replaced by node which can't take its place
+func (m *modification) wrongKind(child, replacement Node, expected string) {
+	if m.err != nil {
+		return
+	}
+
+	found := "nothing"
+	if replacement != nil {
+		found = fmt.Sprintf("%T", replacement)
+	}
+
+	m.err = fmt.Errorf("cannot replace %T with %s, %s expected", child, found, expected)
+}
+
+func (m *modification) expression(expression Expression) Expression {
+	modified := m.node(expression)
+	if replaced, ok := modified.(Expression); ok {
+		return replaced
+	}
+
+	m.wrongKind(expression, modified, "expression")
+
+	return expression
+}
+
+// optional Modifies expression which may be absent
+func (m *modification) optional(expression Expression) Expression {
+	if expression == nil {
+		return nil
+	}
+
+	return m.expression(expression)
+}
+
+func (m *modification) expressions(expressions []Expression) {
+	for i, expression := range expressions {
+		expressions[i] = m.expression(expression)
+	}
+}
+
+func (m *modification) statements(statements []Statement) {
+	for i, statement := range statements {
+		modified := m.node(statement)
+		if replaced, ok := modified.(Statement); ok {
+			statements[i] = replaced
+		} else {
+			m.wrongKind(statement, modified, "statement")
+		}
+	}
+}
+
+func (m *modification) pattern(pattern Pattern) Pattern {
+	modified := m.node(pattern)
+	if replaced, ok := modified.(Pattern); ok {
+		return replaced
+	}
+
+	m.wrongKind(pattern, modified, "pattern")
+
+	return pattern
+}
+
+func (m *modification) patterns(patterns []Pattern) {
+	for i, pattern := range patterns {
+		patterns[i] = m.pattern(pattern)
+	}
+}
+
+// block Modifies block which may be absent
+func (m *modification) block(block *BlockStatement) *BlockStatement {
+	if block == nil {
+		return nil
+	}
+
+	modified := m.node(block)
+	if replaced, ok := modified.(*BlockStatement); ok {
+		return replaced
+	}
+
+	m.wrongKind(block, modified, "block")
+
+	return block
+}
+
+// typeExpression Modifies annotation which may be absent
+func (m *modification) typeExpression(t TypeExpression) TypeExpression {
+	if t == nil {
+		return nil
+	}
+
+	modified := m.node(t)
+	if replaced, ok := modified.(TypeExpression); ok {
+		return replaced
+	}
+
+	m.wrongKind(t, modified, "type")
+
+	return t
+}
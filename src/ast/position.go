@@ -0,0 +1,116 @@
+package ast
+
+import "github.com/aeremic/cgo/token"
+
+// Span Returns the first and the last source token of node. Nodes without
+// a position, like ones created by macro expansion, are skipped. Zero
+// tokens are returned when no node of the tree has a position.
+func Span(node Node) (first, last token.Token) {
+	Inspect(node, func(n Node) bool {
+		for _, t := range nodeTokens(n) {
+			if t.Line == 0 {
+				continue
+			}
+
+			if first.Line == 0 || t.Before(first) {
+				first = t
+			}
+
+			if last.Line == 0 || last.Before(t) {
+				last = t
+			}
+		}
+
+		return true
+	})
+
+	return first, last
+}
+
+// nodeTokens Returns tokens stored by node itself, not by its children
+func nodeTokens(node Node) []token.Token {
+	switch node := node.(type) {
+	case *LetStatement:
+		return []token.Token{node.Token}
+	case *Identifier:
+		return []token.Token{node.Token}
+	case *ReturnStatement:
+		return []token.Token{node.Token}
+	case *ExpressionStatement:
+		return []token.Token{node.Token}
+	case *PrefixExpression:
+		return []token.Token{node.Token}
+	case *InfixExpression:
+		return []token.Token{node.Token}
+	case *IntegerLiteral:
+		return []token.Token{node.Token}
+	case *StringLiteral:
+		return []token.Token{node.Token}
+	case *Boolean:
+		return []token.Token{node.Token}
+	case *NullLiteral:
+		return []token.Token{node.Token}
+	case *IfExpression:
+		return []token.Token{node.Token}
+	case *BlockStatement:
+		return []token.Token{node.Token, node.Rbrace}
+	case *FunctionLiteral:
+		return []token.Token{node.Token}
+	case *MacroLiteral:
+		return []token.Token{node.Token}
+	case *CallExpression:
+		return []token.Token{node.Token}
+	case *PipeExpression:
+		return []token.Token{node.Token}
+	case *ArrayLiteral:
+		return []token.Token{node.Token}
+	case *IndexExpression:
+		return []token.Token{node.Token}
+	case *MemberExpression:
+		return []token.Token{node.Token}
+	case *SliceIndex:
+		return []token.Token{node.Token}
+	case *DictLiteral:
+		return []token.Token{node.Token}
+	case *AssignExpression:
+		return []token.Token{node.Token}
+	case *ImportStatement:
+		return []token.Token{node.Token}
+	case *ExportStatement:
+		return []token.Token{node.Token}
+	case *StructStatement:
+		return []token.Token{node.Token}
+	case *ThrowStatement:
+		return []token.Token{node.Token}
+	case *TryExpression:
+		return []token.Token{node.Token}
+	case *MatchExpression:
+		return []token.Token{node.Token}
+	case *ArrayPattern:
+		return []token.Token{node.Token}
+	case *DictPattern:
+		return []token.Token{node.Token}
+	case *DefaultPattern:
+		return []token.Token{node.Token}
+	case *LiteralPattern:
+		return []token.Token{node.Token}
+	case *WildcardPattern:
+		return []token.Token{node.Token}
+	case *TypePattern:
+		return []token.Token{node.Token}
+	case *SpreadExpression:
+		return []token.Token{node.Token}
+	case *KeywordArgument:
+		return []token.Token{node.Token}
+	case *NamedType:
+		return []token.Token{node.Token}
+	case *ArrayType:
+		return []token.Token{node.Token}
+	case *DictType:
+		return []token.Token{node.Token}
+	case *FunctionType:
+		return []token.Token{node.Token}
+	default:
+		return nil
+	}
+}
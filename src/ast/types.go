@@ -0,0 +1,90 @@
+package ast
+
+import (
+	"strings"
+
+	"github.com/aeremic/cgo/token"
+)
+
+// TypeExpression Static type annotation of a let binding, parameter or return
+// value. Annotations are only used by the type checker, evaluation ignores them.
+type TypeExpression interface {
+	Node
+	typeNode()
+}
+
+// NamedType Builtin type like int or any, or name of a struct
+type NamedType struct {
+	Token token.Token // The type name token
+	Name  string
+}
+
+func (nt *NamedType) typeNode() {}
+
+func (nt *NamedType) TokenLiteral() string {
+	return nt.Token.Literal
+}
+
+func (nt *NamedType) String() string {
+	return nt.Name
+}
+
+// ArrayType Array with elements of the same type, [int] for an example
+type ArrayType struct {
+	Token   token.Token // The '[' token
+	Element TypeExpression
+}
+
+func (at *ArrayType) typeNode() {}
+
+func (at *ArrayType) TokenLiteral() string {
+	return at.Token.Literal
+}
+
+func (at *ArrayType) String() string {
+	return "[" + at.Element.String() + "]"
+}
+
+// DictType Dict with keys and values of the same types, {string: int} for an example
+type DictType struct {
+	Token token.Token // The '{' token
+	Key   TypeExpression
+	Value TypeExpression
+}
+
+func (dt *DictType) typeNode() {}
+
+func (dt *DictType) TokenLiteral() string {
+	return dt.Token.Literal
+}
+
+func (dt *DictType) String() string {
+	return "{" + dt.Key.String() + ": " + dt.Value.String() + "}"
+}
+
+// FunctionType Function signature, fn(int, string) -> bool for an example
+type FunctionType struct {
+	Token      token.Token // The 'fn' token
+	Parameters []TypeExpression
+	Return     TypeExpression // Any value may be returned when absent
+}
+
+func (ft *FunctionType) typeNode() {}
+
+func (ft *FunctionType) TokenLiteral() string {
+	return ft.Token.Literal
+}
+
+func (ft *FunctionType) String() string {
+	params := []string{}
+	for _, p := range ft.Parameters {
+		params = append(params, p.String())
+	}
+
+	out := "fn(" + strings.Join(params, ", ") + ")"
+	if ft.Return != nil {
+		out += " -> " + ft.Return.String()
+	}
+
+	return out
+}
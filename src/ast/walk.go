@@ -0,0 +1,247 @@
+package ast
+
+import "fmt"
+
+// Visitor Visit is called for every node reached by Walk. Children of node
+// are walked with the returned visitor, nil skips them.
+type Visitor interface {
+	Visit(node Node) (w Visitor)
+}
+
+// Walk Traverses the tree depth-first in source order. Visit(node) is called
+// first, then children are walked with the returned visitor and, when it's
+// not nil, Visit(nil) is called after the last child.
+func Walk(node Node, visitor Visitor) {
+	if visitor = visitor.Visit(node); visitor == nil {
+		return
+	}
+
+	switch node := node.(type) {
+	case *ProgramRoot:
+		walkStatements(node.Statements, visitor)
+	case *ExpressionStatement:
+		walkOptional(node.Expression, visitor)
+	case *BlockStatement:
+		walkStatements(node.Statements, visitor)
+	case *LetStatement:
+		if node.Name != nil {
+			Walk(node.Name, visitor)
+		}
+
+		walkOptional(node.Pattern, visitor)
+		walkOptional(node.Type, visitor)
+		walkOptional(node.Value, visitor)
+	case *ReturnStatement:
+		walkOptional(node.ReturnValue, visitor)
+	case *ImportStatement:
+		if node.Path != nil {
+			Walk(node.Path, visitor)
+		}
+
+		if node.Alias != nil {
+			Walk(node.Alias, visitor)
+		}
+
+		for _, name := range node.Names {
+			Walk(name, visitor)
+		}
+	case *ExportStatement:
+		if node.Statement != nil {
+			Walk(node.Statement, visitor)
+		}
+	case *ThrowStatement:
+		walkOptional(node.Value, visitor)
+	case *StructStatement:
+		if node.Name != nil {
+			Walk(node.Name, visitor)
+		}
+
+		walkPatterns(node.Fields, visitor)
+
+		for _, method := range node.Methods {
+			Walk(method.Name, visitor)
+			Walk(method.Function, visitor)
+		}
+	case *PrefixExpression:
+		walkOptional(node.Right, visitor)
+	case *InfixExpression:
+		walkOptional(node.Left, visitor)
+		walkOptional(node.Right, visitor)
+	case *IfExpression:
+		walkOptional(node.Condition, visitor)
+		if node.Consequence != nil {
+			Walk(node.Consequence, visitor)
+		}
+
+		if node.Alternative != nil {
+			Walk(node.Alternative, visitor)
+		}
+	case *FunctionLiteral:
+		walkPatterns(node.Parameters, visitor)
+		walkTypes(node.ParameterTypes, visitor)
+		if node.Rest != nil {
+			Walk(node.Rest, visitor)
+		}
+
+		walkOptional(node.ReturnType, visitor)
+		if node.Body != nil {
+			Walk(node.Body, visitor)
+		}
+	case *MacroLiteral:
+		for _, parameter := range node.Parameters {
+			Walk(parameter, visitor)
+		}
+
+		if node.Body != nil {
+			Walk(node.Body, visitor)
+		}
+	case *CallExpression:
+		walkOptional(node.Function, visitor)
+		walkExpressions(node.Arguments, visitor)
+	case *PipeExpression:
+		walkOptional(node.Left, visitor)
+		walkOptional(node.Right, visitor)
+	case *ArrayLiteral:
+		walkExpressions(node.Elements, visitor)
+	case *IndexExpression:
+		walkOptional(node.Left, visitor)
+		walkOptional(node.Index, visitor)
+	case *MemberExpression:
+		walkOptional(node.Object, visitor)
+		if node.Property != nil {
+			Walk(node.Property, visitor)
+		}
+	case *SliceIndex:
+		walkOptional(node.Start, visitor)
+		walkOptional(node.End, visitor)
+		walkOptional(node.Step, visitor)
+	case *DictLiteral:
+		for _, element := range node.Elements {
+			walkOptional(element.Key, visitor)
+			walkOptional(element.Value, visitor)
+		}
+	case *AssignExpression:
+		walkOptional(node.Target, visitor)
+		walkOptional(node.Value, visitor)
+	case *TryExpression:
+		if node.Block != nil {
+			Walk(node.Block, visitor)
+		}
+
+		if node.CatchParam != nil {
+			Walk(node.CatchParam, visitor)
+		}
+
+		if node.Catch != nil {
+			Walk(node.Catch, visitor)
+		}
+
+		if node.Finally != nil {
+			Walk(node.Finally, visitor)
+		}
+	case *MatchExpression:
+		walkOptional(node.Subject, visitor)
+
+		for _, arm := range node.Arms {
+			walkOptional(arm.Pattern, visitor)
+			walkOptional(arm.Guard, visitor)
+			walkOptional(arm.Body, visitor)
+		}
+	case *ArrayPattern:
+		walkPatterns(node.Elements, visitor)
+		if node.Rest != nil {
+			Walk(node.Rest, visitor)
+		}
+	case *DictPattern:
+		for _, entry := range node.Entries {
+			if entry.Key != nil {
+				Walk(entry.Key, visitor)
+			}
+
+			walkOptional(entry.Value, visitor)
+		}
+
+		if node.Rest != nil {
+			Walk(node.Rest, visitor)
+		}
+	case *DefaultPattern:
+		walkOptional(node.Target, visitor)
+		walkOptional(node.Default, visitor)
+	case *LiteralPattern:
+		walkOptional(node.Value, visitor)
+	case *TypePattern:
+		walkOptional(node.Binding, visitor)
+	case *SpreadExpression:
+		walkOptional(node.Value, visitor)
+	case *KeywordArgument:
+		if node.Name != nil {
+			Walk(node.Name, visitor)
+		}
+
+		walkOptional(node.Value, visitor)
+	case *ArrayType:
+		walkOptional(node.Element, visitor)
+	case *DictType:
+		walkOptional(node.Key, visitor)
+		walkOptional(node.Value, visitor)
+	case *FunctionType:
+		walkTypes(node.Parameters, visitor)
+		walkOptional(node.Return, visitor)
+	case *Identifier, *IntegerLiteral, *StringLiteral, *Boolean, *NullLiteral, *WildcardPattern, *NamedType:
+		// Leaf nodes
+	default:
+		panic(fmt.Sprintf("ast.Walk: unexpected node type %T", node))
+	}
+
+	visitor.Visit(nil)
+}
+
+type inspector func(Node) bool
+
+func (f inspector) Visit(node Node) Visitor {
+	if f(node) {
+		return f
+	}
+
+	return nil
+}
+
+// Inspect Traverses the tree calling f for every node in source order.
+// Children are skipped when f returns false. After the children of a node
+// f is called with nil.
+func Inspect(node Node, f func(Node) bool) {
+	Walk(node, inspector(f))
+}
+
+// walkOptional Walks node which may be absent
+func walkOptional(node Node, visitor Visitor) {
+	if node == nil {
+		return
+	}
+
+	Walk(node, visitor)
+}
+
+func walkStatements(statements []Statement, visitor Visitor) {
+	for _, statement := range statements {
+		walkOptional(statement, visitor)
+	}
+}
+
+func walkExpressions(expressions []Expression, visitor Visitor) {
+	for _, expression := range expressions {
+		walkOptional(expression, visitor)
+	}
+}
+
+func walkPatterns(patterns []Pattern, visitor Visitor) {
+	for _, pattern := range patterns {
+		walkOptional(pattern, visitor)
+	}
+}
+
+func walkTypes(types []TypeExpression, visitor Visitor) {
+	for _, t := range types {
+		walkOptional(t, visitor)
+	}
+}
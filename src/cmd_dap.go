@@ -0,0 +1,30 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/aeremic/cgo/dap"
+)
+
+// serveDebugAdapter Executes `cgo dap [file.cgo]`, debug adapter speaking
+// over stdin and stdout until the editor disconnects. Editors attach to the
+// given file or launch one of their choice.
+func serveDebugAdapter(args []string) int {
+	if len(args) > 1 {
+		fmt.Fprintln(os.Stderr, "usage: cgo dap [file.cgo]")
+		return 2
+	}
+
+	program := ""
+	if len(args) == 1 {
+		program = args[0]
+	}
+
+	if err := dap.NewServer(os.Stdin, os.Stdout, program).Serve(); err != nil {
+		fmt.Fprintf(os.Stderr, "dap: %s\n", err)
+		return 1
+	}
+
+	return 0
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/aeremic/cgo/debugger"
+	"github.com/aeremic/cgo/value"
+)
+
+// debugFile Executes `cgo debug file.cgo`, program pauses before its first
+// statement waiting for debugger commands. Exit code is 1 when the program
+// fails or is aborted.
+func debugFile(args []string) int {
+	if len(args) != 1 {
+		fmt.Fprintln(os.Stderr, "usage: cgo debug file.cgo")
+		return 2
+	}
+
+	result := debugger.NewConsole(os.Stdin, os.Stdout).Run(args[0])
+	if result == nil {
+		fmt.Println("program aborted")
+		return 1
+	}
+
+	if errWrapper, ok := result.(*value.Error); ok {
+		fmt.Fprintln(os.Stderr, errWrapper.Sprintf())
+		return 1
+	}
+
+	fmt.Println("program finished")
+
+	return 0
+}
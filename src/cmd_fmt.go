@@ -0,0 +1,82 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/aeremic/cgo/format"
+)
+
+// formatFiles Executes `cgo fmt [-l] [-w] [files]`. Formatted source is
+// printed unless -l or -w is given, without files stdin is formatted.
+func formatFiles(args []string) int {
+	flags := flag.NewFlagSet("fmt", flag.ContinueOnError)
+	list := flags.Bool("l", false, "list files whose formatting differs")
+	write := flags.Bool("w", false, "write result to source files")
+	flags.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: cgo fmt [-l] [-w] [file.cgo ...]")
+		flags.PrintDefaults()
+	}
+
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	if flags.NArg() == 0 {
+		source, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+
+		formatted, err := format.Source(source)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "<stdin>: %s\n", err)
+			return 1
+		}
+
+		os.Stdout.Write(formatted)
+
+		return 0
+	}
+
+	exitCode := 0
+	for _, path := range flags.Args() {
+		if err := formatFile(path, *list, *write); err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %s\n", path, err)
+			exitCode = 1
+		}
+	}
+
+	return exitCode
+}
+
+func formatFile(path string, list bool, write bool) error {
+	source, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	formatted, err := format.Source(source)
+	if err != nil {
+		return err
+	}
+
+	changed := !bytes.Equal(source, formatted)
+	if list && changed {
+		fmt.Println(path)
+	}
+
+	if write && changed {
+		return os.WriteFile(path, formatted, 0644)
+	}
+
+	if !list && !write {
+		os.Stdout.Write(formatted)
+	}
+
+	return nil
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/aeremic/cgo/lint"
+)
+
+// fileDiagnostic Diagnostic in JSON output of `cgo lint -json`
+type fileDiagnostic struct {
+	File string `json:"file"`
+	lint.Diagnostic
+}
+
+// lintFiles Executes `cgo lint [-json] [-enable rules] [-disable rules] files`.
+// Exit code is 1 when any diagnostic is reported.
+func lintFiles(args []string) int {
+	flags := flag.NewFlagSet("lint", flag.ContinueOnError)
+	jsonOutput := flags.Bool("json", false, "print diagnostics as JSON array")
+	enable := flags.String("enable", "", "comma separated rules to enable")
+	disable := flags.String("disable", "", "comma separated rules to disable")
+	listRules := flags.Bool("rules", false, "list available rules and exit")
+	flags.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: cgo lint [-json] [-enable rules] [-disable rules] file.cgo ...")
+		flags.PrintDefaults()
+	}
+
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	if *listRules {
+		for _, rule := range lint.Rules {
+			status := "enabled"
+			if !rule.Enabled {
+				status = "disabled"
+			}
+
+			fmt.Printf("%-18s %-8s %-9s %s\n", rule.ID, rule.Severity, status, rule.Description)
+		}
+
+		return 0
+	}
+
+	config := lint.Config{}
+	for _, setting := range []struct {
+		rules   string
+		enabled bool
+	}{{*enable, true}, {*disable, false}} {
+		for _, id := range strings.Split(setting.rules, ",") {
+			if id == "" {
+				continue
+			}
+
+			if _, ok := lint.FindRule(id); !ok {
+				fmt.Fprintf(os.Stderr, "unknown rule: %s\n", id)
+				return 2
+			}
+
+			config[id] = setting.enabled
+		}
+	}
+
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return 2
+	}
+
+	exitCode := 0
+	diagnostics := []fileDiagnostic{}
+	for _, path := range flags.Args() {
+		source, err := os.ReadFile(path)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			exitCode = 1
+			continue
+		}
+
+		found, err := lint.Source(source, config)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %s\n", path, err)
+			exitCode = 1
+			continue
+		}
+
+		for _, diagnostic := range found {
+			diagnostics = append(diagnostics, fileDiagnostic{File: path, Diagnostic: diagnostic})
+		}
+	}
+
+	if *jsonOutput {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		encoder.Encode(diagnostics)
+	} else {
+		for _, diagnostic := range diagnostics {
+			fmt.Printf("%s:%s\n", diagnostic.File, diagnostic.Diagnostic)
+		}
+	}
+
+	if len(diagnostics) > 0 {
+		exitCode = 1
+	}
+
+	return exitCode
+}
@@ -0,0 +1,24 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/aeremic/cgo/lsp"
+)
+
+// serveLanguageServer Executes `cgo lsp`, language server speaking over
+// stdin and stdout until the editor asks it to exit
+func serveLanguageServer(args []string) int {
+	if len(args) != 0 {
+		fmt.Fprintln(os.Stderr, "usage: cgo lsp")
+		return 2
+	}
+
+	if err := lsp.NewServer(os.Stdin, os.Stdout).Serve(); err != nil {
+		fmt.Fprintf(os.Stderr, "lsp: %s\n", err)
+		return 1
+	}
+
+	return 0
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/aeremic/cgo/evaluator"
+	"github.com/aeremic/cgo/trace"
+	"github.com/aeremic/cgo/value"
+)
+
+// runFile Executes `cgo [run] [-check] [-profile file] [-top n] [-trace file]
+// [-trace-format format] file.cgo`. Checked programs and modules they import
+// are rejected when they have type errors. Profiled programs write pprof profile
+// to the file and report of the n slowest functions to stderr, traced ones
+// write events of their evaluation. Exit code is 1 when the program fails.
+func runFile(args []string) int {
+	flags := flag.NewFlagSet("run", flag.ContinueOnError)
+	check := flags.Bool("check", false, "check types before evaluating and reject files with type errors")
+	profilePath := flags.String("profile", "", "write pprof profile of function calls to `file`")
+	top := flags.Int("top", 0, "print `n` functions with the most time spent in them")
+	tracePath := flags.String("trace", "", "write events of evaluation to `file`")
+	traceFormat := flags.String("trace-format", "jsonl", "format of trace events, jsonl or chrome")
+	flags.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: cgo [run] [-check] [-profile file] [-top n] [-trace file] [-trace-format format] file.cgo")
+		flags.PrintDefaults()
+	}
+
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return 2
+	}
+
+	evaluator.SetTypeChecking(*check)
+
+	if *tracePath != "" {
+		sink, err := openTrace(*tracePath, *traceFormat)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "unable to trace: %s\n", err)
+			return 2
+		}
+
+		evaluator.SetTracer(sink)
+		defer func() {
+			evaluator.SetTracer(nil)
+			if err := sink.Close(); err != nil {
+				fmt.Fprintf(os.Stderr, "unable to write trace: %s\n", err)
+			}
+		}()
+	}
+
+	profiling := *profilePath != "" || *top > 0
+	if profiling {
+		evaluator.StartProfiling()
+	}
+
+	result := evaluator.RunFile(flags.Arg(0))
+
+	exitCode := 0
+	if errWrapper, ok := result.(*value.Error); ok {
+		fmt.Fprintln(os.Stderr, errWrapper.Sprintf())
+		exitCode = 1
+	}
+
+	if profiling && !writeProfile(*profilePath, *top) && exitCode == 0 {
+		exitCode = 1
+	}
+
+	return exitCode
+}
+
+// writeProfile Writes profile recorded while running, reports false on failure
+func writeProfile(path string, top int) bool {
+	recorded := evaluator.StopProfiling()
+
+	if top > 0 {
+		recorded.WriteReport(os.Stderr, top)
+	}
+
+	if path == "" {
+		return true
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "unable to write profile: %s\n", err)
+		return false
+	}
+
+	defer file.Close()
+
+	if err := recorded.WritePprof(file); err != nil {
+		fmt.Fprintf(os.Stderr, "unable to write profile: %s\n", err)
+		return false
+	}
+
+	return true
+}
+
+// fileSink Trace sink closing the file it writes to
+type fileSink struct {
+	trace.Sink
+	file *os.File
+}
+
+func (s fileSink) Close() error {
+	err := s.Sink.Close()
+	if closeErr := s.file.Close(); err == nil {
+		err = closeErr
+	}
+
+	return err
+}
+
+// openTrace Creates file written by sink of the format
+func openTrace(path string, format string) (trace.Sink, error) {
+	var newSink func(w io.Writer) trace.Sink
+	switch format {
+	case "jsonl":
+		newSink = func(w io.Writer) trace.Sink { return trace.NewJSONLines(w) }
+	case "chrome":
+		newSink = func(w io.Writer) trace.Sink { return trace.NewChrome(w) }
+	default:
+		return nil, fmt.Errorf("unknown trace format: %s", format)
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return fileSink{Sink: newSink(file), file: file}, nil
+}
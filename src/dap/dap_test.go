@@ -0,0 +1,394 @@
+package dap
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+const program = `let fact = fn(n) {
+    if (n < 2) {
+        return 1
+    }
+    n * fact(n - 1)
+};
+let config = {"name": "cgo", "sizes": [1, 2]};
+let total = fact(3);
+puts(total);
+total`
+
+// received Response or event written by server
+type received struct {
+	Type    string          `json:"type"`
+	Event   string          `json:"event"`
+	Command string          `json:"command"`
+	Success bool            `json:"success"`
+	Message string          `json:"message"`
+	Body    json.RawMessage `json:"body"`
+}
+
+// client Talks to a server running in its own goroutine
+type client struct {
+	t      *testing.T
+	writer *io.PipeWriter
+	reader *bufio.Reader
+	seq    int
+	events []string        // Names of events read so far, except output
+	output strings.Builder // Text of output events
+	served chan error      // Receives result of Serve
+}
+
+// connect Writes the program to a temporary directory and starts a server
+// for it. Path of the program is returned and passed to the server when
+// attaching.
+func connect(t *testing.T, attach bool) (*client, string) {
+	path := filepath.Join(t.TempDir(), "main.cgo")
+	if err := os.WriteFile(path, []byte(program), 0o644); err != nil {
+		t.Fatalf("unable to write program: %s", err)
+	}
+
+	inReader, inWriter := io.Pipe()
+	outReader, outWriter := io.Pipe()
+
+	attached := ""
+	if attach {
+		attached = path
+	}
+
+	c := &client{t: t, writer: inWriter, reader: bufio.NewReader(outReader), served: make(chan error, 1)}
+	go func() {
+		c.served <- NewServer(inReader, outWriter, attached).Serve()
+		outWriter.Close()
+	}()
+
+	return c, path
+}
+
+// read Returns the next message written by server, collecting output
+func (c *client) read() received {
+	for {
+		header, err := textproto.NewReader(c.reader).ReadMIMEHeader()
+		if err != nil {
+			c.t.Fatalf("invalid header: %v", err)
+		}
+
+		length, _ := strconv.Atoi(header.Get("Content-Length"))
+		content := make([]byte, length)
+		if _, err := io.ReadFull(c.reader, content); err != nil {
+			c.t.Fatalf("short message: %v", err)
+		}
+
+		var msg received
+		if err := json.Unmarshal(content, &msg); err != nil {
+			c.t.Fatalf("invalid message %s: %v", content, err)
+		}
+
+		if msg.Event == "output" {
+			var body struct{ Output string }
+			json.Unmarshal(msg.Body, &body)
+			c.output.WriteString(body.Output)
+			continue
+		}
+
+		return msg
+	}
+}
+
+// request Sends request and returns its response, events written before it
+// are recorded
+func (c *client) request(command string, arguments interface{}) received {
+	c.seq++
+	content, _ := json.Marshal(map[string]interface{}{
+		"seq": c.seq, "type": "request", "command": command, "arguments": arguments,
+	})
+
+	// Server may be writing an event meanwhile, editors read and write concurrently too
+	go fmt.Fprintf(c.writer, "Content-Length: %d\r\n\r\n%s", len(content), content)
+
+	for {
+		msg := c.read()
+		if msg.Type == "response" {
+			if msg.Command != command {
+				c.t.Fatalf("response to %s received for %s", msg.Command, command)
+			}
+
+			return msg
+		}
+
+		c.events = append(c.events, msg.Event)
+	}
+}
+
+// body Sends request expected to succeed and decodes body of its response
+func (c *client) body(command string, arguments interface{}, target interface{}) {
+	msg := c.request(command, arguments)
+	if !msg.Success {
+		c.t.Fatalf("%s failed: %s", command, msg.Message)
+	}
+
+	if target != nil {
+		if err := json.Unmarshal(msg.Body, target); err != nil {
+			c.t.Fatalf("invalid %s body %s: %v", command, msg.Body, err)
+		}
+	}
+}
+
+// wait Reads messages until the event and returns its body
+func (c *client) wait(name string) json.RawMessage {
+	for {
+		msg := c.read()
+		if msg.Type == "event" {
+			c.events = append(c.events, msg.Event)
+			if msg.Event == name {
+				return msg.Body
+			}
+		}
+	}
+}
+
+// stopped Waits until the program pauses, returns reason and position of
+// the innermost frame as function:line
+func (c *client) stopped() (string, string) {
+	var event struct{ Reason string }
+	json.Unmarshal(c.wait("stopped"), &event)
+
+	frames := c.stack()
+
+	return event.Reason, frames[0]
+}
+
+// stack Returns frames of the paused program as function:line
+func (c *client) stack() []string {
+	var trace struct{ StackFrames []StackFrame }
+	c.body("stackTrace", map[string]int{"threadId": THREAD_ID}, &trace)
+
+	frames := []string{}
+	for _, frame := range trace.StackFrames {
+		frames = append(frames, fmt.Sprintf("%s:%d", frame.Name, frame.Line))
+	}
+
+	return frames
+}
+
+// variables Returns variables of the reference as name=value:type
+func (c *client) variables(reference int) ([]string, map[string]int) {
+	var body struct{ Variables []Variable }
+	c.body("variables", map[string]int{"variablesReference": reference}, &body)
+
+	variables := []string{}
+	references := map[string]int{}
+	for _, variable := range body.Variables {
+		variables = append(variables, fmt.Sprintf("%s=%s:%s", variable.Name, variable.Value, variable.Type))
+		references[variable.Name] = variable.VariablesReference
+	}
+
+	return variables, references
+}
+
+// disconnect Ends the session and checks that server stops cleanly
+func (c *client) disconnect() {
+	c.body("disconnect", nil, nil)
+
+	if err := <-c.served; err != nil {
+		c.t.Errorf("serve failed: %v", err)
+	}
+}
+
+func expectStrings(t *testing.T, what string, got []string, want ...string) {
+	if strings.Join(got, ", ") != strings.Join(want, ", ") {
+		t.Errorf("wrong %s. got=%v, want=%v", what, got, want)
+	}
+}
+
+func TestLaunchSession(t *testing.T) {
+	c, path := connect(t, false)
+
+	var capabilities map[string]bool
+	c.body("initialize", map[string]string{"adapterID": "cgo"}, &capabilities)
+	if !capabilities["supportsConfigurationDoneRequest"] || !capabilities["supportsConditionalBreakpoints"] {
+		t.Errorf("missing capabilities: %v", capabilities)
+	}
+
+	c.wait("initialized")
+	c.body("launch", map[string]string{"program": path}, nil)
+
+	var breakpoints struct{ Breakpoints []Breakpoint }
+	c.body("setBreakpoints", map[string]interface{}{
+		"source":      map[string]string{"path": path},
+		"breakpoints": []map[string]interface{}{{"line": 3, "condition": "n == 1"}, {"line": 99}},
+	}, &breakpoints)
+
+	if len(breakpoints.Breakpoints) != 2 {
+		t.Fatalf("wrong number of breakpoints. got=%d", len(breakpoints.Breakpoints))
+	}
+
+	if b := breakpoints.Breakpoints[0]; !b.Verified || b.ID != 1 || b.Line != 3 || b.Source.Path != path {
+		t.Errorf("wrong breakpoint. got=%+v", b)
+	}
+
+	if b := breakpoints.Breakpoints[1]; b.Verified || b.Message != "main.cgo has no line 99" {
+		t.Errorf("wrong invalid breakpoint. got=%+v", b)
+	}
+
+	c.body("configurationDone", nil, nil)
+
+	reason, position := c.stopped()
+	if reason != "breakpoint" || position != "fact:3" {
+		t.Errorf("wrong stop. got=%s at %s", reason, position)
+	}
+
+	var threads struct{ Threads []Thread }
+	c.body("threads", nil, &threads)
+	if len(threads.Threads) != 1 || threads.Threads[0].ID != THREAD_ID {
+		t.Errorf("wrong threads. got=%+v", threads.Threads)
+	}
+
+	expectStrings(t, "stack", c.stack(), "fact:3", "fact:5", "fact:5", "<main>:8")
+
+	var scopes struct{ Scopes []Scope }
+	c.body("scopes", map[string]int{"frameId": 1}, &scopes)
+	if len(scopes.Scopes) != 2 || scopes.Scopes[0].Name != "Locals" || scopes.Scopes[1].Name != "Globals" {
+		t.Fatalf("wrong scopes. got=%+v", scopes.Scopes)
+	}
+
+	locals, _ := c.variables(scopes.Scopes[0].VariablesReference)
+	expectStrings(t, "locals", locals, "n=1:INTEGER")
+
+	globals, references := c.variables(scopes.Scopes[1].VariablesReference)
+	expectStrings(t, "globals", globals[:1], "config={name: cgo, sizes: [1, 2]}:DICT")
+	if len(globals) != 2 || !strings.HasPrefix(globals[1], "fact=fn(n)") || !strings.HasSuffix(globals[1], ":FUNCTION") {
+		t.Errorf("wrong globals. got=%v", globals)
+	}
+
+	config, references := c.variables(references["config"])
+	expectStrings(t, "dict elements", config, "name=cgo:STRING", "sizes=[1, 2]:ARRAY")
+
+	sizes, _ := c.variables(references["sizes"])
+	expectStrings(t, "array elements", sizes, "[0]=1:INTEGER", "[1]=2:INTEGER")
+
+	var result struct{ Result string }
+	c.body("evaluate", map[string]interface{}{"expression": "n * 10", "frameId": 2}, &result)
+	if result.Result != "20" {
+		t.Errorf("wrong evaluation in frame 2. got=%s", result.Result)
+	}
+
+	c.body("evaluate", map[string]interface{}{"expression": "len(config)"}, &result)
+	if result.Result != "2" {
+		t.Errorf("wrong evaluation in global scope. got=%s", result.Result)
+	}
+
+	if msg := c.request("evaluate", map[string]interface{}{"expression": "missing", "frameId": 1}); msg.Success || msg.Message != "identifier not found: missing" {
+		t.Errorf("wrong failed evaluation. got=%+v", msg)
+	}
+
+	c.body("stepOut", map[string]int{"threadId": THREAD_ID}, nil)
+	if reason, position := c.stopped(); reason != "step" || position != "<main>:9" {
+		t.Errorf("wrong stop after step out. got=%s at %s", reason, position)
+	}
+
+	if msg := c.request("variables", map[string]int{"variablesReference": 5}); msg.Success {
+		t.Errorf("references expected to reset on resume")
+	}
+
+	c.body("continue", map[string]int{"threadId": THREAD_ID}, nil)
+
+	var exited struct{ ExitCode int }
+	json.Unmarshal(c.wait("exited"), &exited)
+	c.wait("terminated")
+
+	if exited.ExitCode != 0 {
+		t.Errorf("wrong exit code. got=%d", exited.ExitCode)
+	}
+
+	if c.output.String() != "6\n" {
+		t.Errorf("wrong output. got=%q", c.output.String())
+	}
+
+	c.disconnect()
+}
+
+func TestAttachSession(t *testing.T) {
+	c, _ := connect(t, true)
+
+	c.body("initialize", nil, nil)
+	c.body("attach", map[string]bool{"stopOnEntry": true}, nil)
+	c.body("configurationDone", nil, nil)
+
+	steps := []struct {
+		command  string
+		reason   string
+		position string
+	}{
+		{"", "entry", "<main>:1"},
+		{"next", "step", "<main>:7"},
+		{"next", "step", "<main>:8"},
+		{"stepIn", "step", "fact:2"},
+		{"next", "step", "fact:5"},
+		{"stepIn", "step", "fact:2"},
+	}
+
+	for _, step := range steps {
+		if step.command != "" {
+			c.body(step.command, map[string]int{"threadId": THREAD_ID}, nil)
+		}
+
+		if reason, position := c.stopped(); reason != step.reason || position != step.position {
+			t.Errorf("%s: wrong stop. got=%s at %s, want=%s at %s", step.command, reason, position, step.reason, step.position)
+		}
+	}
+
+	expectStrings(t, "stack", c.stack(), "fact:2", "fact:5", "<main>:8")
+
+	// Disconnecting aborts the paused program
+	c.disconnect()
+
+	expectStrings(t, "events", c.events, "initialized", "stopped", "stopped", "stopped", "stopped", "stopped", "stopped",
+		"exited", "terminated")
+
+	if c.output.String() != "" {
+		t.Errorf("aborted program printed %q", c.output.String())
+	}
+}
+
+func TestFailedRequests(t *testing.T) {
+	tests := []struct {
+		command   string
+		arguments string
+		message   string
+	}{
+		{"launch", `{}`, "missing program to launch"},
+		{"attach", `{}`, "no program to attach to, pass it to the adapter or launch one"},
+		{"configurationDone", `{}`, "no program launched"},
+		{"stackTrace", `{"threadId":1}`, "program is not paused"},
+		{"continue", `{"threadId":1}`, "program is not paused"},
+		{"pause", `{"threadId":1}`, "program is not running"},
+		{"restart", `{}`, "unsupported request: restart"},
+	}
+
+	var in, out bytes.Buffer
+	for i, test := range tests {
+		msg := fmt.Sprintf(`{"seq":%d,"type":"request","command":"%s","arguments":%s}`, i+1, test.command, test.arguments)
+		fmt.Fprintf(&in, "Content-Length: %d\r\n\r\n%s", len(msg), msg)
+	}
+
+	// Connection closes without disconnect request
+	if err := NewServer(&in, &out, "").Serve(); err != io.EOF {
+		t.Errorf("wrong serve error. got=%v, want=%v", err, io.EOF)
+	}
+
+	c := &client{t: t, reader: bufio.NewReader(&out)}
+	for _, test := range tests {
+		msg := c.read()
+		if msg.Command != test.command || msg.Success || msg.Message != test.message {
+			t.Errorf("%s: wrong response. got=%+v, want message %q", test.command, msg, test.message)
+		}
+	}
+}
@@ -0,0 +1,488 @@
+package dap
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/textproto"
+	"os"
+	"strconv"
+	"sync"
+
+	"github.com/aeremic/cgo/debugger"
+	"github.com/aeremic/cgo/evaluator"
+	"github.com/aeremic/cgo/value"
+)
+
+// Server Debug adapter running a cgo program under the debugger. The program
+// is evaluated in its own goroutine and blocks in it while paused, requests
+// inspecting it are answered only then.
+type Server struct {
+	reader      *bufio.Reader
+	writer      io.Writer
+	debugger    *debugger.Debugger
+	program     string        // Path of the debugged program, known up front when attaching
+	stopOnEntry bool          // Pause before the first statement of the program
+	next        func()        // Runs once response to the current request is written
+	resume      chan struct{} // Continues the paused program
+	quit        chan struct{} // Closed to abort the program
+	done        chan struct{} // Closed once the program ends, nil before it starts
+	references  []interface{} // Scopes and values expanded by variables request, by reference - 1
+	seq         int           // Sequence number of the last message written
+	writeMutex  sync.Mutex    // Guards writer and seq, the program writes events too
+	mutex       sync.Mutex    // Guards stopped
+	stopped     bool          // Set while the program is paused
+}
+
+// handler Handles request arguments and returns body of the response
+type handler func(s *Server, arguments json.RawMessage) (interface{}, error)
+
+var handlers map[string]handler
+
+func init() {
+	handlers = map[string]handler{
+		"initialize":        (*Server).initialize,
+		"launch":            (*Server).launch,
+		"attach":            (*Server).attach,
+		"setBreakpoints":    (*Server).setBreakpoints,
+		"configurationDone": (*Server).configurationDone,
+		"threads":           (*Server).threads,
+		"stackTrace":        (*Server).stackTrace,
+		"scopes":            (*Server).scopes,
+		"variables":         (*Server).variables,
+		"evaluate":          (*Server).evaluate,
+		"continue":          resumeHandler(debugger.Continue),
+		"next":              resumeHandler(debugger.StepOver),
+		"stepIn":            resumeHandler(debugger.StepIn),
+		"stepOut":           resumeHandler(debugger.StepOut),
+		"pause":             (*Server).pause,
+		"terminate":         (*Server).terminate,
+		"disconnect":        (*Server).terminate,
+	}
+}
+
+var (
+	errNotStopped = errors.New("program is not paused")
+	errNotRunning = errors.New("program is not running")
+)
+
+// NewServer Constructor of server reading requests from in and writing to
+// out. Program is the file clients attach to, empty when they launch one.
+func NewServer(in io.Reader, out io.Writer, program string) *Server {
+	s := &Server{
+		reader:  bufio.NewReader(in),
+		writer:  out,
+		program: program,
+		resume:  make(chan struct{}),
+		quit:    make(chan struct{}),
+	}
+	s.debugger = debugger.New(s.paused)
+
+	return s
+}
+
+// Serve Handles requests until disconnect request, which aborts the program
+// if it still runs. Error is returned when the connection fails or is
+// closed before disconnecting.
+func (s *Server) Serve() error {
+	for {
+		content, err := s.read()
+		if err != nil {
+			s.abort()
+			return err
+		}
+
+		var msg message
+		if err := json.Unmarshal(content, &msg); err != nil {
+			s.abort()
+			return fmt.Errorf("invalid message: %s", err)
+		}
+
+		if msg.Type != "request" {
+			continue
+		}
+
+		if err := s.handle(msg); err != nil {
+			s.abort()
+			return err
+		}
+
+		if msg.Command == "disconnect" {
+			return nil
+		}
+	}
+}
+
+// read Returns content of the next message framed by Content-Length header
+func (s *Server) read() ([]byte, error) {
+	header, err := textproto.NewReader(s.reader).ReadMIMEHeader()
+	if err != nil {
+		return nil, err
+	}
+
+	length, err := strconv.Atoi(header.Get("Content-Length"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid Content-Length header: %q", header.Get("Content-Length"))
+	}
+
+	content := make([]byte, length)
+	if _, err := io.ReadFull(s.reader, content); err != nil {
+		return nil, err
+	}
+
+	return content, nil
+}
+
+// write Numbers the message with the next sequence number and writes it
+func (s *Server) write(build func(seq int) interface{}) error {
+	s.writeMutex.Lock()
+	defer s.writeMutex.Unlock()
+
+	s.seq++
+	content, err := json.Marshal(build(s.seq))
+	if err != nil {
+		return err
+	}
+
+	if _, err := fmt.Fprintf(s.writer, "Content-Length: %d\r\n\r\n", len(content)); err != nil {
+		return err
+	}
+
+	_, err = s.writer.Write(content)
+
+	return err
+}
+
+func (s *Server) event(name string, body interface{}) error {
+	return s.write(func(seq int) interface{} {
+		return event{Seq: seq, Type: "event", Event: name, Body: body}
+	})
+}
+
+// handle Answers request, failed requests get an unsuccessful response.
+// Only failures of the connection are returned.
+func (s *Server) handle(msg message) error {
+	r := response{Type: "response", RequestSeq: msg.Seq, Command: msg.Command, Success: true}
+
+	handle, ok := handlers[msg.Command]
+	if !ok {
+		r.Success, r.Message = false, "unsupported request: "+msg.Command
+	} else if body, err := handle(s, msg.Arguments); err != nil {
+		r.Success, r.Message = false, err.Error()
+	} else {
+		r.Body = body
+	}
+
+	err := s.write(func(seq int) interface{} {
+		r.Seq = seq
+		return r
+	})
+
+	if next := s.next; next != nil {
+		s.next = nil
+		next()
+	}
+
+	return err
+}
+
+// decode Unmarshals request arguments, missing ones leave target unchanged
+func decode(arguments json.RawMessage, target interface{}) error {
+	if len(arguments) == 0 {
+		return nil
+	}
+
+	return json.Unmarshal(arguments, target)
+}
+
+func (s *Server) initialize(json.RawMessage) (interface{}, error) {
+	s.next = func() { s.event("initialized", nil) }
+
+	return map[string]interface{}{
+		"supportsConfigurationDoneRequest": true,
+		"supportsConditionalBreakpoints":   true,
+		"supportsEvaluateForHovers":        true,
+		"supportsTerminateRequest":         true,
+	}, nil
+}
+
+func (s *Server) launch(arguments json.RawMessage) (interface{}, error) {
+	var args launchArguments
+	if err := decode(arguments, &args); err != nil {
+		return nil, err
+	}
+
+	if args.Program == "" {
+		return nil, fmt.Errorf("missing program to launch")
+	}
+
+	s.program, s.stopOnEntry = args.Program, args.StopOnEntry
+
+	return nil, nil
+}
+
+// attach Debugs the program the server was started with
+func (s *Server) attach(arguments json.RawMessage) (interface{}, error) {
+	var args launchArguments
+	if err := decode(arguments, &args); err != nil {
+		return nil, err
+	}
+
+	if s.program == "" {
+		return nil, fmt.Errorf("no program to attach to, pass it to the adapter or launch one")
+	}
+
+	s.stopOnEntry = args.StopOnEntry
+
+	return nil, nil
+}
+
+func (s *Server) setBreakpoints(arguments json.RawMessage) (interface{}, error) {
+	var args setBreakpointsArguments
+	if err := decode(arguments, &args); err != nil {
+		return nil, err
+	}
+
+	s.debugger.ClearBreakpoints(args.Source.Path)
+
+	breakpoints := []Breakpoint{}
+	for _, requested := range args.Breakpoints {
+		added, err := s.debugger.AddBreakpoint(args.Source.Path, requested.Line, requested.Condition)
+		if err != nil {
+			breakpoints = append(breakpoints, Breakpoint{Message: err.Error(), Line: requested.Line})
+			continue
+		}
+
+		breakpoints = append(breakpoints, Breakpoint{
+			ID:       added.ID,
+			Verified: true,
+			Source:   source(added.File),
+			Line:     added.Line,
+		})
+	}
+
+	return map[string]interface{}{"breakpoints": breakpoints}, nil
+}
+
+// configurationDone Starts the program once the response is written
+func (s *Server) configurationDone(json.RawMessage) (interface{}, error) {
+	if s.program == "" {
+		return nil, fmt.Errorf("no program launched")
+	}
+
+	if s.done != nil {
+		return nil, fmt.Errorf("program already started")
+	}
+
+	s.done = make(chan struct{})
+	s.next = s.start
+
+	return nil, nil
+}
+
+// start Runs the program in its own goroutine, scripts print to output events
+func (s *Server) start() {
+	evaluator.SetOutput(output{s})
+
+	go func() {
+		defer close(s.done)
+		defer evaluator.SetOutput(os.Stdout)
+
+		exitCode := 0
+		switch result := s.debugger.Run(s.program, s.stopOnEntry).(type) {
+		case nil:
+			exitCode = 1
+		case *value.Error:
+			s.event("output", map[string]string{"category": "stderr", "output": result.Sprintf() + "\n"})
+			exitCode = 1
+		}
+
+		s.event("exited", map[string]int{"exitCode": exitCode})
+		s.event("terminated", nil)
+	}()
+}
+
+// output Writer turning text printed by scripts into output events
+type output struct {
+	s *Server
+}
+
+func (o output) Write(p []byte) (int, error) {
+	if err := o.s.event("output", map[string]string{"category": "stdout", "output": string(p)}); err != nil {
+		return 0, err
+	}
+
+	return len(p), nil
+}
+
+// paused Announces the stop and blocks the program until a request resumes
+// or aborts it
+func (s *Server) paused(reason string) {
+	s.mutex.Lock()
+	s.stopped = true
+	s.mutex.Unlock()
+
+	s.event("stopped", map[string]interface{}{"reason": reason, "threadId": THREAD_ID, "allThreadsStopped": true})
+
+	select {
+	case <-s.resume:
+	case <-s.quit:
+	}
+}
+
+// isStopped Reports whether the program is paused, its state may be inspected only then
+func (s *Server) isStopped() bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.stopped
+}
+
+func resumeHandler(mode debugger.Mode) handler {
+	return func(s *Server, arguments json.RawMessage) (interface{}, error) {
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+
+		if !s.stopped {
+			return nil, errNotStopped
+		}
+
+		s.stopped = false
+		s.references = nil
+		s.debugger.Resume(mode)
+		s.next = func() { s.resume <- struct{}{} }
+
+		if mode == debugger.Continue {
+			return map[string]bool{"allThreadsContinued": true}, nil
+		}
+
+		return nil, nil
+	}
+}
+
+func (s *Server) pause(json.RawMessage) (interface{}, error) {
+	if s.done == nil {
+		return nil, errNotRunning
+	}
+
+	s.debugger.Pause()
+
+	return nil, nil
+}
+
+// terminate Aborts the program and waits until it ends
+func (s *Server) terminate(json.RawMessage) (interface{}, error) {
+	s.abort()
+	return nil, nil
+}
+
+// abort Stops the running program, it's done once abort returns
+func (s *Server) abort() {
+	if s.done == nil {
+		return
+	}
+
+	select {
+	case <-s.quit:
+	default:
+		s.debugger.Quit()
+		close(s.quit)
+	}
+
+	<-s.done
+}
+
+func (s *Server) threads(json.RawMessage) (interface{}, error) {
+	return map[string]interface{}{"threads": []Thread{{ID: THREAD_ID, Name: "main"}}}, nil
+}
+
+func (s *Server) stackTrace(arguments json.RawMessage) (interface{}, error) {
+	var args stackTraceArguments
+	if err := decode(arguments, &args); err != nil {
+		return nil, err
+	}
+
+	if !s.isStopped() {
+		return nil, errNotStopped
+	}
+
+	stack := s.debugger.Stack()
+
+	frames := []StackFrame{}
+	for i, frame := range stack {
+		if i < args.StartFrame || args.Levels > 0 && i >= args.StartFrame+args.Levels {
+			continue
+		}
+
+		frames = append(frames, StackFrame{
+			ID:     i + 1,
+			Name:   frame.Function,
+			Source: source(frame.File),
+			Line:   frame.Line,
+			Column: 1,
+		})
+	}
+
+	return map[string]interface{}{"stackFrames": frames, "totalFrames": len(stack)}, nil
+}
+
+// frame Returns frame of the paused program with the ID, frames are
+// numbered from one starting at the innermost
+func (s *Server) frame(id int) (debugger.Frame, error) {
+	if !s.isStopped() {
+		return debugger.Frame{}, errNotStopped
+	}
+
+	stack := s.debugger.Stack()
+	if id < 1 || id > len(stack) {
+		return debugger.Frame{}, fmt.Errorf("no frame %d", id)
+	}
+
+	return stack[id-1], nil
+}
+
+func (s *Server) evaluate(arguments json.RawMessage) (interface{}, error) {
+	var args evaluateArguments
+	if err := decode(arguments, &args); err != nil {
+		return nil, err
+	}
+
+	if !s.isStopped() {
+		return nil, errNotStopped
+	}
+
+	// Without a frame code is evaluated at top level of the debugged file
+	id := args.FrameID
+	if id == 0 {
+		id = len(s.debugger.Stack())
+	}
+
+	frame, err := s.frame(id)
+	if err != nil {
+		return nil, err
+	}
+
+	program, err := debugger.Parse(args.Expression)
+	if err != nil {
+		return nil, err
+	}
+
+	result := s.debugger.Evaluate(program, frame.Env)
+	if result == nil {
+		result = evaluator.NULL
+	}
+
+	if errWrapper, ok := result.(*value.Error); ok {
+		return nil, fmt.Errorf("%s", errWrapper.Message)
+	}
+
+	variable := s.variable("", result)
+
+	return map[string]interface{}{
+		"result":             variable.Value,
+		"type":               variable.Type,
+		"variablesReference": variable.VariablesReference,
+	}, nil
+}
@@ -0,0 +1,112 @@
+package dap
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strconv"
+
+	"github.com/aeremic/cgo/value"
+)
+
+// source Describes the file frames and breakpoints are in
+func source(file string) *Source {
+	return &Source{Name: filepath.Base(file), Path: file}
+}
+
+// scopes Lists environments of the frame from the innermost. Calls see their
+// own scope, scopes of enclosing closures and the module scope at the end.
+func (s *Server) scopes(arguments json.RawMessage) (interface{}, error) {
+	var args scopesArguments
+	if err := decode(arguments, &args); err != nil {
+		return nil, err
+	}
+
+	frame, err := s.frame(args.FrameID)
+	if err != nil {
+		return nil, err
+	}
+
+	scopes := []Scope{}
+	for env := frame.Env; env != nil; env = env.Outer() {
+		name := "Closure"
+		switch {
+		case env.Outer() == nil:
+			name = "Globals"
+		case env == frame.Env:
+			name = "Locals"
+		}
+
+		scopes = append(scopes, Scope{Name: name, VariablesReference: s.reference(env)})
+	}
+
+	return map[string]interface{}{"scopes": scopes}, nil
+}
+
+// variables Lists names bound in a scope or elements of a value
+func (s *Server) variables(arguments json.RawMessage) (interface{}, error) {
+	var args variablesArguments
+	if err := decode(arguments, &args); err != nil {
+		return nil, err
+	}
+
+	if !s.isStopped() {
+		return nil, errNotStopped
+	}
+
+	if args.VariablesReference < 1 || args.VariablesReference > len(s.references) {
+		return nil, fmt.Errorf("no variables with reference %d", args.VariablesReference)
+	}
+
+	variables := []Variable{}
+	switch container := s.references[args.VariablesReference-1].(type) {
+	case *value.Environment:
+		for _, name := range container.Names() {
+			bound, _ := container.Get(name)
+			variables = append(variables, s.variable(name, bound))
+		}
+	case *value.Array:
+		for i, element := range container.Elements {
+			variables = append(variables, s.variable("["+strconv.Itoa(i)+"]", element))
+		}
+	case *value.Dict:
+		for _, pair := range container.Pairs() {
+			variables = append(variables, s.variable(pair.Key.Sprintf(), pair.Value))
+		}
+	case *value.Struct:
+		for i, field := range container.Definition.Fields {
+			variables = append(variables, s.variable(field, container.Values[i]))
+		}
+	}
+
+	return map[string]interface{}{"variables": variables}, nil
+}
+
+// variable Describes named value, non empty arrays, dicts and structs get
+// a reference their elements are listed with
+func (s *Server) variable(name string, wrapper value.Wrapper) Variable {
+	variable := Variable{Name: name, Value: wrapper.Sprintf(), Type: string(wrapper.Type())}
+
+	switch wrapper := wrapper.(type) {
+	case *value.Array:
+		if len(wrapper.Elements) > 0 {
+			variable.VariablesReference = s.reference(wrapper)
+		}
+	case *value.Dict:
+		if wrapper.Len() > 0 {
+			variable.VariablesReference = s.reference(wrapper)
+		}
+	case *value.Struct:
+		if len(wrapper.Values) > 0 {
+			variable.VariablesReference = s.reference(wrapper)
+		}
+	}
+
+	return variable
+}
+
+// reference Returns handle of the container, valid until the program resumes
+func (s *Server) reference(container interface{}) int {
+	s.references = append(s.references, container)
+	return len(s.references)
+}
@@ -0,0 +1,161 @@
+package debugger
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/aeremic/cgo/ast"
+	"github.com/aeremic/cgo/parser"
+	"github.com/aeremic/cgo/tokenizer"
+)
+
+// Breakpoint Stops evaluation before statements starting on the line,
+// conditional ones only when the condition is truthy
+type Breakpoint struct {
+	ID        int
+	File      string // Absolute path of the source file
+	Line      int
+	Condition ast.Expression // Evaluated in environment of the statement, optional
+}
+
+func (b *Breakpoint) String() string {
+	out := fmt.Sprintf("%d %s:%d", b.ID, filepath.Base(b.File), b.Line)
+	if b.Condition != nil {
+		out += " if " + b.Condition.String()
+	}
+
+	return out
+}
+
+// AddBreakpoint Adds breakpoint at line of the file, condition is optional.
+// Relative files are resolved from directory of the debugged file.
+func (d *Debugger) AddBreakpoint(file string, line int, condition string) (*Breakpoint, error) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	if file == "" {
+		file = d.file
+	} else if !filepath.IsAbs(file) {
+		file = filepath.Join(filepath.Dir(d.file), file)
+	}
+
+	lines := d.Source(file)
+	if lines == nil {
+		return nil, fmt.Errorf("unable to read %s", file)
+	}
+
+	if line < 1 || line > len(lines) {
+		return nil, fmt.Errorf("%s has no line %d", filepath.Base(file), line)
+	}
+
+	breakpoint := &Breakpoint{ID: d.nextID, File: file, Line: line}
+
+	if condition != "" {
+		expression, err := parseExpression(condition)
+		if err != nil {
+			return nil, err
+		}
+
+		breakpoint.Condition = expression
+	}
+
+	d.nextID++
+	d.breakpoints = append(d.breakpoints, breakpoint)
+
+	return breakpoint, nil
+}
+
+// DeleteBreakpoint Removes breakpoint with the ID
+func (d *Debugger) DeleteBreakpoint(id int) bool {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	for i, breakpoint := range d.breakpoints {
+		if breakpoint.ID == id {
+			d.breakpoints = append(d.breakpoints[:i], d.breakpoints[i+1:]...)
+			return true
+		}
+	}
+
+	return false
+}
+
+// ClearBreakpoints Removes breakpoints of the file, all when file is empty
+func (d *Debugger) ClearBreakpoints(file string) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	kept := []*Breakpoint{}
+	for _, breakpoint := range d.breakpoints {
+		if file != "" && breakpoint.File != file {
+			kept = append(kept, breakpoint)
+		}
+	}
+
+	d.breakpoints = kept
+}
+
+// Breakpoints Returns breakpoints in order they were added
+func (d *Debugger) Breakpoints() []*Breakpoint {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	return append([]*Breakpoint{}, d.breakpoints...)
+}
+
+// parseBreakpoint Splits breakpoint described as `[file:]line [if condition]`
+// into its file, line and condition
+func parseBreakpoint(spec string) (string, int, string, error) {
+	location, rest, _ := strings.Cut(strings.TrimSpace(spec), " ")
+
+	keyword, condition, _ := strings.Cut(strings.TrimSpace(rest), " ")
+	if keyword != "" && (keyword != "if" || strings.TrimSpace(condition) == "") {
+		return "", 0, "", fmt.Errorf("expected if condition after line")
+	}
+
+	file := ""
+	if separator := strings.LastIndex(location, ":"); separator >= 0 {
+		file = location[:separator]
+		location = location[separator+1:]
+	}
+
+	line, err := strconv.Atoi(location)
+	if err != nil {
+		return "", 0, "", fmt.Errorf("invalid line: %q", location)
+	}
+
+	return file, line, strings.TrimSpace(condition), nil
+}
+
+// parseExpression Parses source holding a single expression
+func parseExpression(source string) (ast.Expression, error) {
+	program, err := Parse(source)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(program.Statements) != 1 {
+		return nil, fmt.Errorf("expected single expression")
+	}
+
+	statement, ok := program.Statements[0].(*ast.ExpressionStatement)
+	if !ok {
+		return nil, fmt.Errorf("expected expression, got %s", program.Statements[0].TokenLiteral())
+	}
+
+	return statement.Expression, nil
+}
+
+// Parse Parses code evaluated in scope of a paused program
+func Parse(source string) (*ast.ProgramRoot, error) {
+	p := parser.New(tokenizer.New(source))
+
+	program := p.ParseProgram()
+	if len(p.Errors()) > 0 {
+		return nil, fmt.Errorf("%s", strings.Join(p.Errors(), "\n"))
+	}
+
+	return program, nil
+}
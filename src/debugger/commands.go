@@ -0,0 +1,182 @@
+package debugger
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type command struct {
+	names       []string // Full name first, then abbreviations
+	usage       string
+	description string
+	run         func(c *Console, args string) bool // Returns true when evaluation resumes
+}
+
+var commands []command
+
+func init() {
+	commands = []command{
+		{[]string{"break", "b"}, "break [file:]line [if condition]", "set breakpoint", breakCommand},
+		{[]string{"delete", "d"}, "delete [id]", "delete breakpoint, all without id", deleteCommand},
+		{[]string{"breakpoints", "bl"}, "breakpoints", "list breakpoints", breakpointsCommand},
+		{[]string{"continue", "c"}, "continue", "run until breakpoint", resumeCommand(Continue)},
+		{[]string{"step", "s"}, "step", "step to next statement, into calls", resumeCommand(StepIn)},
+		{[]string{"next", "n"}, "next", "step to next statement, over calls", resumeCommand(StepOver)},
+		{[]string{"out", "o"}, "out", "step out of current function", resumeCommand(StepOut)},
+		{[]string{"print", "p"}, "print expression", "print value of expression", printCommand},
+		{[]string{"eval", "e"}, "eval code", "evaluate statements in current scope", evalCommand},
+		{[]string{"locals"}, "locals", "print names bound in current scope", localsCommand},
+		{[]string{"stack", "bt"}, "stack", "print call stack", stackCommand},
+		{[]string{"list", "l"}, "list", "print source around current line", listCommand},
+		{[]string{"help", "h"}, "help", "print this help", helpCommand},
+		{[]string{"quit", "q"}, "quit", "abort program and exit", quitCommand},
+	}
+}
+
+func findCommand(name string) (command, bool) {
+	for _, command := range commands {
+		for _, n := range command.names {
+			if n == name {
+				return command, true
+			}
+		}
+	}
+
+	return command{}, false
+}
+
+func breakCommand(c *Console, args string) bool {
+	file, line, condition, err := parseBreakpoint(args)
+	if err != nil {
+		fmt.Fprintf(c.out, "error: %s\n", err)
+		return false
+	}
+
+	breakpoint, err := c.debugger.AddBreakpoint(file, line, condition)
+	if err != nil {
+		fmt.Fprintf(c.out, "error: %s\n", err)
+		return false
+	}
+
+	fmt.Fprintf(c.out, "breakpoint %s\n", breakpoint)
+
+	return false
+}
+
+func deleteCommand(c *Console, args string) bool {
+	if args == "" {
+		c.debugger.ClearBreakpoints("")
+		fmt.Fprintln(c.out, "deleted all breakpoints")
+
+		return false
+	}
+
+	id, err := strconv.Atoi(args)
+	if err != nil || !c.debugger.DeleteBreakpoint(id) {
+		fmt.Fprintf(c.out, "error: no breakpoint %s\n", args)
+		return false
+	}
+
+	fmt.Fprintf(c.out, "deleted breakpoint %d\n", id)
+
+	return false
+}
+
+func breakpointsCommand(c *Console, args string) bool {
+	breakpoints := c.debugger.Breakpoints()
+	if len(breakpoints) == 0 {
+		fmt.Fprintln(c.out, "no breakpoints")
+	}
+
+	for _, breakpoint := range breakpoints {
+		fmt.Fprintf(c.out, "breakpoint %s\n", breakpoint)
+	}
+
+	return false
+}
+
+func resumeCommand(mode Mode) func(c *Console, args string) bool {
+	return func(c *Console, args string) bool {
+		c.debugger.Resume(mode)
+		return true
+	}
+}
+
+func printCommand(c *Console, args string) bool {
+	expression, err := parseExpression(args)
+	if err != nil {
+		fmt.Fprintf(c.out, "error: %s\n", err)
+		return false
+	}
+
+	c.printResult(c.debugger.Evaluate(expression, c.current.Env))
+
+	return false
+}
+
+// evalCommand Evaluates code as if it was written at the current statement,
+// let statements bind names in the current scope
+func evalCommand(c *Console, args string) bool {
+	program, err := Parse(args)
+	if err != nil {
+		fmt.Fprintf(c.out, "error: %s\n", err)
+		return false
+	}
+
+	if result := c.debugger.Evaluate(program, c.current.Env); result != nil {
+		c.printResult(result)
+	}
+
+	return false
+}
+
+func localsCommand(c *Console, args string) bool {
+	for _, name := range c.current.Env.Names() {
+		local, _ := c.current.Env.Get(name)
+		fmt.Fprintf(c.out, "%s = %s\n", name, local.Sprintf())
+	}
+
+	return false
+}
+
+func stackCommand(c *Console, args string) bool {
+	for i, frame := range c.debugger.Stack() {
+		fmt.Fprintf(c.out, "#%d %s at %s:%d\n", i, frame.Function, c.relative(frame.File), frame.Line)
+	}
+
+	return false
+}
+
+// listCommand Prints five lines before and after the current one
+func listCommand(c *Console, args string) bool {
+	lines := c.debugger.Source(c.current.File)
+	for line := max(c.current.Line-5, 1); line <= min(c.current.Line+5, len(lines)); line++ {
+		marker := "  "
+		if line == c.current.Line {
+			marker = "> "
+		}
+
+		fmt.Fprintf(c.out, "%s%4d  %s\n", marker, line, lines[line-1])
+	}
+
+	return false
+}
+
+func helpCommand(c *Console, args string) bool {
+	for _, command := range commands {
+		usage := command.usage
+		if len(command.names) > 1 {
+			usage += " (" + strings.Join(command.names[1:], ", ") + ")"
+		}
+
+		fmt.Fprintf(c.out, "%-38s %s\n", usage, command.description)
+	}
+
+	return false
+}
+
+func quitCommand(c *Console, args string) bool {
+	c.debugger.Quit()
+	return true
+}
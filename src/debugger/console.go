@@ -0,0 +1,103 @@
+package debugger
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/aeremic/cgo/value"
+)
+
+const PROMPT = "(debug) "
+
+// Console Terminal front-end of the debugger, reads commands from input
+// whenever the program is paused
+type Console struct {
+	scanner  *bufio.Scanner
+	out      io.Writer
+	debugger *Debugger
+	current  Frame // Statement the program is paused at
+}
+
+// NewConsole Constructor of console reading commands from in and writing to out
+func NewConsole(in io.Reader, out io.Writer) *Console {
+	c := &Console{scanner: bufio.NewScanner(in), out: out}
+	c.debugger = New(c.pause)
+
+	return c
+}
+
+// Run Evaluates the file pausing before its first statement and returns the
+// result of evaluation. Nil is returned when the program is aborted by quit
+// command or end of input.
+func (c *Console) Run(path string) value.Wrapper {
+	return c.debugger.Run(path, true)
+}
+
+// pause Shows the statement and handles commands until one resumes evaluation
+func (c *Console) pause(reason string) {
+	c.current = c.debugger.Stack()[0]
+	c.showLine(c.current.File, c.current.Line, "> ")
+
+	for {
+		fmt.Fprint(c.out, PROMPT)
+		if !c.scanner.Scan() {
+			fmt.Fprintln(c.out)
+			c.debugger.Quit()
+			return
+		}
+
+		fields := strings.Fields(c.scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+
+		name, args := fields[0], strings.TrimSpace(strings.TrimPrefix(c.scanner.Text(), fields[0]))
+
+		command, ok := findCommand(name)
+		if !ok {
+			fmt.Fprintf(c.out, "unknown command: %s, type help for list of commands\n", name)
+			continue
+		}
+
+		if command.run(c, args) {
+			return
+		}
+	}
+}
+
+// printResult Prints value or error message of the result
+func (c *Console) printResult(result value.Wrapper) {
+	if result == nil {
+		fmt.Fprintln(c.out, "null")
+		return
+	}
+
+	if errWrapper, ok := result.(*value.Error); ok {
+		fmt.Fprintf(c.out, "error: %s\n", errWrapper.Message)
+		return
+	}
+
+	fmt.Fprintln(c.out, result.Sprintf())
+}
+
+// showLine Prints line of the file with its position
+func (c *Console) showLine(file string, line int, marker string) {
+	text := ""
+	if lines := c.debugger.Source(file); line >= 1 && line <= len(lines) {
+		text = lines[line-1]
+	}
+
+	fmt.Fprintf(c.out, "%s%s:%d\t%s\n", marker, c.relative(file), line, text)
+}
+
+// relative Returns path of the file relative to directory of the debugged file
+func (c *Console) relative(file string) string {
+	if relative, err := filepath.Rel(filepath.Dir(c.debugger.File()), file); err == nil && !strings.HasPrefix(relative, "..") {
+		return relative
+	}
+
+	return file
+}
@@ -0,0 +1,273 @@
+package debugger
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/aeremic/cgo/ast"
+	"github.com/aeremic/cgo/evaluator"
+	"github.com/aeremic/cgo/value"
+)
+
+// Mode How evaluation continues once the front-end returns from a pause
+type Mode int
+
+const (
+	Continue Mode = iota // Run until a breakpoint
+	StepIn               // Stop at the next statement
+	StepOver             // Stop at the next statement of the current or an enclosing call
+	StepOut              // Stop at the next statement of an enclosing call
+)
+
+// Reasons passed to the front-end when evaluation stops
+const (
+	ENTRY      = "entry"
+	STEP       = "step"
+	BREAKPOINT = "breakpoint"
+	PAUSE      = "pause"
+)
+
+// errQuit Aborts the debugged program, raised from the evaluator hook
+var errQuit = errors.New("quit")
+
+// Frame Statement a call or top level code of a module is at
+type Frame struct {
+	Function string // Name of the called function, <main> at top level
+	File     string
+	Line     int
+	Env      *value.Environment
+	id       int // ID of the call, zero at top level of a module
+}
+
+// Debugger Pauses evaluation of a program before statements at breakpoints
+// or while stepping. Front-end gets control in paused callback and the
+// program continues, in the mode set by Resume, once the callback returns.
+type Debugger struct {
+	paused     func(reason string)
+	file       string              // Absolute path of the debugged file
+	sources    map[string][]string // Lines of source files by path
+	frames     []Frame             // Statements calls are at, by call depth
+	evaluating bool                // Set while front-end evaluates code, hook ignores it
+
+	// Fields below may be changed by front-end while the program runs
+	mutex       sync.Mutex
+	breakpoints []*Breakpoint
+	nextID      int // ID given to the next breakpoint
+	mode        Mode
+	depth       int  // Call depth where step over or out started
+	pause       bool // Set by Pause, program stops at the next statement
+	quit        bool // Set by Quit, program is aborted at the next statement
+}
+
+// New Constructor of debugger calling paused on each stop of the program
+func New(paused func(reason string)) *Debugger {
+	return &Debugger{paused: paused, sources: map[string][]string{}, nextID: 1}
+}
+
+// Run Evaluates the file and returns the result of evaluation. Nil is
+// returned when the program is aborted by Quit.
+func (d *Debugger) Run(path string, stopOnEntry bool) (result value.Wrapper) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return &value.Error{Message: err.Error()}
+	}
+
+	d.file = absPath
+	d.frames = nil
+	d.Resume(Continue)
+	if stopOnEntry {
+		d.Resume(StepIn)
+	}
+
+	evaluator.SetDebugHook(d.hook)
+	defer evaluator.SetDebugHook(nil)
+
+	defer func() {
+		if r := recover(); r != nil {
+			if r != errQuit {
+				panic(r)
+			}
+
+			result = nil
+		}
+	}()
+
+	return evaluator.RunFile(path)
+}
+
+// File Returns absolute path of the debugged file
+func (d *Debugger) File() string {
+	return d.file
+}
+
+// Resume Sets how evaluation continues, starting from the current call
+func (d *Debugger) Resume(mode Mode) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	d.mode = mode
+	d.depth = len(d.frames) - 1
+}
+
+// Pause Stops the running program at its next statement
+func (d *Debugger) Pause() {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	d.pause = true
+}
+
+// Quit Aborts the program at its next statement, or once paused callback
+// returns when called from it
+func (d *Debugger) Quit() {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	d.quit = true
+}
+
+// Stack Returns frames of the paused program starting from the innermost
+func (d *Debugger) Stack() []Frame {
+	stack := []Frame{}
+	for i := len(d.frames) - 1; i >= 0; i-- {
+		stack = append(stack, d.frames[i])
+	}
+
+	return stack
+}
+
+// Evaluate Evaluates node in the environment without stopping in it
+func (d *Debugger) Evaluate(node ast.Node, env *value.Environment) value.Wrapper {
+	d.evaluating = true
+	defer func() { d.evaluating = false }()
+
+	return evaluator.Eval(node, env)
+}
+
+// hook Tracks statements of each call and pauses when a statement should stop
+func (d *Debugger) hook(node ast.Node, env *value.Environment) {
+	statement, ok := node.(ast.Statement)
+	if !ok || d.evaluating {
+		return
+	}
+
+	// Blocks and exports stop at statements they hold
+	switch statement.(type) {
+	case *ast.BlockStatement, *ast.ExportStatement:
+		return
+	}
+
+	first, _ := ast.Span(statement)
+	if first.Line == 0 {
+		// Statement made by macro expansion, it has no place in source
+		return
+	}
+
+	stack := evaluator.CallStack()
+	depth := len(stack)
+	current := Frame{Function: "<main>", File: env.File(), Line: first.Line, Env: env}
+	if depth > 0 {
+		current.Function = stack[0].Function
+		current.id = stack[0].ID
+	}
+
+	entry := len(d.frames) == 0
+	for len(d.frames) <= depth {
+		d.frames = append(d.frames, Frame{})
+	}
+
+	d.frames = d.frames[:depth+1]
+	previous := d.frames[depth]
+	d.frames[depth] = current
+
+	reason := d.stopReason(previous, current, depth)
+	if reason == STEP && entry {
+		reason = ENTRY
+	}
+
+	if reason != "" {
+		d.paused(reason)
+	}
+
+	d.mutex.Lock()
+	quit := d.quit
+	d.mutex.Unlock()
+
+	if quit {
+		panic(errQuit)
+	}
+}
+
+// stopReason Returns why evaluation stops at the current statement, empty
+// when it continues
+func (d *Debugger) stopReason(previous, current Frame, depth int) string {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	if d.quit {
+		return ""
+	}
+
+	if d.pause {
+		d.pause = false
+		return PAUSE
+	}
+
+	switch {
+	case d.mode == StepIn,
+		d.mode == StepOver && depth <= d.depth,
+		d.mode == StepOut && depth < d.depth:
+		return STEP
+	}
+
+	// Breakpoint stops once per line of a call, statements sharing the line don't stop again
+	newLine := previous.id != current.id || previous.File != current.File || previous.Line != current.Line
+	if newLine && d.breakpointHit(current) {
+		return BREAKPOINT
+	}
+
+	return ""
+}
+
+// breakpointHit Reports whether a breakpoint at the statement stops
+// evaluation. Conditions failing to evaluate stop it too.
+func (d *Debugger) breakpointHit(current Frame) bool {
+	for _, breakpoint := range d.breakpoints {
+		if breakpoint.File != current.File || breakpoint.Line != current.Line {
+			continue
+		}
+
+		if breakpoint.Condition == nil {
+			return true
+		}
+
+		result := d.Evaluate(breakpoint.Condition, current.Env)
+		if _, ok := result.(*value.Error); ok {
+			return true
+		}
+
+		if result != evaluator.NULL && result != evaluator.FALSE {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Source Returns lines of the file, nil when it can't be read
+func (d *Debugger) Source(file string) []string {
+	lines, ok := d.sources[file]
+	if !ok {
+		content, err := os.ReadFile(file)
+		if err == nil {
+			lines = strings.Split(strings.TrimSuffix(string(content), "\n"), "\n")
+		}
+
+		d.sources[file] = lines
+	}
+
+	return lines
+}
@@ -0,0 +1,180 @@
+package debugger
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const program = `let fact = fn(n) {
+    if (n < 2) {
+        return 1
+    }
+    n * fact(n - 1)
+};
+let xs = [1, 2, 3];
+each(xs, fn(x) {
+    let y = x * 10
+});
+import "lib.cgo";
+let total = fact(3);
+total`
+
+const library = `export let answer = 42;
+`
+
+// debug Runs the program with commands as input, returns result and output
+// of the debugger without prompts
+func debug(t *testing.T, commands ...string) (string, string) {
+	dir := t.TempDir()
+	for name, source := range map[string]string{"main.cgo": program, "lib.cgo": library} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(source), 0o644); err != nil {
+			t.Fatalf("unable to write source: %s", err)
+		}
+	}
+
+	var out bytes.Buffer
+	in := strings.NewReader(strings.Join(commands, "\n") + "\n")
+
+	result := NewConsole(in, &out).Run(filepath.Join(dir, "main.cgo"))
+
+	resultString := "<aborted>"
+	if result != nil {
+		resultString = result.Sprintf()
+	}
+
+	lines := []string{}
+	for _, line := range strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n") {
+		for strings.HasPrefix(line, PROMPT) {
+			line = strings.TrimPrefix(line, PROMPT)
+		}
+
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+
+	return resultString, strings.Join(lines, "\n")
+}
+
+func TestDebugger(t *testing.T) {
+	tests := []struct {
+		name     string
+		commands []string
+		result   string
+		output   []string
+	}{
+		{
+			"runs to end",
+			[]string{"c"},
+			"6",
+			[]string{"> main.cgo:1\tlet fact = fn(n) {"},
+		},
+		{
+			"steps over and into calls",
+			[]string{"n", "n", "n", "s", "s", "s", "out", "c"},
+			"6",
+			[]string{
+				"> main.cgo:1\tlet fact = fn(n) {",
+				"> main.cgo:7\tlet xs = [1, 2, 3];",
+				"> main.cgo:8\teach(xs, fn(x) {",
+				"> main.cgo:11\timport \"lib.cgo\";",
+				"> lib.cgo:1\texport let answer = 42;",
+				"> main.cgo:12\tlet total = fact(3);",
+				"> main.cgo:2\t    if (n < 2) {",
+				"> main.cgo:13\ttotal",
+			},
+		},
+		{
+			"stops at breakpoints once per call",
+			[]string{"break 9", "c", "p x", "c", "p x", "delete 1", "c"},
+			"6",
+			[]string{
+				"> main.cgo:1\tlet fact = fn(n) {",
+				"breakpoint 1 main.cgo:9",
+				"> main.cgo:9\t    let y = x * 10",
+				"1",
+				"> main.cgo:9\t    let y = x * 10",
+				"2",
+				"deleted breakpoint 1",
+			},
+		},
+		{
+			"conditional breakpoint",
+			[]string{"b 3 if n == 1", "c", "stack", "locals", "p [n, fact(n + 1)]", "c"},
+			"6",
+			[]string{
+				"> main.cgo:1\tlet fact = fn(n) {",
+				"breakpoint 1 main.cgo:3 if (n == 1)",
+				"> main.cgo:3\t        return 1",
+				"#0 fact at main.cgo:3",
+				"#1 fact at main.cgo:5",
+				"#2 fact at main.cgo:5",
+				"#3 <main> at main.cgo:12",
+				"n = 1",
+				"[1, 2]",
+			},
+		},
+		{
+			"breakpoint in imported file",
+			[]string{"b lib.cgo:1", "c", "locals", "n", "p lib.answer", "c"},
+			"6",
+			[]string{
+				"> main.cgo:1\tlet fact = fn(n) {",
+				"breakpoint 1 lib.cgo:1",
+				"> lib.cgo:1\texport let answer = 42;",
+				"> main.cgo:12\tlet total = fact(3);",
+				"42",
+			},
+		},
+		{
+			"evaluates code in current scope",
+			[]string{"b 13", "c", "e let total = total + 1", "p total", "p missing", "p 1 +", "c"},
+			"7",
+			[]string{
+				"> main.cgo:1\tlet fact = fn(n) {",
+				"breakpoint 1 main.cgo:13",
+				"> main.cgo:13\ttotal",
+				"7",
+				"error: identifier not found: missing",
+				"error: No prefix parse function found for type EOF",
+			},
+		},
+		{
+			"reports invalid commands",
+			[]string{"jump", "b 99", "b x", "b 2 if", "b 2 n", "b 2 if n > 1", "d 5", "bl", "q"},
+			"<aborted>",
+			[]string{
+				"> main.cgo:1\tlet fact = fn(n) {",
+				"unknown command: jump, type help for list of commands",
+				"error: main.cgo has no line 99",
+				"error: invalid line: \"x\"",
+				"error: expected if condition after line",
+				"error: expected if condition after line",
+				"breakpoint 1 main.cgo:2 if (n > 1)",
+				"error: no breakpoint 5",
+				"breakpoint 1 main.cgo:2 if (n > 1)",
+			},
+		},
+		{
+			"aborts at end of input",
+			[]string{"s"},
+			"<aborted>",
+			[]string{"> main.cgo:1\tlet fact = fn(n) {", "> main.cgo:7\tlet xs = [1, 2, 3];"},
+		},
+	}
+
+	for _, test := range tests {
+		result, output := debug(t, test.commands...)
+
+		if result != test.result {
+			t.Errorf("%s: wrong result. got=%s, want=%s", test.name, result, test.result)
+		}
+
+		if output != strings.Join(test.output, "\n") {
+			t.Errorf("%s: wrong output.\ngot:\n%s\nwant:\n%s", test.name, output, strings.Join(test.output, "\n"))
+		}
+	}
+}
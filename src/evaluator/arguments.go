@@ -0,0 +1,143 @@
+package evaluator
+
+import (
+	"github.com/aeremic/cgo/ast"
+	"github.com/aeremic/cgo/value"
+)
+
+type keywordArgument struct {
+	name  string
+	value value.Wrapper
+}
+
+// evalCallArguments Splits call arguments into positional values, with
+// spreads expanded, and keyword arguments. Arguments are evaluated in source
+// order once the call is checked for positional arguments after keyword ones.
+func evalCallArguments(exps []ast.Expression, env *value.Environment) ([]value.Wrapper, []keywordArgument, value.Wrapper) {
+	keywordSeen := false
+	for _, e := range exps {
+		_, isKeyword := e.(*ast.KeywordArgument)
+		if !isKeyword && keywordSeen {
+			return nil, nil, newKindError(value.TYPE_ERROR,
+				"positional argument follows keyword argument: %s", e.String())
+		}
+
+		keywordSeen = keywordSeen || isKeyword
+	}
+
+	var args []value.Wrapper
+	keywords := []keywordArgument{}
+
+	for _, e := range exps {
+		keyword, ok := e.(*ast.KeywordArgument)
+		if !ok {
+			evaluated := evalExpressions([]ast.Expression{e}, env)
+			if len(evaluated) == 1 && isError(evaluated[0]) {
+				return nil, nil, evaluated[0]
+			}
+
+			args = append(args, evaluated...)
+
+			continue
+		}
+
+		evaluated := Eval(keyword.Value, env)
+		if isError(evaluated) {
+			return nil, nil, evaluated
+		}
+
+		keywords = append(keywords, keywordArgument{name: keyword.Name.Value, value: evaluated})
+	}
+
+	return args, keywords, nil
+}
+
+// spreadElements Returns values a spread expression expands into
+func spreadElements(spread value.Wrapper) ([]value.Wrapper, *value.Error) {
+	switch spread := spread.(type) {
+	case *value.Array:
+		return spread.Elements, nil
+	case *value.Range:
+		return builtinToArray(spread).(*value.Array).Elements, nil
+	default:
+		return nil, newKindError(value.TYPE_ERROR, "cannot spread %s", spread.Type())
+	}
+}
+
+// parameterName Returns name a parameter can be passed by as a keyword argument
+func parameterName(param ast.Pattern) (string, bool) {
+	if defaultPattern, ok := param.(*ast.DefaultPattern); ok {
+		param = defaultPattern.Target
+	}
+
+	identifier, ok := param.(*ast.Identifier)
+	if !ok {
+		return "", false
+	}
+
+	return identifier.Value, true
+}
+
+// bindArguments Matches positional and keyword arguments to parameters,
+// fills in defaults and collects extra positional arguments into the rest
+// parameter. Defaults are evaluated in env so they can refer to earlier
+// parameters.
+func bindArguments(fn *value.Function, env *value.Environment, args []value.Wrapper, keywords []keywordArgument) *value.Error {
+	params := fn.Parameters
+
+	if len(args) > len(params) && fn.Rest == nil {
+		return newKindError(value.TYPE_ERROR, "wrong number of arguments. got=%d, want=%d",
+			len(args), len(params))
+	}
+
+	slots := make([]value.Wrapper, len(params))
+	copy(slots, args)
+
+	for _, keyword := range keywords {
+		idx := -1
+		for i, param := range params {
+			if name, ok := parameterName(param); ok && name == keyword.name {
+				idx = i
+				break
+			}
+		}
+
+		if idx == -1 {
+			return newKindError(value.TYPE_ERROR, "unexpected keyword argument: %s", keyword.name)
+		}
+
+		if slots[idx] != nil {
+			return newKindError(value.TYPE_ERROR, "multiple values for argument: %s", keyword.name)
+		}
+
+		slots[idx] = keyword.value
+	}
+
+	for i, param := range params {
+		if slots[i] != nil {
+			if err := bindPattern(param, slots[i], env); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		err := bindMissing(param, env, func() *value.Error {
+			return newKindError(value.TYPE_ERROR, "missing argument: %s", param.String())
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	if fn.Rest != nil {
+		rest := []value.Wrapper{}
+		if len(args) > len(params) {
+			rest = append(rest, args[len(params):]...)
+		}
+
+		env.Set(fn.Rest.Value, &value.Array{Elements: rest})
+	}
+
+	return nil
+}
@@ -2,38 +2,56 @@ package evaluator
 
 import (
 	"fmt"
+	"io"
+	"os"
+	"sort"
+	"unicode/utf8"
 
 	"github.com/aeremic/cgo/value"
 )
 
+// output Destination of text printed by puts
+var output io.Writer = os.Stdout
+
+// SetOutput Redirects text printed by scripts, stdout by default
+func SetOutput(w io.Writer) {
+	output = w
+}
+
 var builtins = map[string]*value.BuiltIn{
 	"len": {
+		Name: "len",
 		Fn: func(args ...value.Wrapper) value.Wrapper {
 			if len(args) != 1 {
-				return newError("wrong number of arguments. got=%d, want=%d",
+				return newKindError(value.TYPE_ERROR, "wrong number of arguments. got=%d, want=%d",
 					len(args), 1)
 			}
 
 			switch arg := args[0].(type) {
 			case *value.String:
-				return &value.Integer{Value: int64(len(arg.Value))}
+				return &value.Integer{Value: int64(utf8.RuneCountInString(arg.Value))}
 			case *value.Array:
 				return &value.Integer{Value: int64(len(arg.Elements))}
+			case *value.Dict:
+				return &value.Integer{Value: int64(arg.Len())}
+			case *value.Range:
+				return &value.Integer{Value: arg.Len()}
 			default:
-				return newError("argument to `len` not supported, got %s",
+				return newKindError(value.TYPE_ERROR, "argument to `len` not supported, got %s",
 					args[0].Type())
 			}
 		},
 	},
 	"first": {
+		Name: "first",
 		Fn: func(args ...value.Wrapper) value.Wrapper {
 			if len(args) != 1 {
-				return newError("wrong number of arguments. got=%d, want=%d",
+				return newKindError(value.TYPE_ERROR, "wrong number of arguments. got=%d, want=%d",
 					len(args), 1)
 			}
 
 			if args[0].Type() != value.ARRAY {
-				return newError("argument to `first` method must be an array. got=%s",
+				return newKindError(value.TYPE_ERROR, "argument to `first` method must be an array. got=%s",
 					args[0].Type())
 			}
 
@@ -46,14 +64,15 @@ var builtins = map[string]*value.BuiltIn{
 		},
 	},
 	"last": {
+		Name: "last",
 		Fn: func(args ...value.Wrapper) value.Wrapper {
 			if len(args) != 1 {
-				return newError("wrong number of arguments. got=%d, want=%d",
+				return newKindError(value.TYPE_ERROR, "wrong number of arguments. got=%d, want=%d",
 					len(args), 1)
 			}
 
 			if args[0].Type() != value.ARRAY {
-				return newError("argument to `first` method must be an array. got=%s",
+				return newKindError(value.TYPE_ERROR, "argument to `first` method must be an array. got=%s",
 					args[0].Type())
 			}
 
@@ -66,14 +85,15 @@ var builtins = map[string]*value.BuiltIn{
 		},
 	},
 	"tail": {
+		Name: "tail",
 		Fn: func(args ...value.Wrapper) value.Wrapper {
 			if len(args) != 1 {
-				return newError("wrong number of arguments. got=%d, want=%d",
+				return newKindError(value.TYPE_ERROR, "wrong number of arguments. got=%d, want=%d",
 					len(args), 1)
 			}
 
 			if args[0].Type() != value.ARRAY {
-				return newError("argument to `first` method must be an array. got=%s",
+				return newKindError(value.TYPE_ERROR, "argument to `first` method must be an array. got=%s",
 					args[0].Type())
 			}
 
@@ -90,34 +110,166 @@ var builtins = map[string]*value.BuiltIn{
 		},
 	},
 	"push": {
+		Name: "push",
 		Fn: func(args ...value.Wrapper) value.Wrapper {
 			if len(args) != 2 {
-				return newError("wrong number of arguments. got=%d, want=%d",
+				return newKindError(value.TYPE_ERROR, "wrong number of arguments. got=%d, want=%d",
 					len(args), 2)
 			}
 
 			if args[0].Type() != value.ARRAY {
-				return newError("argument to `first` method must be an array. got=%s",
+				return newKindError(value.TYPE_ERROR, "argument to `first` method must be an array. got=%s",
 					args[0].Type())
 			}
 
 			arr := args[0].(*value.Array)
 
 			length := len(arr.Elements)
-			newElements := make([]value.Wrapper, length+1, length+1)
+			newElements := make([]value.Wrapper, length, length+1)
 			copy(newElements, arr.Elements)
 			newElements = append(newElements, args[1])
 
 			return &value.Array{Elements: newElements}
 		},
 	},
+	"append!": {
+		Name: "append!",
+		Fn: func(args ...value.Wrapper) value.Wrapper {
+			if len(args) < 2 {
+				return newKindError(value.TYPE_ERROR, "wrong number of arguments. got=%d, want at least %d",
+					len(args), 2)
+			}
+
+			arr, ok := args[0].(*value.Array)
+			if !ok {
+				return newKindError(value.TYPE_ERROR, "argument to `append!` must be an array. got=%s",
+					args[0].Type())
+			}
+
+			arr.Elements = append(arr.Elements, args[1:]...)
+
+			return arr
+		},
+	},
+	"set": {
+		Name: "set",
+		Fn: func(args ...value.Wrapper) value.Wrapper {
+			if len(args) != 3 {
+				return newKindError(value.TYPE_ERROR, "wrong number of arguments. got=%d, want=%d",
+					len(args), 3)
+			}
+
+			result := evalIndexAssignment(args[0], args[1], args[2])
+			if isError(result) {
+				return result
+			}
+
+			return args[0]
+		},
+	},
+	"delete": {
+		Name: "delete",
+		Fn: func(args ...value.Wrapper) value.Wrapper {
+			if len(args) != 2 {
+				return newKindError(value.TYPE_ERROR, "wrong number of arguments. got=%d, want=%d",
+					len(args), 2)
+			}
+
+			switch container := args[0].(type) {
+			case *value.Array:
+				idx, ok := args[1].(*value.Integer)
+				if !ok {
+					return newKindError(value.TYPE_ERROR, "array index must be INTEGER. got=%s",
+						args[1].Type())
+				}
+
+				position, ok := resolveIndex(idx.Value, int64(len(container.Elements)))
+				if !ok {
+					return NULL
+				}
+
+				removed := container.Elements[position]
+				container.Elements = append(container.Elements[:position],
+					container.Elements[position+1:]...)
+
+				return removed
+			case *value.Dict:
+				key, ok := value.HashKeyOf(args[1])
+				if !ok {
+					return newKindError(value.TYPE_ERROR, "unusable as hash key: %s",
+						args[1].Type())
+				}
+
+				if _, ok := container.Lookup(key, args[1]); !ok {
+					return NULL
+				}
+
+				removed, _ := container.Delete(key)
+
+				return removed.Value
+			default:
+				return newKindError(value.TYPE_ERROR, "argument to `delete` not supported, got %s",
+					args[0].Type())
+			}
+		},
+	},
+	"copy": {
+		Name: "copy",
+		Fn: func(args ...value.Wrapper) value.Wrapper {
+			if len(args) != 1 {
+				return newKindError(value.TYPE_ERROR, "wrong number of arguments. got=%d, want=%d",
+					len(args), 1)
+			}
+
+			switch arg := args[0].(type) {
+			case *value.Array:
+				newElements := make([]value.Wrapper, len(arg.Elements))
+				copy(newElements, arg.Elements)
+
+				return &value.Array{Elements: newElements}
+			case *value.Dict:
+				newDict := value.NewDict()
+				for _, key := range arg.HashKeys() {
+					newDict.Set(key, arg.Elements[key])
+				}
+
+				return newDict
+			default:
+				return args[0]
+			}
+		},
+	},
+	"is_null": {
+		Name: "is_null",
+		Fn: func(args ...value.Wrapper) value.Wrapper {
+			if len(args) != 1 {
+				return newKindError(value.TYPE_ERROR, "wrong number of arguments. got=%d, want=%d",
+					len(args), 1)
+			}
+
+			return nativeBoolToBoolean(args[0] == NULL)
+		},
+	},
 	"puts": {
+		Name: "puts",
 		Fn: func(args ...value.Wrapper) value.Wrapper {
 			for _, arg := range args {
-				fmt.Println(arg.Sprintf())
+				fmt.Fprintln(output, arg.Sprintf())
 			}
 
 			return NULL
 		},
 	},
 }
+
+// BuiltinNames Returns names of all builtin functions in sorted order
+func BuiltinNames() []string {
+	names := make([]string, 0, len(builtins))
+	for name := range builtins {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
@@ -0,0 +1,648 @@
+package evaluator
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/aeremic/cgo/value"
+)
+
+// Collection builtins call back into user functions through applyFunction,
+// which depends on builtins itself, so they are registered in init to avoid
+// an initialization cycle.
+func init() {
+	collectionBuiltins := map[string]value.BuiltInFunction{
+		"map":       builtinMap,
+		"filter":    builtinFilter,
+		"reduce":    builtinReduce,
+		"each":      builtinEach,
+		"range":     builtinRange,
+		"zip":       builtinZip,
+		"enumerate": builtinEnumerate,
+		"sort":      builtinSort,
+		"reverse":   builtinReverse,
+		"slice":     builtinSlice,
+		"contains":  builtinContains,
+		"index_of":  builtinIndexOf,
+		"keys":      builtinKeys,
+		"values":    builtinValues,
+		"items":     builtinItems,
+		"to_array":  builtinToArray,
+		"sum":       builtinSum,
+	}
+
+	for name, fn := range collectionBuiltins {
+		builtins[name] = &value.BuiltIn{Fn: fn, Name: name}
+	}
+}
+
+func checkArgumentsCount(args []value.Wrapper, min, max int) *value.Error {
+	if len(args) >= min && len(args) <= max {
+		return nil
+	}
+
+	if min == max {
+		return newKindError(value.TYPE_ERROR, "wrong number of arguments. got=%d, want=%d",
+			len(args), min)
+	}
+
+	return newKindError(value.TYPE_ERROR, "wrong number of arguments. got=%d, want=%d..%d",
+		len(args), min, max)
+}
+
+func arrayArgument(name string, arg value.Wrapper) (*value.Array, *value.Error) {
+	arr, ok := arg.(*value.Array)
+	if !ok {
+		return nil, newKindError(value.TYPE_ERROR, "argument to `%s` must be an array. got=%s",
+			name, arg.Type())
+	}
+
+	return arr, nil
+}
+
+// sequence Elements of an array or a range. Range elements are created on
+// access so iterating a range doesn't allocate all of them.
+type sequence struct {
+	length int64
+	at     func(i int64) value.Wrapper
+}
+
+func sequenceArgument(name string, arg value.Wrapper) (sequence, *value.Error) {
+	switch arg := arg.(type) {
+	case *value.Array:
+		at := func(i int64) value.Wrapper { return arg.Elements[i] }
+		return sequence{length: int64(len(arg.Elements)), at: at}, nil
+	case *value.Range:
+		at := func(i int64) value.Wrapper { return &value.Integer{Value: arg.At(i)} }
+		return sequence{length: arg.Len(), at: at}, nil
+	default:
+		return sequence{}, newKindError(value.TYPE_ERROR,
+			"argument to `%s` must be an array or a range. got=%s", name, arg.Type())
+	}
+}
+
+func dictArgument(name string, arg value.Wrapper) (*value.Dict, *value.Error) {
+	dict, ok := arg.(*value.Dict)
+	if !ok {
+		return nil, newKindError(value.TYPE_ERROR, "argument to `%s` must be a dict. got=%s",
+			name, arg.Type())
+	}
+
+	return dict, nil
+}
+
+func functionArgument(name string, arg value.Wrapper) *value.Error {
+	switch arg.Type() {
+	case value.FUNCTION, value.BUILTIN:
+		return nil
+	default:
+		return newKindError(value.TYPE_ERROR, "argument to `%s` must be a function. got=%s",
+			name, arg.Type())
+	}
+}
+
+func integerArgument(name string, arg value.Wrapper) (int64, *value.Error) {
+	integer, ok := arg.(*value.Integer)
+	if !ok {
+		return 0, newKindError(value.TYPE_ERROR, "argument to `%s` must be an integer. got=%s",
+			name, arg.Type())
+	}
+
+	return integer.Value, nil
+}
+
+func builtinMap(args ...value.Wrapper) value.Wrapper {
+	if err := checkArgumentsCount(args, 2, 2); err != nil {
+		return err
+	}
+
+	seq, err := sequenceArgument("map", args[0])
+	if err != nil {
+		return err
+	}
+
+	if err := functionArgument("map", args[1]); err != nil {
+		return err
+	}
+
+	result := make([]value.Wrapper, 0, seq.length)
+	for i := int64(0); i < seq.length; i++ {
+		mapped := applyFunction(args[1], []value.Wrapper{seq.at(i)})
+		if isError(mapped) {
+			return mapped
+		}
+
+		result = append(result, mapped)
+	}
+
+	return &value.Array{Elements: result}
+}
+
+func builtinFilter(args ...value.Wrapper) value.Wrapper {
+	if err := checkArgumentsCount(args, 2, 2); err != nil {
+		return err
+	}
+
+	seq, err := sequenceArgument("filter", args[0])
+	if err != nil {
+		return err
+	}
+
+	if err := functionArgument("filter", args[1]); err != nil {
+		return err
+	}
+
+	result := []value.Wrapper{}
+	for i := int64(0); i < seq.length; i++ {
+		element := seq.at(i)
+		keep := applyFunction(args[1], []value.Wrapper{element})
+		if isError(keep) {
+			return keep
+		}
+
+		if isTruthy(keep) {
+			result = append(result, element)
+		}
+	}
+
+	return &value.Array{Elements: result}
+}
+
+// reduce(arr, fn(acc, element)[, initial]) starts from the first element
+// when no initial value is given
+func builtinReduce(args ...value.Wrapper) value.Wrapper {
+	if err := checkArgumentsCount(args, 2, 3); err != nil {
+		return err
+	}
+
+	seq, err := sequenceArgument("reduce", args[0])
+	if err != nil {
+		return err
+	}
+
+	if err := functionArgument("reduce", args[1]); err != nil {
+		return err
+	}
+
+	var accumulator value.Wrapper
+	first := int64(0)
+	if len(args) == 3 {
+		accumulator = args[2]
+	} else {
+		if seq.length == 0 {
+			return newError("reduce of empty array with no initial value")
+		}
+
+		accumulator = seq.at(0)
+		first = 1
+	}
+
+	for i := first; i < seq.length; i++ {
+		accumulator = applyFunction(args[1], []value.Wrapper{accumulator, seq.at(i)})
+		if isError(accumulator) {
+			return accumulator
+		}
+	}
+
+	return accumulator
+}
+
+func builtinEach(args ...value.Wrapper) value.Wrapper {
+	if err := checkArgumentsCount(args, 2, 2); err != nil {
+		return err
+	}
+
+	seq, err := sequenceArgument("each", args[0])
+	if err != nil {
+		return err
+	}
+
+	if err := functionArgument("each", args[1]); err != nil {
+		return err
+	}
+
+	for i := int64(0); i < seq.length; i++ {
+		result := applyFunction(args[1], []value.Wrapper{seq.at(i)})
+		if isError(result) {
+			return result
+		}
+	}
+
+	return NULL
+}
+
+// range(end), range(start, end) or range(start, end, step) with exclusive end
+func builtinRange(args ...value.Wrapper) value.Wrapper {
+	if err := checkArgumentsCount(args, 1, 3); err != nil {
+		return err
+	}
+
+	bounds := make([]int64, len(args))
+	for i, arg := range args {
+		bound, err := integerArgument("range", arg)
+		if err != nil {
+			return err
+		}
+
+		bounds[i] = bound
+	}
+
+	var start, end, step int64 = 0, 0, 1
+	switch len(bounds) {
+	case 1:
+		end = bounds[0]
+	case 2:
+		start, end = bounds[0], bounds[1]
+	case 3:
+		start, end, step = bounds[0], bounds[1], bounds[2]
+	}
+
+	if step == 0 {
+		return newError("range step must not be zero")
+	}
+
+	result := []value.Wrapper{}
+	for i := start; (step > 0 && i < end) || (step < 0 && i > end); i += step {
+		result = append(result, &value.Integer{Value: i})
+	}
+
+	return &value.Array{Elements: result}
+}
+
+// zip stops at the shortest array
+func builtinZip(args ...value.Wrapper) value.Wrapper {
+	if len(args) == 0 {
+		return newKindError(value.TYPE_ERROR, "wrong number of arguments. got=%d, want at least %d",
+			len(args), 1)
+	}
+
+	arrays := make([]*value.Array, len(args))
+	shortest := -1
+	for i, arg := range args {
+		arr, err := arrayArgument("zip", arg)
+		if err != nil {
+			return err
+		}
+
+		arrays[i] = arr
+		if shortest == -1 || len(arr.Elements) < shortest {
+			shortest = len(arr.Elements)
+		}
+	}
+
+	result := make([]value.Wrapper, 0, shortest)
+	for i := 0; i < shortest; i++ {
+		tuple := make([]value.Wrapper, len(arrays))
+		for j, arr := range arrays {
+			tuple[j] = arr.Elements[i]
+		}
+
+		result = append(result, &value.Array{Elements: tuple})
+	}
+
+	return &value.Array{Elements: result}
+}
+
+func builtinEnumerate(args ...value.Wrapper) value.Wrapper {
+	if err := checkArgumentsCount(args, 1, 1); err != nil {
+		return err
+	}
+
+	seq, err := sequenceArgument("enumerate", args[0])
+	if err != nil {
+		return err
+	}
+
+	result := make([]value.Wrapper, 0, seq.length)
+	for i := int64(0); i < seq.length; i++ {
+		pair := []value.Wrapper{&value.Integer{Value: i}, seq.at(i)}
+		result = append(result, &value.Array{Elements: pair})
+	}
+
+	return &value.Array{Elements: result}
+}
+
+// sort(arr[, comparator]) returns a new stably sorted array. Comparator
+// returns either a boolean (left goes first) or an integer (negative when
+// left goes first). Without comparator elements must be all integers or
+// all strings.
+func builtinSort(args ...value.Wrapper) value.Wrapper {
+	if err := checkArgumentsCount(args, 1, 2); err != nil {
+		return err
+	}
+
+	arr, err := arrayArgument("sort", args[0])
+	if err != nil {
+		return err
+	}
+
+	if len(args) == 2 {
+		if err := functionArgument("sort", args[1]); err != nil {
+			return err
+		}
+	}
+
+	result := make([]value.Wrapper, len(arr.Elements))
+	copy(result, arr.Elements)
+
+	var sortErr value.Wrapper
+	less := func(i, j int) bool {
+		if sortErr != nil {
+			return false
+		}
+
+		if len(args) == 1 {
+			ordered, err := defaultLess(result[i], result[j])
+			if err != nil {
+				sortErr = err
+			}
+
+			return ordered
+		}
+
+		compared := applyFunction(args[1], []value.Wrapper{result[i], result[j]})
+		switch compared := compared.(type) {
+		case *value.Boolean:
+			return compared.Value
+		case *value.Integer:
+			return compared.Value < 0
+		case *value.Error:
+			sortErr = compared
+		default:
+			sortErr = newError("sort comparator must return BOOLEAN or INTEGER. got=%s",
+				compared.Type())
+		}
+
+		return false
+	}
+
+	sort.SliceStable(result, less)
+	if sortErr != nil {
+		return sortErr
+	}
+
+	return &value.Array{Elements: result}
+}
+
+func defaultLess(left, right value.Wrapper) (bool, *value.Error) {
+	switch left := left.(type) {
+	case *value.Integer:
+		if right, ok := right.(*value.Integer); ok {
+			return left.Value < right.Value, nil
+		}
+	case *value.String:
+		if right, ok := right.(*value.String); ok {
+			return left.Value < right.Value, nil
+		}
+	}
+
+	return false, newError("unable to compare %s and %s, provide a comparator",
+		left.Type(), right.Type())
+}
+
+func builtinReverse(args ...value.Wrapper) value.Wrapper {
+	if err := checkArgumentsCount(args, 1, 1); err != nil {
+		return err
+	}
+
+	if str, ok := args[0].(*value.String); ok {
+		runes := []rune(str.Value)
+		for i, j := 0, len(runes)-1; i < j; i, j = i+1, j-1 {
+			runes[i], runes[j] = runes[j], runes[i]
+		}
+
+		return &value.String{Value: string(runes)}
+	}
+
+	arr, err := arrayArgument("reverse", args[0])
+	if err != nil {
+		return err
+	}
+
+	length := len(arr.Elements)
+	result := make([]value.Wrapper, length)
+	for i, element := range arr.Elements {
+		result[length-1-i] = element
+	}
+
+	return &value.Array{Elements: result}
+}
+
+// slice(arr, start[, end]) copies elements in [start, end) like arr[start:end],
+// negative bounds count from the end. Strings are sliced by runes.
+func builtinSlice(args ...value.Wrapper) value.Wrapper {
+	if err := checkArgumentsCount(args, 2, 3); err != nil {
+		return err
+	}
+
+	var runes []rune
+	var arr *value.Array
+	var length int64
+
+	if str, ok := args[0].(*value.String); ok {
+		runes = []rune(str.Value)
+		length = int64(len(runes))
+	} else {
+		var err *value.Error
+		arr, err = arrayArgument("slice", args[0])
+		if err != nil {
+			return err
+		}
+
+		length = int64(len(arr.Elements))
+	}
+
+	bounds := []value.Wrapper{nil, nil}
+	for i, arg := range args[1:] {
+		if _, err := integerArgument("slice", arg); err != nil {
+			return err
+		}
+
+		bounds[i] = arg
+	}
+
+	positions, err := slicePositions(length, bounds[0], bounds[1], nil)
+	if err != nil {
+		return err
+	}
+
+	start, end := positions.Start, positions.Start+positions.Len()
+
+	if arr == nil {
+		return &value.String{Value: string(runes[start:end])}
+	}
+
+	result := make([]value.Wrapper, end-start)
+	copy(result, arr.Elements[start:end])
+
+	return &value.Array{Elements: result}
+}
+
+// contains(arr, element) checks elements, contains(dict, key) checks keys
+// and contains(str, substring) checks substrings
+func builtinContains(args ...value.Wrapper) value.Wrapper {
+	if err := checkArgumentsCount(args, 2, 2); err != nil {
+		return err
+	}
+
+	switch container := args[0].(type) {
+	case *value.String:
+		substring, err := stringArgument("contains", args[1])
+		if err != nil {
+			return err
+		}
+
+		return nativeBoolToBoolean(strings.Contains(container.Value, substring))
+	case *value.Array:
+		for _, element := range container.Elements {
+			if value.Equal(element, args[1]) {
+				return TRUE
+			}
+		}
+
+		return FALSE
+	case *value.Range:
+		integer, ok := args[1].(*value.Integer)
+		if !ok {
+			return FALSE
+		}
+
+		offset := integer.Value - container.Start
+		position := offset / container.Step
+
+		return nativeBoolToBoolean(offset%container.Step == 0 &&
+			position >= 0 && position < container.Len())
+	case *value.Dict:
+		key, ok := value.HashKeyOf(args[1])
+		if !ok {
+			return newKindError(value.TYPE_ERROR, "unusable as hash key: %s", args[1].Type())
+		}
+
+		_, exists := container.Lookup(key, args[1])
+
+		return nativeBoolToBoolean(exists)
+	default:
+		return newKindError(value.TYPE_ERROR, "argument to `contains` not supported, got %s",
+			args[0].Type())
+	}
+}
+
+// index_of returns position of the first equal element or -1
+func builtinIndexOf(args ...value.Wrapper) value.Wrapper {
+	if err := checkArgumentsCount(args, 2, 2); err != nil {
+		return err
+	}
+
+	seq, err := sequenceArgument("index_of", args[0])
+	if err != nil {
+		return err
+	}
+
+	for i := int64(0); i < seq.length; i++ {
+		if value.Equal(seq.at(i), args[1]) {
+			return &value.Integer{Value: i}
+		}
+	}
+
+	return &value.Integer{Value: -1}
+}
+
+// sum(seq) adds up integers, sum of an empty sequence is 0
+func builtinSum(args ...value.Wrapper) value.Wrapper {
+	if err := checkArgumentsCount(args, 1, 1); err != nil {
+		return err
+	}
+
+	seq, err := sequenceArgument("sum", args[0])
+	if err != nil {
+		return err
+	}
+
+	var total int64
+	for i := int64(0); i < seq.length; i++ {
+		element := seq.at(i)
+
+		integer, ok := element.(*value.Integer)
+		if !ok {
+			return newKindError(value.TYPE_ERROR, "unable to sum %s", element.Type())
+		}
+
+		total += integer.Value
+	}
+
+	return &value.Integer{Value: total}
+}
+
+func builtinKeys(args ...value.Wrapper) value.Wrapper {
+	if err := checkArgumentsCount(args, 1, 1); err != nil {
+		return err
+	}
+
+	dict, err := dictArgument("keys", args[0])
+	if err != nil {
+		return err
+	}
+
+	result := make([]value.Wrapper, 0, dict.Len())
+	for _, element := range dict.Pairs() {
+		result = append(result, element.Key)
+	}
+
+	return &value.Array{Elements: result}
+}
+
+func builtinValues(args ...value.Wrapper) value.Wrapper {
+	if err := checkArgumentsCount(args, 1, 1); err != nil {
+		return err
+	}
+
+	dict, err := dictArgument("values", args[0])
+	if err != nil {
+		return err
+	}
+
+	result := make([]value.Wrapper, 0, dict.Len())
+	for _, element := range dict.Pairs() {
+		result = append(result, element.Value)
+	}
+
+	return &value.Array{Elements: result}
+}
+
+func builtinItems(args ...value.Wrapper) value.Wrapper {
+	if err := checkArgumentsCount(args, 1, 1); err != nil {
+		return err
+	}
+
+	dict, err := dictArgument("items", args[0])
+	if err != nil {
+		return err
+	}
+
+	result := make([]value.Wrapper, 0, dict.Len())
+	for _, element := range dict.Pairs() {
+		pair := []value.Wrapper{element.Key, element.Value}
+		result = append(result, &value.Array{Elements: pair})
+	}
+
+	return &value.Array{Elements: result}
+}
+
+// to_array(seq) copies array or materializes range elements
+func builtinToArray(args ...value.Wrapper) value.Wrapper {
+	if err := checkArgumentsCount(args, 1, 1); err != nil {
+		return err
+	}
+
+	seq, err := sequenceArgument("to_array", args[0])
+	if err != nil {
+		return err
+	}
+
+	result := make([]value.Wrapper, seq.length)
+	for i := range result {
+		result[i] = seq.at(int64(i))
+	}
+
+	return &value.Array{Elements: result}
+}
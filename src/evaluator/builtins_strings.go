@@ -0,0 +1,297 @@
+package evaluator
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/aeremic/cgo/value"
+)
+
+// maxStringLength Longest string built by builtins in bytes, longer ones fail
+// instead of exhausting memory
+const maxStringLength = 1 << 30
+
+func init() {
+	stringBuiltins := map[string]value.BuiltInFunction{
+		"split":       builtinSplit,
+		"join":        builtinJoin,
+		"trim":        builtinTrim,
+		"upper":       builtinUpper,
+		"lower":       builtinLower,
+		"replace":     builtinReplace,
+		"starts_with": builtinStartsWith,
+		"ends_with":   builtinEndsWith,
+		"find":        builtinFind,
+		"repeat":      builtinRepeat,
+		"chars":       builtinChars,
+		"format":      builtinFormat,
+	}
+
+	for name, fn := range stringBuiltins {
+		builtins[name] = &value.BuiltIn{Fn: fn, Name: name}
+	}
+}
+
+func stringArgument(name string, arg value.Wrapper) (string, *value.Error) {
+	str, ok := arg.(*value.String)
+	if !ok {
+		return "", newKindError(value.TYPE_ERROR, "argument to `%s` must be a string. got=%s",
+			name, arg.Type())
+	}
+
+	return str.Value, nil
+}
+
+// stringArguments Unwraps all arguments expecting each of them to be a string
+func stringArguments(name string, args []value.Wrapper) ([]string, *value.Error) {
+	result := make([]string, len(args))
+	for i, arg := range args {
+		str, err := stringArgument(name, arg)
+		if err != nil {
+			return nil, err
+		}
+
+		result[i] = str
+	}
+
+	return result, nil
+}
+
+// split(s[, separator]) splits around whitespace when no separator is given
+func builtinSplit(args ...value.Wrapper) value.Wrapper {
+	if err := checkArgumentsCount(args, 1, 2); err != nil {
+		return err
+	}
+
+	strs, err := stringArguments("split", args)
+	if err != nil {
+		return err
+	}
+
+	var parts []string
+	if len(strs) == 1 {
+		parts = strings.Fields(strs[0])
+	} else {
+		parts = strings.Split(strs[0], strs[1])
+	}
+
+	result := make([]value.Wrapper, len(parts))
+	for i, part := range parts {
+		result[i] = &value.String{Value: part}
+	}
+
+	return &value.Array{Elements: result}
+}
+
+// join(arr[, separator]) concatenates array of strings
+func builtinJoin(args ...value.Wrapper) value.Wrapper {
+	if err := checkArgumentsCount(args, 1, 2); err != nil {
+		return err
+	}
+
+	arr, err := arrayArgument("join", args[0])
+	if err != nil {
+		return err
+	}
+
+	separator := ""
+	if len(args) == 2 {
+		separator, err = stringArgument("join", args[1])
+		if err != nil {
+			return err
+		}
+	}
+
+	parts, err := stringArguments("join", arr.Elements)
+	if err != nil {
+		return err
+	}
+
+	return &value.String{Value: strings.Join(parts, separator)}
+}
+
+// trim(s[, cutset]) removes surrounding whitespace or cutset characters
+func builtinTrim(args ...value.Wrapper) value.Wrapper {
+	if err := checkArgumentsCount(args, 1, 2); err != nil {
+		return err
+	}
+
+	strs, err := stringArguments("trim", args)
+	if err != nil {
+		return err
+	}
+
+	if len(strs) == 1 {
+		return &value.String{Value: strings.TrimSpace(strs[0])}
+	}
+
+	return &value.String{Value: strings.Trim(strs[0], strs[1])}
+}
+
+func builtinUpper(args ...value.Wrapper) value.Wrapper {
+	if err := checkArgumentsCount(args, 1, 1); err != nil {
+		return err
+	}
+
+	str, err := stringArgument("upper", args[0])
+	if err != nil {
+		return err
+	}
+
+	return &value.String{Value: strings.ToUpper(str)}
+}
+
+func builtinLower(args ...value.Wrapper) value.Wrapper {
+	if err := checkArgumentsCount(args, 1, 1); err != nil {
+		return err
+	}
+
+	str, err := stringArgument("lower", args[0])
+	if err != nil {
+		return err
+	}
+
+	return &value.String{Value: strings.ToLower(str)}
+}
+
+// replace(s, old, new[, count]) replaces all occurrences unless count is given
+func builtinReplace(args ...value.Wrapper) value.Wrapper {
+	if err := checkArgumentsCount(args, 3, 4); err != nil {
+		return err
+	}
+
+	strs, err := stringArguments("replace", args[:3])
+	if err != nil {
+		return err
+	}
+
+	count := int64(-1)
+	if len(args) == 4 {
+		count, err = integerArgument("replace", args[3])
+		if err != nil {
+			return err
+		}
+	}
+
+	return &value.String{Value: strings.Replace(strs[0], strs[1], strs[2], int(count))}
+}
+
+func builtinStartsWith(args ...value.Wrapper) value.Wrapper {
+	if err := checkArgumentsCount(args, 2, 2); err != nil {
+		return err
+	}
+
+	strs, err := stringArguments("starts_with", args)
+	if err != nil {
+		return err
+	}
+
+	return nativeBoolToBoolean(strings.HasPrefix(strs[0], strs[1]))
+}
+
+func builtinEndsWith(args ...value.Wrapper) value.Wrapper {
+	if err := checkArgumentsCount(args, 2, 2); err != nil {
+		return err
+	}
+
+	strs, err := stringArguments("ends_with", args)
+	if err != nil {
+		return err
+	}
+
+	return nativeBoolToBoolean(strings.HasSuffix(strs[0], strs[1]))
+}
+
+// find returns rune position of the first occurrence of substring or -1
+func builtinFind(args ...value.Wrapper) value.Wrapper {
+	if err := checkArgumentsCount(args, 2, 2); err != nil {
+		return err
+	}
+
+	strs, err := stringArguments("find", args)
+	if err != nil {
+		return err
+	}
+
+	byteIdx := strings.Index(strs[0], strs[1])
+	if byteIdx < 0 {
+		return &value.Integer{Value: -1}
+	}
+
+	return &value.Integer{Value: int64(len([]rune(strs[0][:byteIdx])))}
+}
+
+func builtinRepeat(args ...value.Wrapper) value.Wrapper {
+	if err := checkArgumentsCount(args, 2, 2); err != nil {
+		return err
+	}
+
+	str, err := stringArgument("repeat", args[0])
+	if err != nil {
+		return err
+	}
+
+	count, err := integerArgument("repeat", args[1])
+	if err != nil {
+		return err
+	}
+
+	if count < 0 {
+		return newError("repeat count must not be negative. got=%d", count)
+	}
+
+	if len(str) > 0 && count > int64(maxStringLength/len(str)) {
+		return newError("repeat result too long: %d * %d bytes exceeds %d", count, len(str), maxStringLength)
+	}
+
+	return &value.String{Value: strings.Repeat(str, int(count))}
+}
+
+func builtinChars(args ...value.Wrapper) value.Wrapper {
+	if err := checkArgumentsCount(args, 1, 1); err != nil {
+		return err
+	}
+
+	str, err := stringArgument("chars", args[0])
+	if err != nil {
+		return err
+	}
+
+	runes := []rune(str)
+	result := make([]value.Wrapper, len(runes))
+	for i, r := range runes {
+		result[i] = &value.String{Value: string(r)}
+	}
+
+	return &value.Array{Elements: result}
+}
+
+// format(template, args...) follows fmt verbs. Integers, strings and booleans
+// are passed natively, other values use their printed form.
+func builtinFormat(args ...value.Wrapper) value.Wrapper {
+	if len(args) == 0 {
+		return newKindError(value.TYPE_ERROR, "wrong number of arguments. got=%d, want at least %d",
+			len(args), 1)
+	}
+
+	template, err := stringArgument("format", args[0])
+	if err != nil {
+		return err
+	}
+
+	nativeArgs := make([]interface{}, 0, len(args)-1)
+	for _, arg := range args[1:] {
+		switch arg := arg.(type) {
+		case *value.Integer:
+			nativeArgs = append(nativeArgs, arg.Value)
+		case *value.String:
+			nativeArgs = append(nativeArgs, arg.Value)
+		case *value.Boolean:
+			nativeArgs = append(nativeArgs, arg.Value)
+		default:
+			nativeArgs = append(nativeArgs, arg.Sprintf())
+		}
+	}
+
+	return &value.String{Value: fmt.Sprintf(template, nativeArgs...)}
+}
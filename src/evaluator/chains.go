@@ -0,0 +1,117 @@
+package evaluator
+
+import (
+	"github.com/aeremic/cgo/ast"
+	"github.com/aeremic/cgo/value"
+)
+
+// shortCircuit Result of chain link whose optional object is null. Outer
+// links of the same chain pass it on and the whole chain evaluates to null,
+// so a?.b.c is null instead of failing on null.c.
+type shortCircuit struct {
+	value.Null
+}
+
+func isShortCircuit(v value.Wrapper) bool {
+	_, ok := v.(*shortCircuit)
+	return ok
+}
+
+// evalChainObject Evaluates object of a call, index or member link. Links
+// of the same chain may be short-circuited, other expressions are evaluated
+// as usual.
+func evalChainObject(node ast.Expression, env *value.Environment) value.Wrapper {
+	switch node := node.(type) {
+	case *ast.CallExpression:
+		if debugHook != nil {
+			debugHook(node, env)
+		}
+
+		return evalCallExpression(node, env)
+	case *ast.IndexExpression:
+		if debugHook != nil {
+			debugHook(node, env)
+		}
+
+		return evalIndexLink(node, env)
+	case *ast.MemberExpression:
+		if debugHook != nil {
+			debugHook(node, env)
+		}
+
+		return evalMemberLink(node, env)
+	default:
+		return Eval(node, env)
+	}
+}
+
+// endChain Returns null for short-circuited chain
+func endChain(result value.Wrapper) value.Wrapper {
+	if isShortCircuit(result) {
+		return NULL
+	}
+
+	return result
+}
+
+func evalCallExpression(node *ast.CallExpression, env *value.Environment) value.Wrapper {
+	if isQuoteCall(node, "quote") {
+		if len(node.Arguments) != 1 {
+			return newKindError(value.TYPE_ERROR, "wrong number of arguments. got=%d, want=%d",
+				len(node.Arguments), 1)
+		}
+
+		return quote(node.Arguments[0], env)
+	}
+
+	function := evalChainObject(node.Function, env)
+	if isError(function) || isShortCircuit(function) {
+		return function
+	}
+
+	if node.Optional && function == NULL {
+		return &shortCircuit{}
+	}
+
+	args, keywords, err := evalCallArguments(node.Arguments, env)
+	if err != nil {
+		return err
+	}
+
+	return applyFunctionWithKeywords(function, args, keywords)
+}
+
+func evalIndexLink(node *ast.IndexExpression, env *value.Environment) value.Wrapper {
+	left := evalChainObject(node.Left, env)
+	if isError(left) || isShortCircuit(left) {
+		return left
+	}
+
+	if node.Optional && left == NULL {
+		return &shortCircuit{}
+	}
+
+	if slice, ok := node.Index.(*ast.SliceIndex); ok {
+		return evalSliceExpression(left, slice, env)
+	}
+
+	index := Eval(node.Index, env)
+	if isError(index) {
+		return index
+	}
+
+	return evalIndexExpression(left, index)
+}
+
+func evalMemberLink(node *ast.MemberExpression, env *value.Environment) value.Wrapper {
+	object := evalChainObject(node.Object, env)
+	if isError(object) || isShortCircuit(object) {
+		return object
+	}
+
+	if node.Optional && object == NULL {
+		return &shortCircuit{}
+	}
+
+	return evalMemberExpression(object, node.Property.Value)
+}
@@ -0,0 +1,38 @@
+package evaluator
+
+import (
+	"github.com/aeremic/cgo/ast"
+	"github.com/aeremic/cgo/value"
+)
+
+// DebugHook Called by Eval before each statement and expression is evaluated.
+// Evaluation waits for the hook to return, so a debugger can pause inside it.
+type DebugHook func(node ast.Node, env *value.Environment)
+
+var debugHook DebugHook
+
+// SetDebugHook Installs hook called before every evaluated node, nil removes it
+func SetDebugHook(hook DebugHook) {
+	debugHook = hook
+}
+
+// CallDepth Returns number of user functions currently being applied
+func CallDepth() int {
+	return len(callStack)
+}
+
+// Frame Call of user function
+type Frame struct {
+	Function string // Name of the function, <anonymous> for unnamed ones
+	ID       int    // Unique within the process, tells apart calls made at the same depth
+}
+
+// CallStack Returns calls currently being applied starting from the innermost
+func CallStack() []Frame {
+	stack := make([]Frame, len(callStack))
+	for i, frame := range callStack {
+		stack[len(callStack)-1-i] = frame
+	}
+
+	return stack
+}
@@ -2,10 +2,15 @@ package evaluator
 
 import (
 	"github.com/aeremic/cgo/ast"
+	"github.com/aeremic/cgo/profile"
 	"github.com/aeremic/cgo/value"
 )
 
 func Eval(node ast.Node, env *value.Environment) value.Wrapper {
+	if debugHook != nil {
+		debugHook(node, env)
+	}
+
 	switch node := node.(type) {
 	case *ast.ExpressionStatement:
 		return Eval(node.Expression, env)
@@ -29,7 +34,27 @@ func Eval(node ast.Node, env *value.Environment) value.Wrapper {
 			return val
 		}
 
+		if node.Pattern != nil {
+			if err := bindPattern(node.Pattern, val, env); err != nil {
+				return err
+			}
+
+			if tracer != nil {
+				traceBindings(node, env)
+			}
+
+			return nil
+		}
+
+		if fn, ok := val.(*value.Function); ok && fn.Name == "" {
+			fn.Name = node.Name.Value
+		}
+
 		env.Set(node.Name.Value, val)
+
+		if tracer != nil {
+			traceBindings(node, env)
+		}
 	case *ast.PrefixExpression:
 		right := Eval(node.Right, env)
 		if isError(right) {
@@ -37,12 +62,23 @@ func Eval(node ast.Node, env *value.Environment) value.Wrapper {
 		}
 
 		return evalPrefixExpression(node.Operator, right)
+	case *ast.NullLiteral:
+		return NULL
 	case *ast.InfixExpression:
 		left := Eval(node.Left, env)
 		if isError(left) {
 			return left
 		}
 
+		// Right side is only evaluated when needed
+		if node.Operator == "??" {
+			if left != NULL {
+				return left
+			}
+
+			return Eval(node.Right, env)
+		}
+
 		right := Eval(node.Right, env)
 		if isError(right) {
 			return right
@@ -63,23 +99,14 @@ func Eval(node ast.Node, env *value.Environment) value.Wrapper {
 			Value: val,
 		}
 	case *ast.CallExpression:
-		function := Eval(node.Function, env)
-		if isError(function) {
-			return function
-		}
-
-		args := evalExpressions(node.Arguments, env)
-		if len(args) == 1 && isError(args[0]) {
-			return args[0]
-		}
-
-		return applyFunction(function, args)
+		return endChain(evalCallExpression(node, env))
 	case *ast.FunctionLiteral:
 		params := node.Parameters
 		body := node.Body
 
 		return &value.Function{
 			Parameters: params,
+			Rest:       node.Rest,
 			Body:       body,
 			Env:        env,
 		}
@@ -93,19 +120,35 @@ func Eval(node ast.Node, env *value.Environment) value.Wrapper {
 			Elements: elements,
 		}
 	case *ast.IndexExpression:
-		left := Eval(node.Left, env)
-		if isError(left) {
-			return left
-		}
-
-		index := Eval(node.Index, env)
-		if isError(index) {
-			return index
-		}
-
-		return evalIndexExpression(left, index)
+		return endChain(evalIndexLink(node, env))
 	case *ast.DictLiteral:
 		return evalDictLiteral(node, env)
+	case *ast.AssignExpression:
+		return evalAssignExpression(node, env)
+	case *ast.PipeExpression:
+		return evalPipeExpression(node, env)
+	case *ast.MemberExpression:
+		return endChain(evalMemberLink(node, env))
+	case *ast.ImportStatement:
+		return evalImportStatement(node, env)
+	case *ast.ExportStatement:
+		return Eval(node.Statement, env)
+	case *ast.SliceIndex:
+		return newError("slice is only allowed in index expressions")
+	case *ast.SpreadExpression:
+		return newError("spread is only allowed in calls and array literals")
+	case *ast.KeywordArgument:
+		return newError("keyword argument is only allowed in calls")
+	case *ast.ThrowStatement:
+		return evalThrowStatement(node, env)
+	case *ast.TryExpression:
+		return evalTryExpression(node, env)
+	case *ast.MatchExpression:
+		return evalMatchExpression(node, env)
+	case *ast.StructStatement:
+		return evalStructStatement(node, env)
+	case *ast.MacroLiteral:
+		return newError("macros can only be defined by top level let statements")
 	}
 
 	return nil
@@ -134,7 +177,7 @@ func evalPrefixExpression(operator string, right value.Wrapper) value.Wrapper {
 	case "-":
 		return evalMinusPrefixOperatorExpression(right)
 	default:
-		return newError("unknown operator: %s%s", operator, right.Type())
+		return newKindError(value.TYPE_ERROR, "unknown operator: %s%s", operator, right.Type())
 	}
 }
 
@@ -156,6 +199,10 @@ func evalIntegerInfixExpression(operator string, left value.Wrapper, right value
 			Value: lv * rv,
 		}
 	case "/":
+		if rv == 0 {
+			return newError("division by zero")
+		}
+
 		return &value.Integer{
 			Value: lv / rv,
 		}
@@ -167,39 +214,57 @@ func evalIntegerInfixExpression(operator string, left value.Wrapper, right value
 		return nativeBoolToBoolean(lv == rv)
 	case "!=":
 		return nativeBoolToBoolean(lv != rv)
+	case "..":
+		return &value.Range{Start: lv, End: rv, Step: 1}
+	case "..=":
+		return &value.Range{Start: lv, End: rv, Step: 1, Inclusive: true}
 	default:
-		return newError("unknown operator: %s %s %s",
+		return newKindError(value.TYPE_ERROR, "unknown operator: %s %s %s",
 			left.Type(), operator, right.Type())
 	}
 }
 
 func evalStringInfixExpression(operator string, left value.Wrapper, right value.Wrapper) value.Wrapper {
-	if operator != "+" {
-		return newError("unknown operator: %s %s %s", left.Type(), operator, right.Type())
-	}
-
 	lv := left.(*value.String).Value
 	rv := right.(*value.String).Value
 
-	return &value.String{
-		Value: lv + rv,
+	switch operator {
+	case "+":
+		return &value.String{
+			Value: lv + rv,
+		}
+	case "<":
+		return nativeBoolToBoolean(lv < rv)
+	case ">":
+		return nativeBoolToBoolean(lv > rv)
+	case "==":
+		return nativeBoolToBoolean(lv == rv)
+	case "!=":
+		return nativeBoolToBoolean(lv != rv)
+	default:
+		return newKindError(value.TYPE_ERROR, "unknown operator: %s %s %s",
+			left.Type(), operator, right.Type())
 	}
 }
 
 func evalInfixExpression(operator string, left value.Wrapper, right value.Wrapper) value.Wrapper {
 	switch {
+	case (left == NULL || right == NULL) && (operator == "==" || operator == "!="):
+		return nativeBoolToBoolean((left == right) == (operator == "=="))
 	case left.Type() != right.Type():
-		return newError("type mismatch: %s %s %s", left.Type(), operator, right.Type())
+		return newKindError(value.TYPE_ERROR, "type mismatch: %s %s %s",
+			left.Type(), operator, right.Type())
 	case left.Type() == value.INTEGER && right.Type() == value.INTEGER:
 		return evalIntegerInfixExpression(operator, left, right)
 	case left.Type() == value.STRING && right.Type() == value.STRING:
 		return evalStringInfixExpression(operator, left, right)
 	case operator == "==":
-		return nativeBoolToBoolean(left == right)
+		return nativeBoolToBoolean(value.Equal(left, right))
 	case operator == "!=":
-		return nativeBoolToBoolean(left != right)
+		return nativeBoolToBoolean(!value.Equal(left, right))
 	default:
-		return newError("unknown operator: %s %s %s", left.Type(), operator, right.Type())
+		return newKindError(value.TYPE_ERROR, "unknown operator: %s %s %s",
+			left.Type(), operator, right.Type())
 	}
 }
 
@@ -239,12 +304,27 @@ func evalExpressions(exps []ast.Expression, env *value.Environment) []value.Wrap
 	var results []value.Wrapper
 
 	for _, e := range exps {
+		spread, isSpread := e.(*ast.SpreadExpression)
+		if isSpread {
+			e = spread.Value
+		}
+
 		evaluated := Eval(e, env)
 		if isError(evaluated) {
 			return []value.Wrapper{evaluated}
 		}
 
-		results = append(results, evaluated)
+		if !isSpread {
+			results = append(results, evaluated)
+			continue
+		}
+
+		elements, err := spreadElements(evaluated)
+		if err != nil {
+			return []value.Wrapper{err}
+		}
+
+		results = append(results, elements...)
 	}
 
 	return results
@@ -254,34 +334,75 @@ func evalIndexExpression(left, index value.Wrapper) value.Wrapper {
 	switch {
 	case left.Type() == value.ARRAY && index.Type() == value.INTEGER:
 		return evalArrayIndexExpression(left, index)
+	case left.Type() == value.STRING && index.Type() == value.INTEGER:
+		return evalStringIndexExpression(left, index)
+	case left.Type() == value.RANGE && index.Type() == value.INTEGER:
+		return evalRangeIndexExpression(left, index)
 	case left.Type() == value.DICT:
 		return evalDictIndexExpression(left, index)
+	case left.Type() == value.MODULE && index.Type() == value.STRING:
+		return evalModuleIndexExpression(left, index)
 	default:
-		return newError("index operator not supported: %s", left.Type())
+		return newKindError(value.TYPE_ERROR, "index operator not supported: %s", left.Type())
 	}
 }
 
 func evalArrayIndexExpression(array, index value.Wrapper) value.Wrapper {
 	arrayWrapper := array.(*value.Array)
-	idx := index.(*value.Integer).Value
-	maxIdx := int64(len(arrayWrapper.Elements) - 1)
 
-	if idx < 0 || idx > maxIdx {
+	idx, ok := resolveIndex(index.(*value.Integer).Value, int64(len(arrayWrapper.Elements)))
+	if !ok {
 		return NULL
 	}
 
 	return arrayWrapper.Elements[idx]
 }
 
+// Strings are indexed by runes, result is a single character string
+func evalStringIndexExpression(str, index value.Wrapper) value.Wrapper {
+	runes := []rune(str.(*value.String).Value)
+
+	idx, ok := resolveIndex(index.(*value.Integer).Value, int64(len(runes)))
+	if !ok {
+		return NULL
+	}
+
+	return &value.String{Value: string(runes[idx])}
+}
+
+func evalRangeIndexExpression(rangeWrapper, index value.Wrapper) value.Wrapper {
+	r := rangeWrapper.(*value.Range)
+
+	idx, ok := resolveIndex(index.(*value.Integer).Value, r.Len())
+	if !ok {
+		return NULL
+	}
+
+	return &value.Integer{Value: r.At(idx)}
+}
+
+func evalModuleIndexExpression(module, index value.Wrapper) value.Wrapper {
+	moduleWrapper := module.(*value.Module)
+	name := index.(*value.String).Value
+
+	exported, ok := moduleWrapper.Export(name)
+	if !ok {
+		return newKindError(value.NAME_ERROR, "module %s has no export %s",
+			moduleWrapper.Path, name)
+	}
+
+	return exported
+}
+
 func evalDictIndexExpression(dict, index value.Wrapper) value.Wrapper {
 	dictWrapper := dict.(*value.Dict)
 
-	key, ok := index.(value.Hashable)
+	key, ok := value.HashKeyOf(index)
 	if !ok {
-		return newError("unusable as hash key: %s", index.Type())
+		return newKindError(value.TYPE_ERROR, "unusable as hash key: %s", index.Type())
 	}
 
-	element, ok := dictWrapper.Elements[key.HashKey()]
+	element, ok := dictWrapper.Lookup(key, index)
 	if !ok {
 		return NULL
 	}
@@ -289,54 +410,216 @@ func evalDictIndexExpression(dict, index value.Wrapper) value.Wrapper {
 	return element.Value
 }
 
+func evalAssignExpression(node *ast.AssignExpression, env *value.Environment) value.Wrapper {
+	var container, index value.Wrapper
+	switch target := node.Target.(type) {
+	case *ast.IndexExpression:
+		container = Eval(target.Left, env)
+		if isError(container) {
+			return container
+		}
+
+		index = Eval(target.Index, env)
+		if isError(index) {
+			return index
+		}
+	case *ast.MemberExpression:
+		container = Eval(target.Object, env)
+		if isError(container) {
+			return container
+		}
+
+		index = &value.String{Value: target.Property.Value}
+	default:
+		return newError("invalid assignment target: %s", node.Target.String())
+	}
+
+	val := Eval(node.Value, env)
+	if isError(val) {
+		return val
+	}
+
+	return evalIndexAssignment(container, index, val)
+}
+
+func evalIndexAssignment(container, index, val value.Wrapper) value.Wrapper {
+	switch container := container.(type) {
+	case *value.Array:
+		idx, ok := index.(*value.Integer)
+		if !ok {
+			return newKindError(value.TYPE_ERROR, "array index must be INTEGER. got=%s",
+				index.Type())
+		}
+
+		position, ok := resolveIndex(idx.Value, int64(len(container.Elements)))
+		if !ok {
+			return newKindError(value.INDEX_ERROR, "index out of range: %d (length %d)",
+				idx.Value, len(container.Elements))
+		}
+
+		container.Elements[position] = val
+	case *value.Dict:
+		key, ok := value.HashKeyOf(index)
+		if !ok {
+			return newKindError(value.TYPE_ERROR, "unusable as hash key: %s", index.Type())
+		}
+
+		container.Set(key, value.DictElement{Key: index, Value: val})
+	case *value.Struct:
+		name, ok := index.(*value.String)
+		if !ok {
+			return newKindError(value.TYPE_ERROR, "struct field must be STRING. got=%s",
+				index.Type())
+		}
+
+		if !container.SetField(name.Value, val) {
+			return newKindError(value.NAME_ERROR, "%s has no field %s",
+				container.Definition.Name, name.Value)
+		}
+	default:
+		return newKindError(value.TYPE_ERROR, "index assignment not supported: %s",
+			container.Type())
+	}
+
+	return val
+}
+
+// evalPipeExpression Prepends piped value to arguments of the call on the
+// right side. Any other right side is called with piped value alone.
+func evalPipeExpression(node *ast.PipeExpression, env *value.Environment) value.Wrapper {
+	left := Eval(node.Left, env)
+	if isError(left) {
+		return left
+	}
+
+	var function value.Wrapper
+	args := []value.Wrapper{left}
+	var keywords []keywordArgument
+
+	if call, ok := node.Right.(*ast.CallExpression); ok {
+		function = Eval(call.Function, env)
+		if isError(function) {
+			return function
+		}
+
+		if call.Optional && function == NULL {
+			return NULL
+		}
+
+		callArgs, callKeywords, err := evalCallArguments(call.Arguments, env)
+		if err != nil {
+			return err
+		}
+
+		args = append(args, callArgs...)
+		keywords = callKeywords
+	} else {
+		function = Eval(node.Right, env)
+		if isError(function) {
+			return function
+		}
+	}
+
+	if function.Type() != value.FUNCTION && function.Type() != value.BUILTIN {
+		return newKindError(value.TYPE_ERROR, "pipe target is not callable: %s (%s)",
+			node.Right.String(), function.Type())
+	}
+
+	return applyFunctionWithKeywords(function, args, keywords)
+}
+
 func applyFunction(fn value.Wrapper, args []value.Wrapper) value.Wrapper {
+	return applyFunctionWithKeywords(fn, args, nil)
+}
+
+func applyFunctionWithKeywords(fn value.Wrapper, args []value.Wrapper, keywords []keywordArgument) value.Wrapper {
 	switch fn := fn.(type) {
 	case *value.Function:
-		extendedEnv := createExtendedEnv(fn, args)
-		evaluated := Eval(fn.Body, extendedEnv)
+		if tracer != nil {
+			return traceFunction(fn, args, keywords)
+		}
 
-		return unwrapReturnValue(evaluated)
+		return applyUserFunction(fn, args, keywords)
 	case *value.BuiltIn:
+		if len(keywords) > 0 {
+			return newKindError(value.TYPE_ERROR, "builtin functions don't accept keyword arguments")
+		}
+
+		// User method applied by bound struct methods is profiled and traced instead
+		if fn.Method != nil {
+			return fn.Fn(args...)
+		}
+
+		if activeProfiler != nil {
+			activeProfiler.enter(profile.Function{Name: fn.Name, Builtin: true})
+			defer activeProfiler.exit()
+		}
+
+		if tracer != nil {
+			return traceBuiltin(fn, args)
+		}
+
 		return fn.Fn(args...)
+	case *value.StructType:
+		return constructStruct(fn, args, keywords)
 	default:
-		return newError("not a function: %s", fn.Type())
+		return newKindError(value.TYPE_ERROR, "not a function: %s", fn.Type())
 	}
 }
 
+func applyUserFunction(fn *value.Function, args []value.Wrapper, keywords []keywordArgument) value.Wrapper {
+	pushCall(fn)
+	defer popCall()
+
+	if activeProfiler != nil {
+		activeProfiler.enter(profiledFunction(fn))
+		defer activeProfiler.exit()
+	}
+
+	extendedEnv, err := createExtendedEnv(fn, args, keywords)
+	if err != nil {
+		return err
+	}
+
+	evaluated := Eval(fn.Body, extendedEnv)
+
+	return unwrapReturnValue(evaluated)
+}
+
 func evalDictLiteral(dict *ast.DictLiteral, env *value.Environment) value.Wrapper {
-	elements := make(map[value.HashKey]value.DictElement)
+	result := value.NewDict()
 
-	for dlKey, dlValue := range dict.Elements {
-		evalKey := Eval(dlKey, env)
+	for _, element := range dict.Elements {
+		evalKey := Eval(element.Key, env)
 		if isError(evalKey) {
 			return evalKey
 		}
 
-		evalValue := Eval(dlValue, env)
+		evalValue := Eval(element.Value, env)
 		if isError(evalValue) {
 			return evalValue
 		}
 
-		hashKey, ok := evalKey.(value.Hashable)
+		hashKey, ok := value.HashKeyOf(evalKey)
 		if !ok {
-			return newError("unusable hash key: %s", evalKey.Type())
+			return newKindError(value.TYPE_ERROR, "unusable hash key: %s", evalKey.Type())
 		}
 
-		hashed := hashKey.HashKey()
-		elements[hashed] = value.DictElement{Key: evalKey, Value: evalValue}
+		result.Set(hashKey, value.DictElement{Key: evalKey, Value: evalValue})
 	}
 
-	return &value.Dict{Elements: elements}
+	return result
 }
 
-func createExtendedEnv(fn *value.Function, args []value.Wrapper) *value.Environment {
+func createExtendedEnv(fn *value.Function, args []value.Wrapper,
+	keywords []keywordArgument) (*value.Environment, *value.Error) {
 	env := value.NewEnclosedEnvironment(fn.Env)
 
-	for paramIdx, param := range fn.Parameters {
-		env.Set(param.Value, args[paramIdx])
+	if err := bindArguments(fn, env, args, keywords); err != nil {
+		return nil, err
 	}
 
-	return env
+	return env, nil
 }
 
 func unwrapReturnValue(evaluated value.Wrapper) value.Wrapper {
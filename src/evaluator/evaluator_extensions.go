@@ -15,9 +15,15 @@ var (
 )
 
 func newError(format string, a ...interface{}) *value.Error {
-	return &value.Error{
+	return newKindError(value.RUNTIME_ERROR, format, a...)
+}
+
+func newKindError(kind string, format string, a ...interface{}) *value.Error {
+	return traceError(&value.Error{
 		Message: fmt.Sprintf(format, a...),
-	}
+		Kind:    kind,
+		Stack:   currentStack(),
+	})
 }
 
 func isError(v value.Wrapper) bool {
@@ -43,7 +49,7 @@ func evalBangOperatorExpression(right value.Wrapper) value.Wrapper {
 
 func evalMinusPrefixOperatorExpression(right value.Wrapper) value.Wrapper {
 	if right.Type() != value.INTEGER {
-		return newError("unknown operator: -%s", right.Type())
+		return newKindError(value.TYPE_ERROR, "unknown operator: -%s", right.Type())
 	}
 
 	v := right.(*value.Integer).Value
@@ -61,7 +67,7 @@ func evalIdentifier(node *ast.Identifier, env *value.Environment) value.Wrapper
 		return builtin
 	}
 
-	return newError("%s", "identifier not found: "+node.Value)
+	return newKindError(value.NAME_ERROR, "%s", "identifier not found: "+node.Value)
 }
 
 func nativeBoolToBoolean(input bool) *value.Boolean {
@@ -1,8 +1,16 @@
 package evaluator
 
 import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/aeremic/cgo/ast"
 	"github.com/aeremic/cgo/parser"
 	"github.com/aeremic/cgo/tokenizer"
+	"github.com/aeremic/cgo/trace"
 	"github.com/aeremic/cgo/value"
 
 	"testing"
@@ -440,7 +448,9 @@ func TestArrayIndexExpressions(t *testing.T) {
 		{"let myArray = [1, 2, 3]; myArray[0] + myArray[1] + myArray[2];", 6},
 		{"let myArray = [1, 2, 3]; let i = myArray[0]; myArray[i]", 2},
 		{"[1, 2, 3][3]", nil},
-		{"[1, 2, 3][-1]", nil},
+		{"[1, 2, 3][-1]", 3},
+		{"[1, 2, 3][-3]", 1},
+		{"[1, 2, 3][-4]", nil},
 	}
 
 	for _, test := range tests {
@@ -562,3 +572,1291 @@ func TestDictIndexExpressions(t *testing.T) {
 		}
 	}
 }
+
+func TestDictInsertionOrder(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`{"b": 1, "a": 2, "c": 3}`, "{b: 1, a: 2, c: 3}"},
+		{`{3: 1, 1: 2, 2: 3}`, "{3: 1, 1: 2, 2: 3}"},
+		{`{"a": 1, "b": 2, "a": 3}`, "{a: 3, b: 2}"},
+		{`{true: 1, false: 2}`, "{true: 1, false: 2}"},
+	}
+
+	for _, test := range tests {
+		for i := 0; i < 10; i++ {
+			evaluated := testEval(test.input)
+			if evaluated.Sprintf() != test.expected {
+				t.Fatalf("invalid dict order. got %s instead of %s",
+					evaluated.Sprintf(), test.expected)
+			}
+		}
+	}
+}
+
+func TestIndexAssignment(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let a = [1, 2, 3]; a[0] = 5; a", "[5, 2, 3]"},
+		{"let a = [1, 2, 3]; a[1] = a[2] = 7; a", "[1, 7, 7]"},
+		{"let a = [1, 2, 3]; a[2] = 9", "9"},
+		{`let d = {"a": 1}; d["b"] = 2; d["a"] = 3; d`, "{a: 3, b: 2}"},
+		{`let d = {}; d[1] = [1]; d[1][0] = 2; d`, "{1: [2]}"},
+		{"let a = [1]; a[1] = 2", "ERROR: index out of range: 1 (length 1)"},
+		{`let a = [1]; a["x"] = 2`, "ERROR: array index must be INTEGER. got=STRING"},
+		{`let d = {}; d[fn(x) { x }] = 2`, "ERROR: unusable as hash key: FUNCTION"},
+		{`let s = "abc"; s[0] = 2`, "ERROR: index assignment not supported: STRING"},
+	}
+
+	for _, test := range tests {
+		evaluated := testEval(test.input)
+		if evaluated.Sprintf() != test.expected {
+			t.Errorf("invalid result for %q. got %s instead of %s",
+				test.input, evaluated.Sprintf(), test.expected)
+		}
+	}
+}
+
+func TestMutatingBuiltInFunctions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let a = [1]; append!(a, 2, 3); a", "[1, 2, 3]"},
+		{"let a = [1]; push(a, 2); a", "[1]"},
+		{"push([1], 2)", "[1, 2]"},
+		{`let d = {}; set(d, "a", 1); set(d, "b", 2); d`, "{a: 1, b: 2}"},
+		{"let a = [1, 2]; set(a, 1, 5)", "[1, 5]"},
+		{`let d = {"a": 1, "b": 2, "c": 3}; delete(d, "b"); d`, "{a: 1, c: 3}"},
+		{`let d = {"a": 1}; delete(d, "x")`, "null"},
+		{`let d = {"a": 1}; delete(d, "a")`, "1"},
+		{"let a = [1, 2, 3]; delete(a, 0); a", "[2, 3]"},
+		{"let a = [1, 2, 3]; [delete(a, -1), a]", "[3, [1, 2]]"},
+		{"let a = [1, 2, 3]; delete(a, -3); a", "[2, 3]"},
+		{"let a = [1, 2, 3]; [delete(a, -4), a]", "[null, [1, 2, 3]]"},
+		{"let a = [1, 2]; let b = copy(a); append!(b, 3); a", "[1, 2]"},
+		{`let d = {"a": 1}; let c = copy(d); set(c, "b", 2); d`, "{a: 1}"},
+		{"append!(1, 2)", "ERROR: argument to `append!` must be an array. got=INTEGER"},
+		{"let a = [1]; append!(a, a)", "[1, [...]]"},
+		{`let d = {"a": 1}; set(d, "self", d); d`, "{a: 1, self: {...}}"},
+		{`let a = []; let d = {"a": a}; append!(a, d); [a, d]`, "[[{a: [...]}], {a: [{...}]}]"},
+		{"let a = [1]; [a, a]", "[[1], [1]]"},
+		{"struct Node { next = null } let n = Node(); n.next = [n]; n", "Node{next: [Node{...}]}"},
+	}
+
+	for _, test := range tests {
+		evaluated := testEval(test.input)
+		if evaluated.Sprintf() != test.expected {
+			t.Errorf("invalid result for %q. got %s instead of %s",
+				test.input, evaluated.Sprintf(), test.expected)
+		}
+	}
+}
+
+func TestMutationAliasing(t *testing.T) {
+	input := `
+let counts = {};
+let record = fn(key) {
+	let current = counts[key];
+	if (current) { counts[key] = current + 1 } else { counts[key] = 1 }
+};
+let alias = counts;
+record("a"); record("b"); record("a");
+alias`
+
+	evaluated := testEval(input)
+	if evaluated.Sprintf() != "{a: 2, b: 1}" {
+		t.Errorf("closure mutation not visible through alias. got %s",
+			evaluated.Sprintf())
+	}
+}
+
+func TestCollectionBuiltInFunctions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"map([1, 2, 3], fn(x) { x * 2 })", "[2, 4, 6]"},
+		{"map([], fn(x) { x })", "[]"},
+		{"map([1, -2], len)", "ERROR: argument to `len` not supported, got INTEGER"},
+		{"map([1], 5)", "ERROR: argument to `map` must be a function. got=INTEGER"},
+		{"filter([1, 2, 3, 4], fn(x) { x > 2 })", "[3, 4]"},
+		{"reduce([1, 2, 3, 4], fn(acc, x) { acc + x })", "10"},
+		{"reduce([1, 2, 3], fn(acc, x) { acc + x }, 10)", "16"},
+		{"reduce([], fn(acc, x) { acc + x })", "ERROR: reduce of empty array with no initial value"},
+		{"let total = [0]; each([1, 2, 3], fn(x) { total[0] = total[0] + x }); total[0]", "6"},
+		{"range(4)", "[0, 1, 2, 3]"},
+		{"range(2, 5)", "[2, 3, 4]"},
+		{"range(5, 0, -2)", "[5, 3, 1]"},
+		{"range(1, 2, 0)", "ERROR: range step must not be zero"},
+		{"zip([1, 2, 3], [4, 5])", "[[1, 4], [2, 5]]"},
+		{`enumerate(["a", "b"])`, "[[0, a], [1, b]]"},
+		{"sort([3, 1, 2])", "[1, 2, 3]"},
+		{`sort(["b", "c", "a"])`, "[a, b, c]"},
+		{"sort([3, 1, 2], fn(a, b) { a > b })", "[3, 2, 1]"},
+		{"sort([3, 1, 2], fn(a, b) { b - a })", "[3, 2, 1]"},
+		{"let a = [2, 1]; sort(a); a", "[2, 1]"},
+		{`sort([1, 1, "a"])`, "ERROR: unable to compare STRING and INTEGER, provide a comparator"},
+		{"reverse([1, 2, 3])", "[3, 2, 1]"},
+		{"slice([1, 2, 3, 4], 1, 3)", "[2, 3]"},
+		{"slice([1, 2, 3, 4], 2)", "[3, 4]"},
+		{"slice([1, 2], 1, 10)", "[2]"},
+		{"slice([1, 2, 3, 4], -2)", "[3, 4]"},
+		{"slice([1, 2, 3, 4], 1, -1)", "[2, 3]"},
+		{"slice([1, 2, 3, 4], -10, -3)", "[1]"},
+		{"slice([1, 2, 3, 4], 3, 1)", "[]"},
+		{"let a = [1, 2, 3, 4]; slice(a, -3, -1) == a[-3:-1]", "true"},
+		{"contains([1, 2, 3], 2)", "true"},
+		{`contains(["a"], "b")`, "false"},
+		{`contains({"a": 1}, "a")`, "true"},
+		{`index_of(["a", "b"], "b")`, "1"},
+		{"index_of([1, 2], 3)", "-1"},
+		{`keys({"b": 1, "a": 2})`, "[b, a]"},
+		{`values({"b": 1, "a": 2})`, "[1, 2]"},
+		{`items({"b": 1, "a": 2})`, "[[b, 1], [a, 2]]"},
+		{"keys([1])", "ERROR: argument to `keys` must be a dict. got=ARRAY"},
+		{"reverse()", "ERROR: wrong number of arguments. got=0, want=1"},
+	}
+
+	for _, test := range tests {
+		evaluated := testEval(test.input)
+		if evaluated.Sprintf() != test.expected {
+			t.Errorf("invalid result for %q. got %s instead of %s",
+				test.input, evaluated.Sprintf(), test.expected)
+		}
+	}
+}
+
+func TestStringOperations(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`"abc"[0]`, "a"},
+		{`"héllo"[1]`, "é"},
+		{`"abc"[3]`, "null"},
+		{`len("héllo")`, "5"},
+		{`slice("héllo", 1, 3)`, "él"},
+		{`slice("héllo", 3)`, "lo"},
+		{`slice("héllo", -4, -2)`, "él"},
+		{`reverse("héllo")`, "olléh"},
+		{`"a" < "b"`, "true"},
+		{`"b" > "a"`, "true"},
+		{`"abc" == "abc"`, "true"},
+		{`"abc" != "abd"`, "true"},
+		{`split("a,b,c", ",")`, "[a, b, c]"},
+		{`split("  a  b ")`, "[a, b]"},
+		{`join(["a", "b", "c"], "-")`, "a-b-c"},
+		{`join(["a", 1])`, "ERROR: argument to `join` must be a string. got=INTEGER"},
+		{`trim("  a ")`, "a"},
+		{`trim("xxaxx", "x")`, "a"},
+		{`upper("abc")`, "ABC"},
+		{`lower("ABC")`, "abc"},
+		{`replace("aaa", "a", "b")`, "bbb"},
+		{`replace("aaa", "a", "b", 1)`, "baa"},
+		{`starts_with("hello", "he")`, "true"},
+		{`ends_with("hello", "he")`, "false"},
+		{`find("héllo", "l")`, "2"},
+		{`find("hello", "z")`, "-1"},
+		{`repeat("ab", 3)`, "ababab"},
+		{`repeat("ab", -1)`, "ERROR: repeat count must not be negative. got=-1"},
+		{`repeat("ab", 9223372036854775807)`,
+			"ERROR: repeat result too long: 9223372036854775807 * 2 bytes exceeds 1073741824"},
+		{`len(repeat("", 9223372036854775807))`, "0"},
+		{`chars("hé")`, "[h, é]"},
+		{`contains("hello", "ell")`, "true"},
+		{`format("%s is %d, %t", "x", 5, true)`, "x is 5, true"},
+		{`format("%v", [1, 2])`, "[1, 2]"},
+		{`upper(1)`, "ERROR: argument to `upper` must be a string. got=INTEGER"},
+	}
+
+	for _, test := range tests {
+		evaluated := testEval(test.input)
+		if evaluated.Sprintf() != test.expected {
+			t.Errorf("invalid result for %q. got %s instead of %s",
+				test.input, evaluated.Sprintf(), test.expected)
+		}
+	}
+}
+
+func TestStructuralEquality(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{"[1, 2] == [1, 2]", true},
+		{"[1, 2] == [2, 1]", false},
+		{"[1, 2] != [1, 2, 3]", true},
+		{`[[1, "a"], [true]] == [[1, "a"], [true]]`, true},
+		{`{"a": 1, "b": 2} == {"b": 2, "a": 1}`, true},
+		{`{"a": 1} == {"a": 2}`, false},
+		{`{"a": 1} == {"b": 1}`, false},
+		{`{"a": [1, {"b": 2}]} == {"a": [1, {"b": 2}]}`, true},
+		{"let f = fn(x) { x }; f == f", true},
+		{"fn(x) { x } == fn(x) { x }", false},
+		{"len == len", true},
+		{"if (false) { 1 } == if (false) { 2 }", true},
+		{"let a = []; append!(a, a); let b = []; append!(b, b); a == b", true},
+		{"let a = [1]; append!(a, a); let b = [2]; append!(b, b); a == b", false},
+		{`let a = {}; a["self"] = a; let b = {}; b["self"] = b; a == b`, true},
+		{"contains([[1], [2]], [2])", true},
+		{`index_of([{"a": 1}], {"a": 1}) == 0`, true},
+	}
+
+	for _, test := range tests {
+		evaluated := testEval(test.input)
+		testBooleanValueWrapper(t, evaluated, test.expected)
+	}
+}
+
+func writeModules(t *testing.T, files map[string]string) string {
+	dir := t.TempDir()
+
+	for name, source := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("unable to create module dir: %s", err)
+		}
+
+		if err := os.WriteFile(path, []byte(source), 0o644); err != nil {
+			t.Fatalf("unable to write module: %s", err)
+		}
+	}
+
+	return dir
+}
+
+func TestModuleImports(t *testing.T) {
+	dir := writeModules(t, map[string]string{
+		"lib/math.cgo": `
+let square = fn(x) { x * x };
+let hidden = 42;
+export let cube = fn(x) { square(x) * x };
+export let answer = hidden;
+`,
+		"lib/counter.cgo": `
+export let state = [0];
+append!(state, 1);
+`,
+		"main.cgo": `
+import "lib/math.cgo" as m;
+from "./lib/math" import cube, answer;
+import "lib/counter";
+import "lib/counter.cgo" as again;
+[m["cube"](2), cube(3), answer, len(counter["state"]), again["state"] == counter["state"]];
+`,
+	})
+
+	result := RunFile(filepath.Join(dir, "main.cgo"))
+	if result.Sprintf() != "[8, 27, 42, 2, true]" {
+		t.Errorf("invalid module result. got %s", result.Sprintf())
+	}
+}
+
+func TestModulesReloadedByEachRun(t *testing.T) {
+	dir := writeModules(t, map[string]string{
+		"lib.cgo":  `export let version = 1;`,
+		"main.cgo": `from "lib" import version; version`,
+	})
+
+	testIntegerValueWrapper(t, RunFile(filepath.Join(dir, "main.cgo")), 1)
+
+	if err := os.WriteFile(filepath.Join(dir, "lib.cgo"), []byte(`export let version = 2;`), 0o644); err != nil {
+		t.Fatalf("unable to edit module: %s", err)
+	}
+
+	testIntegerValueWrapper(t, RunFile(filepath.Join(dir, "main.cgo")), 2)
+}
+
+func TestModuleImportErrors(t *testing.T) {
+	tests := []struct {
+		files    map[string]string
+		expected string
+	}{
+		{
+			map[string]string{"main.cgo": `import "missing";`},
+			"module not found: missing",
+		},
+		{
+			map[string]string{
+				"lib.cgo":  `let private = 1;`,
+				"main.cgo": `import "lib"; lib["private"];`,
+			},
+			"has no export private",
+		},
+		{
+			map[string]string{
+				"lib.cgo":  `let private = 1;`,
+				"main.cgo": `from "lib" import private;`,
+			},
+			"module lib has no export private",
+		},
+		{
+			map[string]string{
+				"a.cgo":    `import "b"; export let a = 1;`,
+				"b.cgo":    `import "a"; export let b = 1;`,
+				"main.cgo": `import "a";`,
+			},
+			"import cycle detected: a.cgo -> b.cgo -> a.cgo",
+		},
+		{
+			map[string]string{
+				"main.cgo": `import "main";`,
+			},
+			"import cycle detected: main.cgo -> main.cgo",
+		},
+		{
+			map[string]string{
+				"lib.cgo":  `let x = ;`,
+				"main.cgo": `import "lib";`,
+			},
+			"parse errors in",
+		},
+	}
+
+	for _, test := range tests {
+		dir := writeModules(t, test.files)
+
+		result := RunFile(filepath.Join(dir, "main.cgo"))
+		errWrapper, ok := result.(*value.Error)
+		if !ok {
+			t.Errorf("expected error. got %T (%+v)", result, result)
+			continue
+		}
+
+		if !strings.Contains(errWrapper.Message, test.expected) {
+			t.Errorf("invalid error message. got %q, expected to contain %q",
+				errWrapper.Message, test.expected)
+		}
+	}
+}
+
+func TestTypeChecking(t *testing.T) {
+	tests := []struct {
+		input    string
+		checked  string
+		expected string
+	}{
+		{`let n: int = "1"; n`, "ERROR: type errors in", "1"},
+		{`let twice = fn(x: int) -> int { x + x }; twice("a")`, "ERROR: type errors in", "aa"},
+		{`let r = try { 1 + "a" } catch (e) { e["kind"] }; r`, "ERROR: type errors in", "TypeError"},
+		{`if (false) { puts(1 + "a") }; 5`, "ERROR: type errors in", "5"},
+		{`let add = fn(a, b) { a + b }; add(1, 2) + len(add("a", "b"))`, "5", "5"},
+	}
+
+	for _, test := range tests {
+		dir := writeModules(t, map[string]string{"main.cgo": test.input})
+		path := filepath.Join(dir, "main.cgo")
+
+		// Unannotated code stays dynamically typed unless checking is enabled
+		if result := RunFile(path).Sprintf(); result != test.expected {
+			t.Errorf("invalid result for %q. got %s instead of %s", test.input, result, test.expected)
+		}
+
+		SetTypeChecking(true)
+		result := RunFile(path).Sprintf()
+		SetTypeChecking(false)
+
+		if !strings.HasPrefix(result, test.checked) {
+			t.Errorf("invalid checked result for %q. got %s, expected to start with %s",
+				test.input, result, test.checked)
+		}
+	}
+}
+
+func TestModuleSearchPath(t *testing.T) {
+	libDir := writeModules(t, map[string]string{
+		"shared/util.cgo": `export let twice = fn(x) { x * 2 };`,
+	})
+	dir := writeModules(t, map[string]string{
+		"main.cgo": `from "shared/util" import twice; twice(21);`,
+	})
+
+	SetModuleSearchPath([]string{libDir})
+	defer SetModuleSearchPath(nil)
+
+	result := RunFile(filepath.Join(dir, "main.cgo"))
+	testIntegerValueWrapper(t, result, 42)
+}
+
+func TestTryCatchFinally(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`try { throw "boom" } catch (e) { e["message"] }`, "boom"},
+		{`try { throw "boom" } catch (e) { e["kind"] }`, "Error"},
+		{`try { throw 5 } catch (e) { e["value"] + 1 }`, "6"},
+		{`try { 1 + true } catch (e) { e["kind"] + ": " + e["message"] }`,
+			"TypeError: type mismatch: INTEGER + BOOLEAN"},
+		{`try { missing } catch (e) { e["kind"] }`, "NameError"},
+		{`try { "a" - "b" } catch (e) { e["kind"] }`, "TypeError"},
+		{`try { let a = [1]; a[5] = 1 } catch (e) { e["kind"] }`, "IndexError"},
+		{`try { 1 / 0 } catch (e) { e["message"] }`, "division by zero"},
+		{`try { 10 } catch (e) { 20 }`, "10"},
+		{`let log = []; try { append!(log, 1) } finally { append!(log, 2) }; log`, "[1, 2]"},
+		{`let log = []; try { throw "x" } catch (e) { append!(log, e["message"]) } finally { append!(log, "done") }; log`,
+			"[x, done]"},
+		{`try { throw {"kind": "ValidationError", "message": "bad input"} } catch (e) { e["kind"] + " " + e["message"] }`,
+			"ValidationError bad input"},
+		{`try { try { 1 + true } catch (e) { throw e } } catch (outer) { outer["kind"] }`, "TypeError"},
+		{`try { try { throw "inner" } finally { 1 } } catch (e) { e["message"] }`, "inner"},
+		{`try { 1 } finally { throw "from finally" }`, "ERROR: from finally"},
+		{`let f = fn() { try { return 1 } finally { 2 } }; f()`, "1"},
+		{`let f = fn() { try { return 1 } finally { return 2 } }; f()`, "2"},
+		{`let f = fn() { try { throw "x" } catch (e) { return e["message"] + "!" }; 5 }; f()`, "x!"},
+		{`try { throw "x" } catch (e) { 1 }; e`, "ERROR: identifier not found: e"},
+		{`let r = try { let leak = 5; 1 } catch (e) { 0 }; leak`, "ERROR: identifier not found: leak"},
+		{`try { 1 } finally { let leak = 5 }; leak`, "ERROR: identifier not found: leak"},
+		{`let x = 1; try { let x = 2; x } catch (e) { 0 }; x`, "1"},
+		{`throw "uncaught"; 5`, "ERROR: uncaught"},
+	}
+
+	for _, test := range tests {
+		evaluated := testEval(test.input)
+		if evaluated.Sprintf() != test.expected {
+			t.Errorf("invalid result for %q. got %s instead of %s",
+				test.input, evaluated.Sprintf(), test.expected)
+		}
+	}
+}
+
+func TestErrorStack(t *testing.T) {
+	input := `
+let inner = fn() { throw "deep" };
+let outer = fn() { inner() };
+try { outer() } catch (e) { e["stack"] }`
+
+	evaluated := testEval(input)
+	if evaluated.Sprintf() != "[inner, outer]" {
+		t.Errorf("invalid stack. got %s", evaluated.Sprintf())
+	}
+
+	evaluated = testEval(`let f = fn() { 1 + true }; fn() { f() }()`)
+	errWrapper, ok := evaluated.(*value.Error)
+	if !ok {
+		t.Fatalf("value is not Error. got %T (%+v)", evaluated, evaluated)
+	}
+
+	if strings.Join(errWrapper.Stack, ",") != "f,<anonymous>" {
+		t.Errorf("invalid runtime error stack. got %v", errWrapper.Stack)
+	}
+
+	if len(callStack) != 0 {
+		t.Errorf("call stack not unwound. got %v", callStack)
+	}
+}
+
+func TestNullAndNullSafeOperators(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"null", "null"},
+		{"null == null", "true"},
+		{"1 == null", "false"},
+		{`"a" != null`, "true"},
+		{"[1][5] == null", "true"},
+		{"is_null(null)", "true"},
+		{"is_null(0)", "false"},
+		{"!null", "true"},
+		{"null ?? 5", "5"},
+		{"0 ?? 5", "0"},
+		{"false ?? 5", "false"},
+		{"null ?? null ?? 3", "3"},
+		{"1 ?? missing", "1"},
+		{"null ?? missing", "ERROR: identifier not found: missing"},
+		{`let d = null; d?.["a"]`, "null"},
+		{`let d = {"a": 1}; d?.["a"]`, "1"},
+		{`let d = {"a": null}; d["a"]?.["b"] ?? "default"`, "default"},
+		{`let f = null; f?.(1)`, "null"},
+		{`let f = fn(x) { x * 2 }; f?.(2)`, "4"},
+		{`let a = null; a?.[1][2]`, "null"},
+		{`let a = null; a?.b.c(1)["d"]`, "null"},
+		{`let f = null; f?.(1)(2).x`, "null"},
+		{`let a = null; [a?.[1][2], a?.b.c ?? "default"]`, "[null, default]"},
+		{`let d = {"a": null}; d?.["a"]["b"]`, "ERROR: index operator not supported: NULL"},
+		{`let a = null; let calls = []; a?.[append!(calls, 1)][append!(calls, 2)]; calls`, "[]"},
+		{`let d = null; d["a"]`, "ERROR: index operator not supported: NULL"},
+		{`let f = null; f(1)`, "ERROR: not a function: NULL"},
+		{"let d = {}; d[1] = null ?? 2; d[1]", "2"},
+	}
+
+	for _, test := range tests {
+		evaluated := testEval(test.input)
+		if evaluated.Sprintf() != test.expected {
+			t.Errorf("invalid result for %q. got %s instead of %s",
+				test.input, evaluated.Sprintf(), test.expected)
+		}
+	}
+}
+
+func TestDestructuring(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let [a, b] = [1, 2]; a + b", "3"},
+		{"let [a, ...rest] = [1, 2, 3]; rest", "[2, 3]"},
+		{"let [a, ...rest] = [1]; rest", "[]"},
+		{"let [x = 10, y = 20] = [1]; [x, y]", "[1, 20]"},
+		{"let [x = 10] = [null]; x", "null"},
+		{"let [a, [b, c]] = [1, [2, 3]]; a + b + c", "6"},
+		{`let {name, age} = {"name": "Ann", "age": 30}; [name, age]`, "[Ann, 30]"},
+		{`let {name: n, "full name": f} = {"name": "a", "full name": "b"}; n + f`, "ab"},
+		{`let {missing = 5} = {}; missing`, "5"},
+		{`let {a, ...others} = {"a": 1, "b": 2, "c": 3}; others`, "{b: 2, c: 3}"},
+		{`let {user: {tags: [first]}} = {"user": {"tags": ["x"]}}; first`, "x"},
+		{"let base = 3; let [x = base * 2] = []; x", "6"},
+		{"let sum = fn([a, b]) { a + b }; sum([3, 4])", "7"},
+		{`let greet = fn({name}, [greeting = "hi"]) { greeting + " " + name }; greet({"name": "Bo"}, [])`,
+			"hi Bo"},
+		{"let [a, b] = [1]", "ERROR: not enough elements to destructure. got=1, want=2"},
+		{"let [a] = [1, 2]", "ERROR: too many elements to destructure. got=2, want=1"},
+		{"let [a] = 5", "ERROR: cannot destructure INTEGER as array"},
+		{`let {a} = [1]`, "ERROR: cannot destructure ARRAY as dict"},
+		{`let {a} = {"b": 1}`, "ERROR: missing key to destructure: a"},
+		{`let f = fn([a]) { a }; f(1)`, "ERROR: cannot destructure INTEGER as array"},
+		{`let [x = missing] = []`, "ERROR: identifier not found: missing"},
+	}
+
+	for _, test := range tests {
+		evaluated := testEval(test.input)
+		if evaluated.Sprintf() != test.expected {
+			t.Errorf("invalid result for %q. got %s instead of %s",
+				test.input, evaluated.Sprintf(), test.expected)
+		}
+	}
+}
+
+func TestFunctionParameters(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let add = fn(x, y) { x + y }; add(1)", "ERROR: missing argument: y"},
+		{"let add = fn(x, y) { x + y }; add(1, 2, 3)", "ERROR: wrong number of arguments. got=3, want=2"},
+		{"let f = fn() { 1 }; f(1)", "ERROR: wrong number of arguments. got=1, want=0"},
+		{"let add = fn(x, y = 10) { x + y }; add(1)", "11"},
+		{"let add = fn(x, y = 10) { x + y }; add(1, 2)", "3"},
+		{"let f = fn(x, y = x * 2) { y }; f(4)", "8"},
+		{"let f = fn(first, ...rest) { [first, rest] }; f(1, 2, 3)", "[1, [2, 3]]"},
+		{"let f = fn(first, ...rest) { rest }; f(1)", "[]"},
+		{"let f = fn(...all) { len(all) }; f()", "0"},
+		{"let add = fn(x, y) { x + y }; let args = [1, 2]; add(...args)", "3"},
+		{"let f = fn(...all) { all }; f(0, ...[1, 2], 3, ...[])", "[0, 1, 2, 3]"},
+		{"[0, ...[1, 2], 3]", "[0, 1, 2, 3]"},
+		{"let f = fn(x) { x }; f(...5)", "ERROR: cannot spread INTEGER"},
+		{"len(...[\"abc\"])", "3"},
+		{"let sub = fn(x, y) { x - y }; sub(y: 2, x: 10)", "8"},
+		{"let sub = fn(x, y) { x - y }; sub(10, y: 2)", "8"},
+		{"let f = fn(x, y = 1, z = 2) { [x, y, z] }; f(0, z: 5)", "[0, 1, 5]"},
+		{"let f = fn(x: int, y: int = 2) -> int { x * y }; f(3)", "6"},
+		{"let f = fn(x: int) -> int { x }; f(\"not checked by Eval\")", "not checked by Eval"},
+		{"let f = fn(x) { x }; f(z: 1)", "ERROR: unexpected keyword argument: z"},
+		{"let f = fn(x) { x }; f(1, x: 2)", "ERROR: multiple values for argument: x"},
+		{"let f = fn(x, y) { x }; f(x: 1, 2)", "ERROR: positional argument follows keyword argument: 2"},
+		{"let log = []; let f = fn(...a) { 0 }; try { f(x: append!(log, 1), append!(log, 2)) } catch (e) { log }", "[]"},
+		{"let log = []; let f = fn(a, b, c = 0, d = 0) { log }; " +
+			"f(append!(log, 1), append!(log, 2), c: append!(log, 3), d: append!(log, 4))", "[1, 2, 3, 4]"},
+		{"len(x: 1)", "ERROR: builtin functions don't accept keyword arguments"},
+		{"let f = fn([a, b] = [1, 2]) { a + b }; f()", "3"},
+		{"...[1]", "ERROR: spread is only allowed in calls and array literals"},
+	}
+
+	for _, test := range tests {
+		evaluated := testEval(test.input)
+		if evaluated.Sprintf() != test.expected {
+			t.Errorf("invalid result for %q. got %s instead of %s",
+				test.input, evaluated.Sprintf(), test.expected)
+		}
+	}
+}
+
+func TestMatchExpression(t *testing.T) {
+	describe := `let describe = fn(v) {
+		match (v) {
+			0 => "zero",
+			-1 => "minus one",
+			int(n) if n > 100 => "big",
+			int => "int",
+			"hi" => "greeting",
+			string(s) => s,
+			bool(b) => !b,
+			[] => "empty",
+			[x] => x,
+			[x, y = 10] => x + y,
+			[x, ...rest] => len(rest),
+			{"kind": "circle", r} => r,
+			{"kind": k, ...others} => others,
+			function => "callable",
+			null => "null",
+			_ => "other",
+		}
+	};`
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{describe + "describe(0)", "zero"},
+		{describe + "describe(-1)", "minus one"},
+		{describe + "describe(500)", "big"},
+		{describe + "describe(5)", "int"},
+		{describe + `describe("hi")`, "greeting"},
+		{describe + `describe("yo")`, "yo"},
+		{describe + "describe(true)", "false"},
+		{describe + "describe([])", "empty"},
+		{describe + "describe([7])", "7"},
+		{describe + "describe([1, 2])", "3"},
+		{describe + "describe([1, 2, 3, 4])", "3"},
+		{describe + `describe({"kind": "circle", "r": 3})`, "3"},
+		{describe + `describe({"kind": "square", "a": 2})`, "{a: 2}"},
+		{describe + "describe(len)", "callable"},
+		{describe + "describe(null)", "null"},
+		{describe + `describe({"a": 1})`, "other"},
+		{"match ([1, [2, 3]]) { [a, [b, c]] => a + b + c }", "6"},
+		{"let x = 1; match (2) { x => x }; x", "1"},
+		{"match (1) { x if x > 5 => 1, x => x * 2 }", "2"},
+		{"match ({\"a\": [1, 2]}) { {\"a\": [1, n]} => n }", "2"},
+		{"match (5) { 1 => 1, 2 => 2 }", "ERROR: no match arm for value: 5"},
+		{"match (1) { x if y => 1 }", "ERROR: identifier not found: y"},
+		{"try { match (5) { 1 => 1 } } catch (e) { e[\"kind\"] }", "MatchError"},
+		{"match (null) { null(n) => [n], _ => 0 }", "[null]"},
+		{"match (0) { null(n) => [n], _ => 0 }", "0"},
+		{"struct P { x } struct Q { x } match (Q(1)) { P(p) => p.x, Q(q) => q.x + 1 }", "2"},
+		{"struct P { x } match ({\"x\": 1}) { P(_) => 1, _ => 2 }", "2"},
+		{"struct P { x } let old = P(1); struct P { x } match (old) { P(_) => 1, _ => 2 }", "2"},
+		{"match (1) { Missing(m) => m }", "ERROR: identifier not found: Missing"},
+		{"let size = 1; match (1) { size(n) => n }", "ERROR: size is not a type"},
+	}
+
+	for _, test := range tests {
+		evaluated := testEval(test.input)
+		if evaluated.Sprintf() != test.expected {
+			t.Errorf("invalid result for %q. got %s instead of %s",
+				test.input, evaluated.Sprintf(), test.expected)
+		}
+	}
+}
+
+func TestSlicesAndRanges(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let a = [1, 2, 3, 4, 5]; a[1:3]", "[2, 3]"},
+		{"let a = [1, 2, 3, 4, 5]; a[:-1]", "[1, 2, 3, 4]"},
+		{"let a = [1, 2, 3, 4, 5]; a[2:]", "[3, 4, 5]"},
+		{"let a = [1, 2, 3, 4, 5]; a[:]", "[1, 2, 3, 4, 5]"},
+		{"let a = [1, 2, 3, 4, 5]; a[::2]", "[1, 3, 5]"},
+		{"let a = [1, 2, 3, 4, 5]; a[::-1]", "[5, 4, 3, 2, 1]"},
+		{"let a = [1, 2, 3, 4, 5]; a[3:1:-1]", "[4, 3]"},
+		{"let a = [1, 2, 3, 4, 5]; a[-100:100]", "[1, 2, 3, 4, 5]"},
+		{"let a = [1, 2, 3, 4, 5]; a[4:1]", "[]"},
+		{"let a = [1, 2, 3]; let b = a[:]; b[0] = 9; a", "[1, 2, 3]"},
+		{"let a = [1, 2, 3]; a[-1] = 9; a", "[1, 2, 9]"},
+		{"let a = [1, 2, 3]; a[-4] = 9", "ERROR: index out of range: -4 (length 3)"},
+		{`"hello"[1:3]`, "el"},
+		{`"hello"[-3:]`, "llo"},
+		{`"héllo"[::-1]`, "olléh"},
+		{`"hello"[-1]`, "o"},
+		{"[1, 2, 3][::0]", "ERROR: slice step must not be zero"},
+		{`[1, 2, 3]["a":]`, "ERROR: slice index must be INTEGER. got=STRING"},
+		{"5[1:2]", "ERROR: slice operator not supported: INTEGER"},
+		{"let a = [1, 2]; a[0:1] = 5", "ERROR: slice is only allowed in index expressions"},
+		{"1..5", "1..5"},
+		{"1..=5", "1..=5"},
+		{"1..=9223372036854775807 |> len", "9223372036854775807"},
+		{"(9223372036854775806..=9223372036854775807)[1]", "9223372036854775807"},
+		{"to_array(9223372036854775805..=9223372036854775807)",
+			"[9223372036854775805, 9223372036854775806, 9223372036854775807]"},
+		{"to_array(3..=3)", "[3]"},
+		{"to_array(3..=2)", "[]"},
+		{"contains(1..=5, 5)", "true"},
+		{"len(1..10)", "9"},
+		{"len(10..1)", "0"},
+		{"(1..10)[0]", "1"},
+		{"(1..10)[-1]", "9"},
+		{"(1..10)[9]", "null"},
+		{"(1..10)[2:5]", "3..6"},
+		{"(0..10)[::3]", "0..12 step 3"},
+		{"(1..4)[::-1]", "3..0 step -1"},
+		{"to_array((1..4)[::-1])", "[3, 2, 1]"},
+		{"to_array(1..=3)", "[1, 2, 3]"},
+		{"to_array(0..0)", "[]"},
+		{"1..10 == 1..=9", "true"},
+		{"(0..10)[::2] == (0..9)[::2]", "true"},
+		{"1..3 == [1, 2]", "ERROR: type mismatch: RANGE == ARRAY"},
+		{"let n = 3; 1..n + 1", "1..4"},
+		{"map(1..4, fn(x) { x * x })", "[1, 4, 9]"},
+		{"filter(1..=10, fn(x) { x / 2 * 2 == x })", "[2, 4, 6, 8, 10]"},
+		{"reduce(1..=100, fn(acc, x) { acc + x })", "5050"},
+		{"enumerate(5..7)", "[[0, 5], [1, 6]]"},
+		{"index_of(5..10, 7)", "2"},
+		{"contains(0..1000000000000, 999999999999)", "true"},
+		{"contains((0..10)[::3], 4)", "false"},
+		{"len(0..1000000000000)", "1000000000000"},
+		{"(0..1000000000000)[-1]", "999999999999"},
+		{"[0, ...(1..3)]", "[0, 1, 2]"},
+		{`"a".."b"`, "ERROR: unknown operator: STRING .. STRING"},
+		{"match (1..3) { range => 1, _ => 2 }", "1"},
+	}
+
+	for _, test := range tests {
+		evaluated := testEval(test.input)
+		if evaluated.Sprintf() != test.expected {
+			t.Errorf("invalid result for %q. got %s instead of %s",
+				test.input, evaluated.Sprintf(), test.expected)
+		}
+	}
+}
+
+func TestPipeExpression(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"[1, 2, 3] |> len()", "3"},
+		{"[1, 2, 3] |> len", "3"},
+		{"let double = fn(x) { x * 2 }; 5 |> double", "10"},
+		{"let add = fn(x, y) { x + y }; 5 |> add(3) |> add(2)", "10"},
+		{"1..=4 |> map(fn(x) { x * x }) |> filter(fn(x) { x > 1 }) |> sum()",
+			"29"},
+		{"let sub = fn(x, y) { x - y }; 10 |> sub(y: 3)", "7"},
+		{"let f = fn(x, ...rest) { [x, rest] }; 1 |> f(...[2, 3])", "[1, [2, 3]]"},
+		{"let make = fn(n) { fn(x) { x + n } }; 1 |> make(10)()", "11"},
+		{"let f = null; 1 |> f?.()", "null"},
+		{"let x = [3, 1, 2] |> sort() |> reverse(); x", "[3, 2, 1]"},
+		{"null ?? [1] |> len()", "1"},
+		{"1 |> 2", "ERROR: pipe target is not callable: 2 (INTEGER)"},
+		{"let n = 5; 1 |> n(2)", "ERROR: pipe target is not callable: n(2) (INTEGER)"},
+		{"1 |> missing()", "ERROR: identifier not found: missing"},
+		{"[1] |> map(fn(x) { x / 0 })", "ERROR: division by zero"},
+		{"sum([])", "0"},
+		{`sum([1, "a"])`, "ERROR: unable to sum STRING"},
+	}
+
+	for _, test := range tests {
+		evaluated := testEval(test.input)
+		if evaluated.Sprintf() != test.expected {
+			t.Errorf("invalid result for %q. got %s instead of %s",
+				test.input, evaluated.Sprintf(), test.expected)
+		}
+	}
+}
+
+func TestMemberExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`let p = {"name": "Ana"}; p.name`, "Ana"},
+		{`let p = {"name": "Ana"}; p.age`, "null"},
+		{`let p = {"a": {"b": [1, 2]}}; p.a.b[1]`, "2"},
+		{`let p = {"name": "Ana"}; p.name = "Iva"; p.name`, "Iva"},
+		{`let p = {}; p.x = 1; p["x"]`, "1"},
+		{`let p = {"keys": fn() { "own" }}; p.keys()`, "own"},
+		{`let p = {"a": 1, "b": 2}; p.keys()`, "[a, b]"},
+		{`let p = {"a": 1}; p.len()`, "1"},
+		{`"abc".upper()`, "ABC"},
+		{`" a ".trim().len()`, "1"},
+		{`"a,b".split(",")`, "[a, b]"},
+		{"[1, 2].push(3)", "[1, 2, 3]"},
+		{"let a = [1]; a.append!(2); a", "[1, 2]"},
+		{"[3, 1, 2].sort().map(fn(x) { x * 10 })", "[10, 20, 30]"},
+		{"(1..4).to_array()", "[1, 2, 3]"},
+		{"(1..=3).sum()", "6"},
+		{`["a", "b"].join("-")`, "a-b"},
+		{`let up = "x".upper; up()`, "X"},
+		{"[1, 2, 3] |> len", "3"},
+		{"let p = null; p?.name", "null"},
+		{"let p = null; p?.name.first", "null"},
+		{`let p = {"name": null}; p?.name.first`, "ERROR: NULL has no member first"},
+		{"5.foo", "ERROR: INTEGER has no member foo"},
+		{"[1].foo()", "ERROR: ARRAY has no member foo"},
+		{"[1].len(x: 1)", "ERROR: builtin functions don't accept keyword arguments"},
+		{"let a = [1]; a.x = 2", "ERROR: array index must be INTEGER. got=STRING"},
+	}
+
+	for _, test := range tests {
+		evaluated := testEval(test.input)
+		if evaluated.Sprintf() != test.expected {
+			t.Errorf("invalid result for %q. got %s instead of %s",
+				test.input, evaluated.Sprintf(), test.expected)
+		}
+	}
+}
+
+func TestRegisterMethod(t *testing.T) {
+	RegisterMethod(value.INTEGER, "double", func(args ...value.Wrapper) value.Wrapper {
+		if err := checkArgumentsCount(args, 1, 1); err != nil {
+			return err
+		}
+
+		return &value.Integer{Value: args[0].(*value.Integer).Value * 2}
+	})
+	defer delete(methods[value.INTEGER], "double")
+
+	evaluated := testEval("let x = 21; x.double()")
+	if evaluated.Sprintf() != "42" {
+		t.Errorf("invalid result of registered method. got %s", evaluated.Sprintf())
+	}
+
+	evaluated = testEval("let x = 21; x.double(1)")
+	if evaluated.Sprintf() != "ERROR: wrong number of arguments. got=2, want=1" {
+		t.Errorf("invalid error of registered method. got %s", evaluated.Sprintf())
+	}
+}
+
+func TestModuleMembers(t *testing.T) {
+	dir := writeModules(t, map[string]string{
+		"math.cgo": `
+let hidden = 1;
+export let square = fn(x) { x * x };
+`,
+		"main.cgo": `
+import "math";
+[math.square(3), try { math.hidden } catch (e) { e.kind }];
+`,
+	})
+
+	result := RunFile(filepath.Join(dir, "main.cgo"))
+	if result.Sprintf() != "[9, NameError]" {
+		t.Errorf("invalid module result. got %s", result.Sprintf())
+	}
+}
+
+func TestStructs(t *testing.T) {
+	point := `struct Point {
+		x,
+		y = 0,
+		fn norm(self) { self.x * self.x + self.y * self.y }
+		fn add(self, other) { Point(self.x + other.x, self.y + other.y) }
+	}
+	`
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{point + "Point(1, 2)", "Point{x: 1, y: 2}"},
+		{point + "Point(3)", "Point{x: 3, y: 0}"},
+		{point + "Point(y: 1, x: 2)", "Point{x: 2, y: 1}"},
+		{point + "Point", "<struct Point>"},
+		{point + "Point(1, 2).x", "1"},
+		{point + "Point(1, 2).norm()", "5"},
+		{point + "Point(1, 2).add(Point(3, 4))", "Point{x: 4, y: 6}"},
+		{point + "let p = Point(1, 2); p.x = 5; p", "Point{x: 5, y: 2}"},
+		{point + "Point(1, 2) == Point(1, 2)", "true"},
+		{point + "Point(1, 2) != Point(1, 3)", "true"},
+		{point + "let d = {}; d[Point(1, 2)] = 1; d[Point(1, 2)] + len(d)", "2"},
+		{point + "let d = {}; d[Point(1, [2])] = 1", "ERROR: unusable as hash key: Point"},
+		{point + "let d = {}; d[Point(Point(1), \"a\")] = 1; d[Point(Point(1), \"a\")]", "1"},
+		{point + "let p = Point(1); p.y = p; contains({}, p)", "ERROR: unusable as hash key: Point"},
+		{"struct A { x } let a = A; struct A { x } let d = {}; d[a(1)] = 1; [d[A(1)], contains(d, A(1))]",
+			"[null, false]"},
+		{point + "let d = {}; d[Point(1, 2)] = 1; d[Point(2, 1)]", "null"},
+		{"struct A { x } struct B { x } A(1) == B(1)", "ERROR: type mismatch: A == B"},
+		{"struct A { x } let a = A; struct A { x } a(1) == A(1)", "false"},
+		{"struct Empty {} Empty()", "Empty{}"},
+		{`struct STRING { x } "a" == STRING(1)`, "ERROR: struct name STRING is reserved for builtin type"},
+		{"struct ARRAY { x } 1", "ERROR: struct name ARRAY is reserved for builtin type"},
+		{"struct Box { items } let b = Box([]); append!(b.items, 1); b", "Box{items: [1]}"},
+		{point + "Point()", "ERROR: missing argument: x"},
+		{point + "Point(1, 2, 3)", "ERROR: wrong number of arguments. got=3, want=2"},
+		{point + "Point(1, z: 2)", "ERROR: unexpected keyword argument: z"},
+		{point + "Point(1).z", "ERROR: Point has no member z"},
+		{point + "let p = Point(1); p.z = 2", "ERROR: Point has no field z"},
+		{"struct Node { next = null, fn depth(self) { if (self.next == null) { 1 } else { 1 + self.next.depth() } } }" +
+			"Node(Node(Node())).depth()", "3"},
+		{"struct Bad { fn fail(self) { throw \"boom\" } }" +
+			"try { Bad().fail() } catch (e) { e.stack }", "[Bad.fail]"},
+	}
+
+	for _, test := range tests {
+		evaluated := testEval(test.input)
+		if evaluated.Sprintf() != test.expected {
+			t.Errorf("invalid result for %q. got %s instead of %s",
+				test.input, evaluated.Sprintf(), test.expected)
+		}
+	}
+}
+
+func TestQuoteUnquote(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"quote(5)", "QUOTE(5)"},
+		{"quote(5 + 8)", "QUOTE((5 + 8))"},
+		{"quote(foobar)", "QUOTE(foobar)"},
+		{"quote(foobar + barfoo)", "QUOTE((foobar + barfoo))"},
+		{"quote(unquote(4))", "QUOTE(4)"},
+		{"quote(unquote(4 + 4))", "QUOTE(8)"},
+		{"quote(8 + unquote(4 + 4))", "QUOTE((8 + 8))"},
+		{"quote(unquote(4 + 4) + 8)", "QUOTE((8 + 8))"},
+		{"let foobar = 8; quote(foobar)", "QUOTE(foobar)"},
+		{"let foobar = 8; quote(unquote(foobar))", "QUOTE(8)"},
+		{"quote(unquote(true))", "QUOTE(true)"},
+		{"quote(unquote(true == false))", "QUOTE(false)"},
+		{`quote(unquote("a" + "b"))`, "QUOTE(ab)"},
+		{"quote(unquote(null))", "QUOTE(null)"},
+		{"quote(unquote([1, 2]))", "QUOTE([1, 2])"},
+		{"quote(unquote(quote(4 + 4)))", "QUOTE((4 + 4))"},
+		{"let quotedInfix = quote(4 + 4); quote(unquote(4 + 4) + unquote(quotedInfix))",
+			"QUOTE((8 + (4 + 4)))"},
+		{"let f = fn(x) { quote(unquote(x) + 1) }; [f(1), f(2)]", "[QUOTE((1 + 1)), QUOTE((2 + 1))]"},
+		{"let f = fn(x) { quote(unquote(x)) }; let a = f(1); f(2); a", "QUOTE(1)"},
+		{"quote(unquote(len))", "ERROR: unable to unquote BUILTIN"},
+		{"quote(unquote(missing))", "ERROR: identifier not found: missing"},
+		{"quote(1, 2)", "ERROR: wrong number of arguments. got=2, want=1"},
+	}
+
+	for _, test := range tests {
+		evaluated := testEval(test.input)
+		if evaluated.Sprintf() != test.expected {
+			t.Errorf("invalid result for %q. got %s instead of %s",
+				test.input, evaluated.Sprintf(), test.expected)
+		}
+	}
+}
+
+func TestDefineMacros(t *testing.T) {
+	input := `
+	let number = 1;
+	let function = fn(x, y) { x + y };
+	let mymacro = macro(x, y) { x + y; };
+	`
+
+	env := value.NewEnvironment()
+	program := parser.New(tokenizer.New(input)).ParseProgram()
+
+	DefineMacros(program, env)
+
+	if len(program.Statements) != 2 {
+		t.Fatalf("invalid number of statements. got=%d", len(program.Statements))
+	}
+
+	if _, ok := env.Get("number"); ok {
+		t.Fatalf("number should not be defined")
+	}
+
+	if _, ok := env.Get("function"); ok {
+		t.Fatalf("function should not be defined")
+	}
+
+	val, ok := env.Get("mymacro")
+	if !ok {
+		t.Fatalf("macro not in environment")
+	}
+
+	macro, ok := val.(*value.Macro)
+	if !ok {
+		t.Fatalf("value is not Macro. got=%T", val)
+	}
+
+	if len(macro.Parameters) != 2 || macro.Parameters[0].Value != "x" || macro.Parameters[1].Value != "y" {
+		t.Fatalf("invalid macro parameters. got=%v", macro.Parameters)
+	}
+
+	if macro.Body.String() != "(x + y)" {
+		t.Fatalf("invalid macro body. got=%q", macro.Body.String())
+	}
+}
+
+func TestExpandMacros(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{
+			"let infixExpression = macro() { quote(1 + 2); }; infixExpression();",
+			"(1 + 2)",
+		},
+		{
+			"let reverse = macro(a, b) { quote(unquote(b) - unquote(a)); }; reverse(2 + 2, 10 - 5);",
+			"(10 - 5) - (2 + 2)",
+		},
+		{
+			`let unless = macro(condition, consequence, alternative) {
+				quote(if (!(unquote(condition))) {
+					unquote(consequence);
+				} else {
+					unquote(alternative);
+				});
+			};
+			unless(10 > 5, puts("not greater"), puts("greater"));`,
+			`if (!(10 > 5)) { puts("not greater") } else { puts("greater") }`,
+		},
+		{
+			"let m = macro(x) { quote(unquote(x) * 2) }; let f = fn() { m(3) }; f",
+			"let f = fn() { 3 * 2 }; f",
+		},
+	}
+
+	for _, test := range tests {
+		expected := parser.New(tokenizer.New(test.expected)).ParseProgram()
+		program := parser.New(tokenizer.New(test.input)).ParseProgram()
+
+		env := value.NewEnvironment()
+		DefineMacros(program, env)
+
+		expanded, err := ExpandMacros(program, env)
+		if err != nil {
+			t.Fatalf("unexpected expansion error: %s", err.Message)
+		}
+
+		if expanded.String() != expected.String() {
+			t.Errorf("not equal. want=%q, got=%q", expected.String(), expanded.String())
+		}
+	}
+}
+
+func TestExpandMacrosErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let m = macro(x) { quote(x) }; m()", "wrong number of macro arguments. got=0, want=1"},
+		{"let m = macro() { 1 }; m()", "macro m must return a quote"},
+		{"let m = macro() { missing }; m()", "identifier not found: missing"},
+	}
+
+	for _, test := range tests {
+		program := parser.New(tokenizer.New(test.input)).ParseProgram()
+
+		env := value.NewEnvironment()
+		DefineMacros(program, env)
+
+		_, err := ExpandMacros(program, env)
+		if err == nil || err.Message != test.expected {
+			t.Errorf("invalid expansion error for %q. got=%v, want=%q", test.input, err, test.expected)
+		}
+	}
+
+	evaluated := testEval("let f = fn() { macro(x) { x } }; f()")
+	if evaluated.Sprintf() != "ERROR: macros can only be defined by top level let statements" {
+		t.Errorf("invalid result for nested macro. got %s", evaluated.Sprintf())
+	}
+}
+
+func TestModuleMacros(t *testing.T) {
+	dir := writeModules(t, map[string]string{
+		"main.cgo": `
+let swap = macro(a, b) { quote([unquote(b), unquote(a)]) };
+swap(1 + 1, "x");
+`,
+	})
+
+	result := RunFile(filepath.Join(dir, "main.cgo"))
+	if result.Sprintf() != "[x, 2]" {
+		t.Errorf("invalid module result. got %s", result.Sprintf())
+	}
+}
+
+func TestDebugHook(t *testing.T) {
+	visited := []string{}
+	ids := map[int]bool{}
+	SetDebugHook(func(node ast.Node, env *value.Environment) {
+		if _, ok := node.(*ast.ExpressionStatement); !ok {
+			return
+		}
+
+		stack := CallStack()
+		if len(stack) != CallDepth() {
+			t.Errorf("call stack has %d frames at depth %d", len(stack), CallDepth())
+		}
+
+		name := "<main>"
+		if len(stack) > 0 {
+			name = stack[0].Function
+			ids[stack[0].ID] = true
+		}
+
+		visited = append(visited, fmt.Sprintf("%s in %s", node, name))
+	})
+	defer SetDebugHook(nil)
+
+	testEval("let double = fn(x) { x * 2 }; double(1); map([3], fn(y) { y })")
+
+	expected := []string{"double(1) in <main>", "(x * 2) in double", "map([3], fn(y)y) in <main>", "y in <anonymous>"}
+	if strings.Join(visited, ", ") != strings.Join(expected, ", ") {
+		t.Errorf("wrong statements visited. got=%v, want=%v", visited, expected)
+	}
+
+	if len(ids) != 2 {
+		t.Errorf("calls don't have distinct IDs. got=%v", ids)
+	}
+}
+
+func TestProfiling(t *testing.T) {
+	StartProfiling()
+	testEval(`let fact = fn(n) { if (n < 2) { return 1 } n * fact(n - 1) };
+let fns = map([1, 2], fn(x) { fn() { x } });
+fact(3); fns[0](); fns[1](); "a".upper(); len([1])`)
+	recorded := StopProfiling()
+
+	if StopProfiling() != nil {
+		t.Errorf("profiling not stopped")
+	}
+
+	samples := []string{}
+	for _, sample := range recorded.Samples {
+		names := []string{}
+		for _, index := range sample.Stack {
+			function := recorded.Functions[index]
+			names = append(names, fmt.Sprintf("%s@%d", function.Name, function.Column))
+		}
+
+		samples = append(samples, fmt.Sprintf("%s x%d", strings.Join(names, " < "), sample.Calls))
+	}
+
+	expected := []string{
+		"<anonymous>@29 < map@0 < <main>@0 x2",
+		"map@0 < <main>@0 x1",
+		"fact@18 < fact@18 < fact@18 < <main>@0 x1",
+		"fact@18 < fact@18 < <main>@0 x1",
+		"fact@18 < <main>@0 x1",
+		"<anonymous>@36 < <main>@0 x2",
+		"upper@0 < <main>@0 x1",
+		"len@0 < <main>@0 x1",
+		"<main>@0 x1",
+	}
+	if strings.Join(samples, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("wrong samples.\ngot:\n%s\nwant:\n%s", strings.Join(samples, "\n"), strings.Join(expected, "\n"))
+	}
+
+	total := time.Duration(0)
+	for _, sample := range recorded.Samples {
+		total += sample.Time
+	}
+
+	if total > recorded.Duration {
+		t.Errorf("samples take longer than profile. got=%s, duration=%s", total, recorded.Duration)
+	}
+}
+
+func TestBuiltinsProfiled(t *testing.T) {
+	StartProfiling()
+	for _, builtin := range builtins {
+		applyFunction(builtin, []value.Wrapper{})
+	}
+	testEval(`let a = []; append!(a, 1); append!(a, 2)`)
+	recorded := StopProfiling()
+
+	calls := map[string]int64{}
+	for _, sample := range recorded.Samples {
+		calls[recorded.Functions[sample.Stack[0]].Name] += sample.Calls
+	}
+
+	for name, builtin := range builtins {
+		if builtin.Name != name {
+			t.Errorf("builtin %s has wrong name. got=%q", name, builtin.Name)
+		}
+
+		if calls[name] == 0 {
+			t.Errorf("builtin %s not profiled", name)
+		}
+	}
+
+	if calls["append!"] != 3 {
+		t.Errorf("wrong number of append! calls. got=%d, want=3", calls["append!"])
+	}
+}
+
+// recorder Tracer keeping events it receives
+type recorder struct {
+	events []trace.Event
+}
+
+func (r *recorder) Trace(event trace.Event) {
+	r.events = append(r.events, event)
+}
+
+// tracedEvents Describes events the recorder received
+func tracedEvents(t *testing.T, r *recorder) []string {
+	traced := []string{}
+	for _, event := range r.events {
+		if event.Time.IsZero() {
+			t.Errorf("%s event has no time", event.Kind)
+		}
+
+		description := fmt.Sprintf("%s@%d", event.Kind, event.Depth)
+		switch event.Kind {
+		case trace.ENTER:
+			description += fmt.Sprintf(" %s:%d(%s)", event.Function, event.Line, strings.Join(event.Arguments, ", "))
+		case trace.EXIT, trace.BUILTIN:
+			description += fmt.Sprintf(" %s(%s) = %s%s", event.Function, strings.Join(event.Arguments, ", "), event.Result, event.Error)
+		case trace.BIND:
+			description += fmt.Sprintf(" %s:%d %s = %s", event.File, event.Line, event.Name, event.Value)
+		case trace.ERROR:
+			description += fmt.Sprintf(" %s: %s", event.ErrorKind, event.Error)
+		}
+
+		traced = append(traced, description)
+	}
+
+	return traced
+}
+
+func TestTracer(t *testing.T) {
+	r := &recorder{}
+	SetTracer(r)
+	defer SetTracer(nil)
+
+	testEval(`let double = fn(x, scale = 2) { x * scale };
+let [a, b] = [double(2, scale: 3), len("ab")];
+try { throw {"kind": "Custom", "message": "boom"} } catch (e) { e };
+[1].first();
+double("a")`)
+
+	traced := tracedEvents(t, r)
+
+	expected := []string{
+		"bind@0 :1 double = fn(x, scale = 2) {\n(x * scale)\n}",
+		"enter@0 double:1(2, scale=3)",
+		"exit@0 double() = 6",
+		"builtin@0 len(ab) = 2",
+		"bind@0 :2 a = 6",
+		"bind@0 :2 b = 2",
+		"error@0 Custom: boom",
+		"builtin@0 first() = 1",
+		"enter@0 double:1(a)",
+		"error@1 TypeError: type mismatch: STRING * INTEGER",
+		"exit@0 double() = type mismatch: STRING * INTEGER",
+	}
+	if strings.Join(traced, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("wrong events.\ngot:\n%s\nwant:\n%s", strings.Join(traced, "\n"), strings.Join(expected, "\n"))
+	}
+}
+
+func TestTracerMutatingBuiltins(t *testing.T) {
+	r := &recorder{}
+	SetTracer(r)
+	defer SetTracer(nil)
+
+	testEval(`struct Counter { n, fn add(self, x) { self.n + x } }
+let a = [];
+append!(a, 1);
+Counter(1).add(2)`)
+
+	traced := []string{}
+	for _, event := range tracedEvents(t, r) {
+		if !strings.HasPrefix(event, "bind") {
+			traced = append(traced, event)
+		}
+	}
+
+	expected := []string{
+		"builtin@0 append!([1], 1) = [1]",
+		"enter@0 Counter.add:1(Counter{n: 1}, 2)",
+		"exit@0 Counter.add() = 3",
+	}
+	if strings.Join(traced, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("wrong events.\ngot:\n%s\nwant:\n%s", strings.Join(traced, "\n"), strings.Join(expected, "\n"))
+	}
+}
@@ -0,0 +1,182 @@
+package evaluator
+
+import (
+	"github.com/aeremic/cgo/ast"
+	"github.com/aeremic/cgo/value"
+)
+
+const anonymousFunctionName = "<anonymous>"
+
+// User functions currently being applied, innermost last
+var (
+	callStack []Frame
+	callCount int // Number of calls started so far, used to assign frame IDs
+)
+
+func pushCall(fn *value.Function) {
+	name := fn.Name
+	if name == "" {
+		name = anonymousFunctionName
+	}
+
+	callCount++
+	callStack = append(callStack, Frame{Function: name, ID: callCount})
+}
+
+func popCall() {
+	callStack = callStack[:len(callStack)-1]
+}
+
+// currentStack Returns call stack starting from the innermost call
+func currentStack() []string {
+	stack := make([]string, len(callStack))
+	for i, frame := range callStack {
+		stack[len(callStack)-1-i] = frame.Function
+	}
+
+	return stack
+}
+
+// evalThrowStatement Raises thrown value as an error. Dicts may carry their
+// own kind and message (e.g. a rethrown caught error), strings become the
+// message and any other value is described by its printed form.
+func evalThrowStatement(node *ast.ThrowStatement, env *value.Environment) value.Wrapper {
+	thrown := Eval(node.Value, env)
+	if isError(thrown) {
+		return thrown
+	}
+
+	errWrapper := &value.Error{
+		Message: thrown.Sprintf(),
+		Kind:    value.THROWN_ERROR,
+		Value:   thrown,
+		Stack:   currentStack(),
+	}
+
+	switch thrown := thrown.(type) {
+	case *value.Dict:
+		if kind, ok := dictStringField(thrown, "kind"); ok {
+			errWrapper.Kind = kind
+		}
+
+		if message, ok := dictStringField(thrown, "message"); ok {
+			errWrapper.Message = message
+		}
+
+		// Rethrown errors keep their original payload and stack
+		if inner, ok := dictField(thrown, "value"); ok {
+			errWrapper.Value = inner
+			if inner == NULL {
+				errWrapper.Value = nil
+			}
+		}
+
+		if stack, ok := dictField(thrown, "stack"); ok {
+			if names, ok := stringElements(stack); ok {
+				errWrapper.Stack = names
+			}
+		}
+	case *value.String:
+		errWrapper.Message = thrown.Value
+	}
+
+	return traceError(errWrapper)
+}
+
+// evalTryExpression Runs catch block when try block fails and finally block
+// in any case. Errors and returns from finally block take precedence.
+func evalTryExpression(node *ast.TryExpression, env *value.Environment) value.Wrapper {
+	result := Eval(node.Block, value.NewEnclosedEnvironment(env))
+
+	if errWrapper, ok := result.(*value.Error); ok && node.Catch != nil {
+		catchEnv := value.NewEnclosedEnvironment(env)
+		catchEnv.Set(node.CatchParam.Value, errorToDict(errWrapper))
+
+		result = Eval(node.Catch, catchEnv)
+	}
+
+	if node.Finally != nil {
+		finallyResult := Eval(node.Finally, value.NewEnclosedEnvironment(env))
+		if finallyResult != nil {
+			rt := finallyResult.Type()
+			if rt == value.RETURN || rt == value.ERROR {
+				return finallyResult
+			}
+		}
+	}
+
+	return result
+}
+
+// errorToDict Exposes caught error to scripts
+func errorToDict(errWrapper *value.Error) *value.Dict {
+	stack := make([]value.Wrapper, len(errWrapper.Stack))
+	for i, name := range errWrapper.Stack {
+		stack[i] = &value.String{Value: name}
+	}
+
+	var thrown value.Wrapper = NULL
+	if errWrapper.Value != nil {
+		thrown = errWrapper.Value
+	}
+
+	dict := value.NewDict()
+	fields := []struct {
+		name  string
+		value value.Wrapper
+	}{
+		{"message", &value.String{Value: errWrapper.Message}},
+		{"kind", &value.String{Value: errWrapper.Kind}},
+		{"stack", &value.Array{Elements: stack}},
+		{"value", thrown},
+	}
+
+	for _, field := range fields {
+		key := &value.String{Value: field.name}
+		dict.Set(key.HashKey(), value.DictElement{Key: key, Value: field.value})
+	}
+
+	return dict
+}
+
+func dictField(dict *value.Dict, name string) (value.Wrapper, bool) {
+	element, ok := dict.Get((&value.String{Value: name}).HashKey())
+	if !ok {
+		return nil, false
+	}
+
+	return element.Value, true
+}
+
+func dictStringField(dict *value.Dict, name string) (string, bool) {
+	field, ok := dictField(dict, name)
+	if !ok {
+		return "", false
+	}
+
+	str, ok := field.(*value.String)
+	if !ok {
+		return "", false
+	}
+
+	return str.Value, true
+}
+
+func stringElements(v value.Wrapper) ([]string, bool) {
+	arr, ok := v.(*value.Array)
+	if !ok {
+		return nil, false
+	}
+
+	result := make([]string, len(arr.Elements))
+	for i, element := range arr.Elements {
+		str, ok := element.(*value.String)
+		if !ok {
+			return nil, false
+		}
+
+		result[i] = str.Value
+	}
+
+	return result, true
+}
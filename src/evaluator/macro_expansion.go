@@ -0,0 +1,106 @@
+package evaluator
+
+import (
+	"github.com/aeremic/cgo/ast"
+	"github.com/aeremic/cgo/value"
+)
+
+// DefineMacros Moves top level `let name = macro(...)` definitions out of
+// program and binds them in env
+func DefineMacros(program *ast.ProgramRoot, env *value.Environment) {
+	statements := []ast.Statement{}
+
+	for _, statement := range program.Statements {
+		letStatement, ok := statement.(*ast.LetStatement)
+		if !ok || letStatement.Name == nil {
+			statements = append(statements, statement)
+			continue
+		}
+
+		macroLiteral, ok := letStatement.Value.(*ast.MacroLiteral)
+		if !ok {
+			statements = append(statements, statement)
+			continue
+		}
+
+		env.Set(letStatement.Name.Value, &value.Macro{
+			Parameters: macroLiteral.Parameters,
+			Body:       macroLiteral.Body,
+			Env:        env,
+		})
+	}
+
+	program.Statements = statements
+}
+
+// ExpandMacros Replaces calls of macros defined in env with the quote they
+// return. Macro arguments are passed quoted, without being evaluated.
+func ExpandMacros(program ast.Node, env *value.Environment) (ast.Node, *value.Error) {
+	var expansionErr *value.Error
+
+	expanded, err := ast.Modify(program, func(node ast.Node) ast.Node {
+		if expansionErr != nil {
+			return node
+		}
+
+		call, ok := node.(*ast.CallExpression)
+		if !ok {
+			return node
+		}
+
+		macro, ok := macroCall(call, env)
+		if !ok {
+			return node
+		}
+
+		if len(call.Arguments) != len(macro.Parameters) {
+			expansionErr = newKindError(value.TYPE_ERROR, "wrong number of macro arguments. got=%d, want=%d",
+				len(call.Arguments), len(macro.Parameters))
+
+			return node
+		}
+
+		evalEnv := value.NewEnclosedEnvironment(macro.Env)
+		for i, param := range macro.Parameters {
+			evalEnv.Set(param.Value, &value.Quote{Node: call.Arguments[i]})
+		}
+
+		evaluated := unwrapReturnValue(Eval(macro.Body, evalEnv))
+		if errWrapper, ok := evaluated.(*value.Error); ok {
+			expansionErr = errWrapper
+			return node
+		}
+
+		quote, ok := evaluated.(*value.Quote)
+		if !ok {
+			expansionErr = newKindError(value.TYPE_ERROR, "macro %s must return a quote",
+				call.Function.String())
+
+			return node
+		}
+
+		return quote.Node
+	})
+
+	if expansionErr == nil && err != nil {
+		expansionErr = newKindError(value.TYPE_ERROR, "unable to expand macro: %s", err)
+	}
+
+	return expanded, expansionErr
+}
+
+func macroCall(call *ast.CallExpression, env *value.Environment) (*value.Macro, bool) {
+	identifier, ok := call.Function.(*ast.Identifier)
+	if !ok {
+		return nil, false
+	}
+
+	val, ok := env.Get(identifier.Value)
+	if !ok {
+		return nil, false
+	}
+
+	macro, ok := val.(*value.Macro)
+
+	return macro, ok
+}
@@ -0,0 +1,202 @@
+package evaluator
+
+import (
+	"github.com/aeremic/cgo/ast"
+	"github.com/aeremic/cgo/value"
+)
+
+// evalMatchExpression Evaluates body of the first arm whose pattern matches
+// subject and whose guard holds. Each arm binds names in its own scope.
+func evalMatchExpression(node *ast.MatchExpression, env *value.Environment) value.Wrapper {
+	subject := Eval(node.Subject, env)
+	if isError(subject) {
+		return subject
+	}
+
+	for _, arm := range node.Arms {
+		armEnv := value.NewEnclosedEnvironment(env)
+
+		matched, err := matchPattern(arm.Pattern, subject, armEnv)
+		if err != nil {
+			return err
+		}
+
+		if !matched {
+			continue
+		}
+
+		if arm.Guard != nil {
+			guard := Eval(arm.Guard, armEnv)
+			if isError(guard) {
+				return guard
+			}
+
+			if !isTruthy(guard) {
+				continue
+			}
+		}
+
+		return Eval(arm.Body, armEnv)
+	}
+
+	return newKindError(value.MATCH_ERROR, "no match arm for value: %s", subject.Sprintf())
+}
+
+// matchPattern Reports whether value has the shape described by pattern
+// and binds pattern names in env. Unlike bindPattern, a mismatch isn't an error.
+func matchPattern(pattern ast.Pattern, val value.Wrapper, env *value.Environment) (bool, *value.Error) {
+	switch pattern := pattern.(type) {
+	case *ast.Identifier:
+		env.Set(pattern.Value, val)
+
+		return true, nil
+	case *ast.WildcardPattern:
+		return true, nil
+	case *ast.LiteralPattern:
+		literal := Eval(pattern.Value, env)
+		if errWrapper, ok := literal.(*value.Error); ok {
+			return false, errWrapper
+		}
+
+		return value.Equal(literal, val), nil
+	case *ast.TypePattern:
+		return matchTypePattern(pattern, val, env)
+	case *ast.DefaultPattern:
+		return matchPattern(pattern.Target, val, env)
+	case *ast.ArrayPattern:
+		return matchArrayPattern(pattern, val, env)
+	case *ast.DictPattern:
+		return matchDictPattern(pattern, val, env)
+	default:
+		return false, newError("unsupported pattern: %s", pattern.String())
+	}
+}
+
+// matchMissing Matches pattern element that has no corresponding value.
+// Only elements with a default can be missing.
+func matchMissing(pattern ast.Pattern, env *value.Environment) (bool, *value.Error) {
+	defaultPattern, ok := pattern.(*ast.DefaultPattern)
+	if !ok {
+		return false, nil
+	}
+
+	defaultValue := Eval(defaultPattern.Default, env)
+	if errWrapper, ok := defaultValue.(*value.Error); ok {
+		return false, errWrapper
+	}
+
+	return matchPattern(defaultPattern.Target, defaultValue, env)
+}
+
+func matchTypePattern(pattern *ast.TypePattern, val value.Wrapper, env *value.Environment) (bool, *value.Error) {
+	matched, err := matchesType(pattern.Name, val, env)
+	if err != nil || !matched || pattern.Binding == nil {
+		return matched, err
+	}
+
+	return matchPattern(pattern.Binding, val, env)
+}
+
+// matchesType Reports whether value is of the named type. Names other than
+// builtin type patterns must be bound to a struct whose instance value is.
+func matchesType(name string, val value.Wrapper, env *value.Environment) (bool, *value.Error) {
+	if types, ok := value.PatternTypes(name); ok {
+		for _, t := range types {
+			if val.Type() == t {
+				return true, nil
+			}
+		}
+
+		return false, nil
+	}
+
+	bound, ok := env.Get(name)
+	if !ok {
+		return false, newKindError(value.NAME_ERROR, "%s", "identifier not found: "+name)
+	}
+
+	structType, ok := bound.(*value.StructType)
+	if !ok {
+		return false, newKindError(value.TYPE_ERROR, "%s is not a type", name)
+	}
+
+	instance, ok := val.(*value.Struct)
+
+	return ok && instance.Definition == structType, nil
+}
+
+func matchArrayPattern(pattern *ast.ArrayPattern, val value.Wrapper, env *value.Environment) (bool, *value.Error) {
+	arr, ok := val.(*value.Array)
+	if !ok {
+		return false, nil
+	}
+
+	if pattern.Rest == nil && len(arr.Elements) > len(pattern.Elements) {
+		return false, nil
+	}
+
+	for i, element := range pattern.Elements {
+		var matched bool
+		var err *value.Error
+		if i >= len(arr.Elements) {
+			matched, err = matchMissing(element, env)
+		} else {
+			matched, err = matchPattern(element, arr.Elements[i], env)
+		}
+
+		if err != nil || !matched {
+			return false, err
+		}
+	}
+
+	if pattern.Rest != nil {
+		rest := []value.Wrapper{}
+		if len(arr.Elements) > len(pattern.Elements) {
+			rest = append(rest, arr.Elements[len(pattern.Elements):]...)
+		}
+
+		env.Set(pattern.Rest.Value, &value.Array{Elements: rest})
+	}
+
+	return true, nil
+}
+
+// matchDictPattern Matches dicts containing all keys of the pattern,
+// other keys are ignored unless collected by rest
+func matchDictPattern(pattern *ast.DictPattern, val value.Wrapper, env *value.Environment) (bool, *value.Error) {
+	dict, ok := val.(*value.Dict)
+	if !ok {
+		return false, nil
+	}
+
+	used := map[value.HashKey]bool{}
+	for _, entry := range pattern.Entries {
+		key := &value.String{Value: entry.Key.Value}
+		used[key.HashKey()] = true
+
+		var matched bool
+		var err *value.Error
+		if element, ok := dict.Get(key.HashKey()); ok {
+			matched, err = matchPattern(entry.Value, element.Value, env)
+		} else {
+			matched, err = matchMissing(entry.Value, env)
+		}
+
+		if err != nil || !matched {
+			return false, err
+		}
+	}
+
+	if pattern.Rest != nil {
+		rest := value.NewDict()
+		for _, hashKey := range dict.HashKeys() {
+			if !used[hashKey] {
+				rest.Set(hashKey, dict.Elements[hashKey])
+			}
+		}
+
+		env.Set(pattern.Rest.Value, rest)
+	}
+
+	return true, nil
+}
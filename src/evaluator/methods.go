@@ -0,0 +1,80 @@
+package evaluator
+
+import (
+	"github.com/aeremic/cgo/value"
+)
+
+// Methods callable as value.method(args) by receiver type. Receiver is
+// passed to the method as its first argument.
+var methods = map[value.Type]map[string]value.BuiltInFunction{}
+
+func init() {
+	// Builtins taking the receiver as their first argument
+	defaultMethods := map[value.Type][]string{
+		value.STRING: {
+			"len", "upper", "lower", "trim", "split", "replace", "starts_with", "ends_with",
+			"find", "repeat", "chars", "format", "contains", "slice",
+		},
+		value.ARRAY: {
+			"len", "first", "last", "tail", "push", "append!", "set", "delete", "copy",
+			"map", "filter", "reduce", "each", "zip", "enumerate", "sort", "reverse",
+			"slice", "contains", "index_of", "join", "sum", "to_array",
+		},
+		value.DICT: {
+			"len", "keys", "values", "items", "contains", "set", "delete", "copy",
+		},
+		value.RANGE: {
+			"len", "map", "filter", "reduce", "each", "enumerate", "contains", "index_of",
+			"sum", "to_array",
+		},
+	}
+
+	for t, names := range defaultMethods {
+		for _, name := range names {
+			RegisterMethod(t, name, builtins[name].Fn)
+		}
+	}
+}
+
+// RegisterMethod Makes fn callable as a method on values of given type.
+// Host programs use it to extend builtin types, registering an existing
+// name replaces the method.
+func RegisterMethod(t value.Type, name string, fn value.BuiltInFunction) {
+	if methods[t] == nil {
+		methods[t] = map[string]value.BuiltInFunction{}
+	}
+
+	methods[t][name] = fn
+}
+
+// evalMemberExpression Resolves object.name. Dict keys, module exports and
+// struct members take precedence over registered methods, which are returned
+// bound to the object.
+// Missing dict keys evaluate to null like index expressions do.
+func evalMemberExpression(object value.Wrapper, name string) value.Wrapper {
+	switch object := object.(type) {
+	case *value.Dict:
+		if element, ok := object.Get((&value.String{Value: name}).HashKey()); ok {
+			return element.Value
+		}
+	case *value.Module:
+		return evalModuleIndexExpression(object, &value.String{Value: name})
+	case *value.Struct:
+		if member, ok := evalStructMember(object, name); ok {
+			return member
+		}
+	}
+
+	method, ok := methods[object.Type()][name]
+	if ok {
+		return &value.BuiltIn{Name: name, Fn: func(args ...value.Wrapper) value.Wrapper {
+			return method(append([]value.Wrapper{object}, args...)...)
+		}}
+	}
+
+	if object.Type() == value.DICT {
+		return NULL
+	}
+
+	return newKindError(value.TYPE_ERROR, "%s has no member %s", object.Type(), name)
+}
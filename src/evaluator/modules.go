@@ -0,0 +1,233 @@
+package evaluator
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/aeremic/cgo/ast"
+	"github.com/aeremic/cgo/parser"
+	"github.com/aeremic/cgo/token"
+	"github.com/aeremic/cgo/tokenizer"
+	"github.com/aeremic/cgo/types"
+	"github.com/aeremic/cgo/value"
+)
+
+const moduleExtension = ".cgo"
+
+// Modules are evaluated once and shared by every importer
+var (
+	moduleCache      = map[string]*value.Module{}
+	moduleSearchPath []string
+	loadingModules   []string // Modules currently being evaluated, used to detect cycles
+	typeChecking     bool     // Files with type errors are rejected before evaluation
+)
+
+// SetModuleSearchPath Configures directories searched for imports
+// that can't be resolved relative to the importing file
+func SetModuleSearchPath(paths []string) {
+	moduleSearchPath = paths
+}
+
+// SetTypeChecking Checks types of files before evaluating them and rejects
+// ones with type errors. Checking is off by default, type errors surface
+// when the failing operation is evaluated.
+func SetTypeChecking(enabled bool) {
+	typeChecking = enabled
+}
+
+// RunFile Parses and evaluates source file as the main module. Modules are
+// loaded anew by each run, so runs in the same process see edited files.
+func RunFile(path string) value.Wrapper {
+	moduleCache = map[string]*value.Module{}
+	loadingModules = nil
+
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return newError("unable to resolve %s: %s", path, err)
+	}
+
+	program, errWrapper := parseModuleFile(absPath)
+	if errWrapper != nil {
+		return errWrapper
+	}
+
+	env := value.NewEnvironment()
+	env.SetFile(absPath)
+
+	loadingModules = append(loadingModules, absPath)
+	defer func() { loadingModules = loadingModules[:len(loadingModules)-1] }()
+
+	return Eval(program, env)
+}
+
+func evalImportStatement(node *ast.ImportStatement, env *value.Environment) value.Wrapper {
+	module := loadModule(node.Path.Value, env.File())
+	if isError(module) {
+		return module
+	}
+
+	mod := module.(*value.Module)
+
+	if node.Token.Type == token.FROM {
+		for _, name := range node.Names {
+			exported, ok := mod.Export(name.Value)
+			if !ok {
+				return newKindError(value.NAME_ERROR, "module %s has no export %s",
+					node.Path.Value, name.Value)
+			}
+
+			env.Set(name.Value, exported)
+		}
+
+		return nil
+	}
+
+	env.Set(ImportAlias(node), mod)
+
+	return nil
+}
+
+// ImportAlias Returns name bound by whole module import, file name of the
+// module unless alias is given
+func ImportAlias(node *ast.ImportStatement) string {
+	if node.Alias != nil {
+		return node.Alias.Value
+	}
+
+	return strings.TrimSuffix(filepath.Base(node.Path.Value), moduleExtension)
+}
+
+func loadModule(importPath, importerFile string) value.Wrapper {
+	path, ok := resolveModulePath(importPath, importerFile)
+	if !ok {
+		return newError("module not found: %s", importPath)
+	}
+
+	if module, ok := moduleCache[path]; ok {
+		return module
+	}
+
+	for i, loading := range loadingModules {
+		if loading == path {
+			cycle := append(append([]string{}, loadingModules[i:]...), path)
+			for j := range cycle {
+				cycle[j] = filepath.Base(cycle[j])
+			}
+
+			return newError("import cycle detected: %s", strings.Join(cycle, " -> "))
+		}
+	}
+
+	program, errWrapper := parseModuleFile(path)
+	if errWrapper != nil {
+		return errWrapper
+	}
+
+	env := value.NewEnvironment()
+	env.SetFile(path)
+
+	loadingModules = append(loadingModules, path)
+	result := Eval(program, env)
+	loadingModules = loadingModules[:len(loadingModules)-1]
+
+	if isError(result) {
+		return result
+	}
+
+	module := &value.Module{Path: path, Env: env, Exports: []string{}}
+	for _, statement := range program.Statements {
+		if export, ok := statement.(*ast.ExportStatement); ok {
+			for _, name := range ast.PatternIdentifiers(export.Statement.Target()) {
+				module.Exports = append(module.Exports, name.Value)
+			}
+		}
+	}
+
+	moduleCache[path] = module
+
+	return module
+}
+
+// resolveModulePath Looks up module relative to the importing file first,
+// then in search path directories. Paths starting with ./ or ../ are only
+// resolved relative to the importer.
+func resolveModulePath(importPath, importerFile string) (string, bool) {
+	if filepath.Ext(importPath) == "" {
+		importPath += moduleExtension
+	}
+
+	if filepath.IsAbs(importPath) {
+		return importPath, fileExists(importPath)
+	}
+
+	importerDir := "."
+	if importerFile != "" {
+		importerDir = filepath.Dir(importerFile)
+	}
+
+	candidates := []string{filepath.Join(importerDir, importPath)}
+	if !strings.HasPrefix(importPath, "./") && !strings.HasPrefix(importPath, "../") {
+		for _, dir := range moduleSearchPath {
+			candidates = append(candidates, filepath.Join(dir, importPath))
+		}
+	}
+
+	for _, candidate := range candidates {
+		if !fileExists(candidate) {
+			continue
+		}
+
+		absPath, err := filepath.Abs(candidate)
+		if err != nil {
+			continue
+		}
+
+		return absPath, true
+	}
+
+	return "", false
+}
+
+// parseModuleFile Parses source file and expands macros it defines, types
+// are checked too when enabled. Macros are private to the file.
+func parseModuleFile(path string) (*ast.ProgramRoot, *value.Error) {
+	source, err := os.ReadFile(path)
+	if err != nil {
+		return nil, newError("unable to read module %s: %s", path, err)
+	}
+
+	p := parser.New(tokenizer.New(string(source)))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		return nil, newError("parse errors in %s: %s", path, strings.Join(p.Errors(), "; "))
+	}
+
+	macroEnv := value.NewEnvironment()
+	DefineMacros(program, macroEnv)
+
+	expanded, errWrapper := ExpandMacros(program, macroEnv)
+	if errWrapper != nil {
+		return nil, errWrapper
+	}
+
+	if !typeChecking {
+		return expanded.(*ast.ProgramRoot), nil
+	}
+
+	if typeErrors := types.Check(expanded.(*ast.ProgramRoot)); len(typeErrors) != 0 {
+		messages := []string{}
+		for _, typeError := range typeErrors {
+			messages = append(messages, typeError.Error())
+		}
+
+		return nil, newKindError(value.TYPE_ERROR, "type errors in %s: %s", path, strings.Join(messages, "; "))
+	}
+
+	return expanded.(*ast.ProgramRoot), nil
+}
+
+func fileExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir()
+}
@@ -0,0 +1,124 @@
+package evaluator
+
+import (
+	"github.com/aeremic/cgo/ast"
+	"github.com/aeremic/cgo/value"
+)
+
+// bindPattern Destructures value into names declared by the pattern.
+// Defaults are evaluated in env and only used for missing elements.
+// Returns an error when value's shape doesn't match the pattern.
+func bindPattern(pattern ast.Pattern, val value.Wrapper, env *value.Environment) *value.Error {
+	switch pattern := pattern.(type) {
+	case *ast.Identifier:
+		env.Set(pattern.Value, val)
+
+		return nil
+	case *ast.DefaultPattern:
+		return bindPattern(pattern.Target, val, env)
+	case *ast.ArrayPattern:
+		return bindArrayPattern(pattern, val, env)
+	case *ast.DictPattern:
+		return bindDictPattern(pattern, val, env)
+	default:
+		return newError("unsupported pattern: %s", pattern.String())
+	}
+}
+
+// bindMissing Binds pattern element that has no corresponding value
+func bindMissing(pattern ast.Pattern, env *value.Environment, describe func() *value.Error) *value.Error {
+	defaultPattern, ok := pattern.(*ast.DefaultPattern)
+	if !ok {
+		return describe()
+	}
+
+	defaultValue := Eval(defaultPattern.Default, env)
+	if errWrapper, ok := defaultValue.(*value.Error); ok {
+		return errWrapper
+	}
+
+	return bindPattern(defaultPattern.Target, defaultValue, env)
+}
+
+func bindArrayPattern(pattern *ast.ArrayPattern, val value.Wrapper, env *value.Environment) *value.Error {
+	arr, ok := val.(*value.Array)
+	if !ok {
+		return newKindError(value.TYPE_ERROR, "cannot destructure %s as array", val.Type())
+	}
+
+	if pattern.Rest == nil && len(arr.Elements) > len(pattern.Elements) {
+		return newKindError(value.TYPE_ERROR, "too many elements to destructure. got=%d, want=%d",
+			len(arr.Elements), len(pattern.Elements))
+	}
+
+	for i, element := range pattern.Elements {
+		if i >= len(arr.Elements) {
+			err := bindMissing(element, env, func() *value.Error {
+				return newKindError(value.TYPE_ERROR, "not enough elements to destructure. got=%d, want=%d",
+					len(arr.Elements), len(pattern.Elements))
+			})
+			if err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		if err := bindPattern(element, arr.Elements[i], env); err != nil {
+			return err
+		}
+	}
+
+	if pattern.Rest != nil {
+		rest := []value.Wrapper{}
+		if len(arr.Elements) > len(pattern.Elements) {
+			rest = append(rest, arr.Elements[len(pattern.Elements):]...)
+		}
+
+		env.Set(pattern.Rest.Value, &value.Array{Elements: rest})
+	}
+
+	return nil
+}
+
+func bindDictPattern(pattern *ast.DictPattern, val value.Wrapper, env *value.Environment) *value.Error {
+	dict, ok := val.(*value.Dict)
+	if !ok {
+		return newKindError(value.TYPE_ERROR, "cannot destructure %s as dict", val.Type())
+	}
+
+	used := map[value.HashKey]bool{}
+	for _, entry := range pattern.Entries {
+		key := &value.String{Value: entry.Key.Value}
+		used[key.HashKey()] = true
+
+		element, ok := dict.Get(key.HashKey())
+		if !ok {
+			err := bindMissing(entry.Value, env, func() *value.Error {
+				return newKindError(value.TYPE_ERROR, "missing key to destructure: %s", key.Value)
+			})
+			if err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		if err := bindPattern(entry.Value, element.Value, env); err != nil {
+			return err
+		}
+	}
+
+	if pattern.Rest != nil {
+		rest := value.NewDict()
+		for _, hashKey := range dict.HashKeys() {
+			if !used[hashKey] {
+				rest.Set(hashKey, dict.Elements[hashKey])
+			}
+		}
+
+		env.Set(pattern.Rest.Value, rest)
+	}
+
+	return nil
+}
@@ -0,0 +1,143 @@
+package evaluator
+
+import (
+	"time"
+
+	"github.com/aeremic/cgo/profile"
+	"github.com/aeremic/cgo/value"
+)
+
+// profiledCall Call being applied while profiling
+type profiledCall struct {
+	node     int // Index of the call tree node of this call
+	start    time.Time
+	children time.Duration // Time spent in calls this one made
+}
+
+// callTreeNode Function called through the path of calls from the root.
+// Each node gets a sample the first time a call of it exits.
+type callTreeNode struct {
+	parent   int
+	function int         // Index in profile.Functions
+	children map[int]int // Nodes of functions called from this one, by function index
+	sample   *profile.Sample
+}
+
+// profiler Records calls of user functions and builtins into a profile
+type profiler struct {
+	profile   *profile.Profile
+	functions map[profile.Function]int // Indexes in profile.Functions
+	nodes     []callTreeNode           // The first one is root, parent of top level code
+	calls     []profiledCall           // Calls being applied, top level code first
+}
+
+// activeProfiler Profiler recording calls, nil when profiling is off
+var activeProfiler *profiler
+
+// StartProfiling Starts recording time and count of calls. Time spent
+// outside of any call is attributed to top level code.
+func StartProfiling() {
+	now := time.Now()
+
+	activeProfiler = &profiler{
+		profile:   &profile.Profile{Start: now},
+		functions: map[profile.Function]int{},
+		nodes:     []callTreeNode{{parent: -1, function: -1}},
+	}
+	activeProfiler.enter(profile.Function{Name: profile.MAIN})
+}
+
+// StopProfiling Stops recording and returns profile of calls made since
+// StartProfiling, nil when profiling wasn't started
+func StopProfiling() *profile.Profile {
+	p := activeProfiler
+	if p == nil {
+		return nil
+	}
+
+	activeProfiler = nil
+
+	// Calls interrupted by a panic never exited
+	for len(p.calls) > 0 {
+		p.exit()
+	}
+
+	p.profile.Duration = time.Since(p.profile.Start)
+
+	return p.profile
+}
+
+// enter Starts call of the function made by the innermost call
+func (p *profiler) enter(function profile.Function) {
+	index, ok := p.functions[function]
+	if !ok {
+		index = len(p.profile.Functions)
+		p.functions[function] = index
+		p.profile.Functions = append(p.profile.Functions, function)
+	}
+
+	parent := 0
+	if len(p.calls) > 0 {
+		parent = p.calls[len(p.calls)-1].node
+	}
+
+	node, ok := p.nodes[parent].children[index]
+	if !ok {
+		node = len(p.nodes)
+		p.nodes = append(p.nodes, callTreeNode{parent: parent, function: index})
+
+		if p.nodes[parent].children == nil {
+			p.nodes[parent].children = map[int]int{}
+		}
+
+		p.nodes[parent].children[index] = node
+	}
+
+	p.calls = append(p.calls, profiledCall{node: node, start: time.Now()})
+}
+
+// exit Ends the innermost call and adds its own time to the sample of its node
+func (p *profiler) exit() {
+	call := p.calls[len(p.calls)-1]
+	p.calls = p.calls[:len(p.calls)-1]
+
+	elapsed := time.Since(call.start)
+	if len(p.calls) > 0 {
+		p.calls[len(p.calls)-1].children += elapsed
+	}
+
+	node := &p.nodes[call.node]
+	if node.sample == nil {
+		node.sample = &profile.Sample{Stack: p.stack(call.node)}
+		p.profile.Samples = append(p.profile.Samples, node.sample)
+	}
+
+	node.sample.Calls++
+	node.sample.Time += elapsed - call.children
+}
+
+// stack Returns indexes of functions on the path to the node, innermost first
+func (p *profiler) stack(node int) []int {
+	stack := []int{}
+	for ; node > 0; node = p.nodes[node].parent {
+		stack = append(stack, p.nodes[node].function)
+	}
+
+	return stack
+}
+
+// profiledFunction Identifies user function by its name and the position
+// of its body, so closures made by the same literal share a function
+func profiledFunction(fn *value.Function) profile.Function {
+	name := fn.Name
+	if name == "" {
+		name = anonymousFunctionName
+	}
+
+	function := profile.Function{Name: name, File: fn.Env.File()}
+	if fn.Body != nil {
+		function.Line, function.Column = fn.Body.Token.Line, fn.Body.Token.Column
+	}
+
+	return function
+}
@@ -0,0 +1,109 @@
+package evaluator
+
+import (
+	"fmt"
+
+	"github.com/aeremic/cgo/ast"
+	"github.com/aeremic/cgo/token"
+	"github.com/aeremic/cgo/value"
+)
+
+// quote Wraps node without evaluating it. Calls to unquote inside of it
+// are evaluated and replaced by AST of their result in a copy of the node,
+// so quoting the same code again unquotes it anew.
+func quote(node ast.Node, env *value.Environment) value.Wrapper {
+	var errWrapper value.Wrapper
+
+	node, err := ast.Modify(ast.Copy(node), func(node ast.Node) ast.Node {
+		if errWrapper != nil || !isUnquoteCall(node) {
+			return node
+		}
+
+		call := node.(*ast.CallExpression)
+		if len(call.Arguments) != 1 {
+			errWrapper = newKindError(value.TYPE_ERROR, "wrong number of arguments. got=%d, want=%d",
+				len(call.Arguments), 1)
+
+			return node
+		}
+
+		unquoted := Eval(call.Arguments[0], env)
+		if isError(unquoted) {
+			errWrapper = unquoted
+			return node
+		}
+
+		converted, ok := convertValueToASTNode(unquoted)
+		if !ok {
+			errWrapper = newKindError(value.TYPE_ERROR, "unable to unquote %s", unquoted.Type())
+			return node
+		}
+
+		return converted
+	})
+
+	if errWrapper != nil {
+		return errWrapper
+	}
+
+	if err != nil {
+		return newKindError(value.TYPE_ERROR, "unable to unquote: %s", err)
+	}
+
+	return &value.Quote{Node: node}
+}
+
+func isQuoteCall(node ast.Node, name string) bool {
+	call, ok := node.(*ast.CallExpression)
+	if !ok {
+		return false
+	}
+
+	identifier, ok := call.Function.(*ast.Identifier)
+
+	return ok && identifier.Value == name
+}
+
+func isUnquoteCall(node ast.Node) bool {
+	return isQuoteCall(node, "unquote")
+}
+
+// convertValueToASTNode Returns expression evaluating to the given value.
+// Quotes are spliced as they are.
+func convertValueToASTNode(val value.Wrapper) (ast.Expression, bool) {
+	switch val := val.(type) {
+	case *value.Integer:
+		t := token.Token{Type: token.INT, Literal: fmt.Sprintf("%d", val.Value)}
+		return &ast.IntegerLiteral{Token: t, Value: val.Value}, true
+	case *value.String:
+		t := token.Token{Type: token.STRING, Literal: val.Value}
+		return &ast.StringLiteral{Token: t, Value: val.Value}, true
+	case *value.Boolean:
+		t := token.Token{Type: token.FALSE, Literal: "false"}
+		if val.Value {
+			t = token.Token{Type: token.TRUE, Literal: "true"}
+		}
+
+		return &ast.Boolean{Token: t, Value: val.Value}, true
+	case *value.Null:
+		return &ast.NullLiteral{Token: token.Token{Type: token.NULL, Literal: "null"}}, true
+	case *value.Array:
+		elements := make([]ast.Expression, len(val.Elements))
+		for i, element := range val.Elements {
+			converted, ok := convertValueToASTNode(element)
+			if !ok {
+				return nil, false
+			}
+
+			elements[i] = converted
+		}
+
+		t := token.Token{Type: token.LBRACKET, Literal: "["}
+		return &ast.ArrayLiteral{Token: t, Elements: elements}, true
+	case *value.Quote:
+		expression, ok := val.Node.(ast.Expression)
+		return expression, ok
+	default:
+		return nil, false
+	}
+}
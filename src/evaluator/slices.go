@@ -0,0 +1,131 @@
+package evaluator
+
+import (
+	"github.com/aeremic/cgo/ast"
+	"github.com/aeremic/cgo/value"
+)
+
+// resolveIndex Converts negative index counting from the end into position
+// and reports whether it falls within sequence of given length
+func resolveIndex(idx, length int64) (int64, bool) {
+	if idx < 0 {
+		idx += length
+	}
+
+	return idx, idx >= 0 && idx < length
+}
+
+// evalSliceExpression Evaluates a[start:end:step] on arrays, strings and
+// ranges. Arrays and strings are copied, slicing a range gives a new range.
+func evalSliceExpression(left value.Wrapper, slice *ast.SliceIndex, env *value.Environment) value.Wrapper {
+	bounds := []value.Wrapper{nil, nil, nil}
+	for i, exp := range []ast.Expression{slice.Start, slice.End, slice.Step} {
+		if exp == nil {
+			continue
+		}
+
+		bound := Eval(exp, env)
+		if isError(bound) {
+			return bound
+		}
+
+		bounds[i] = bound
+	}
+
+	var length int64
+	switch left := left.(type) {
+	case *value.Array:
+		length = int64(len(left.Elements))
+	case *value.String:
+		length = int64(len([]rune(left.Value)))
+	case *value.Range:
+		length = left.Len()
+	default:
+		return newKindError(value.TYPE_ERROR, "slice operator not supported: %s", left.Type())
+	}
+
+	positions, err := slicePositions(length, bounds[0], bounds[1], bounds[2])
+	if err != nil {
+		return err
+	}
+
+	switch left := left.(type) {
+	case *value.Array:
+		result := make([]value.Wrapper, positions.Len())
+		for i := range result {
+			result[i] = left.Elements[positions.At(int64(i))]
+		}
+
+		return &value.Array{Elements: result}
+	case *value.String:
+		runes := []rune(left.Value)
+		result := make([]rune, positions.Len())
+		for i := range result {
+			result[i] = runes[positions.At(int64(i))]
+		}
+
+		return &value.String{Value: string(result)}
+	default:
+		r := left.(*value.Range)
+		start := r.At(positions.Start)
+		step := r.Step * positions.Step
+
+		return &value.Range{Start: start, End: start + positions.Len()*step, Step: step}
+	}
+}
+
+// slicePositions Resolves slice bounds into range of positions in sequence
+// of given length. Bounds follow Python rules: missing or null bounds cover
+// the whole sequence in step direction, negative bounds count from the end
+// and out of range bounds are clamped.
+func slicePositions(length int64, start, end, step value.Wrapper) (*value.Range, *value.Error) {
+	integers := make([]*value.Integer, 3)
+	for i, bound := range []value.Wrapper{start, end, step} {
+		if bound == nil || bound == NULL {
+			continue
+		}
+
+		integer, ok := bound.(*value.Integer)
+		if !ok {
+			return nil, newKindError(value.TYPE_ERROR, "slice index must be INTEGER. got=%s",
+				bound.Type())
+		}
+
+		integers[i] = integer
+	}
+
+	positions := &value.Range{Step: 1}
+	if integers[2] != nil {
+		positions.Step = integers[2].Value
+	}
+
+	if positions.Step == 0 {
+		return nil, newError("slice step must not be zero")
+	}
+
+	// Walking backwards starts at the last element and may stop before the first
+	lower, upper := int64(0), length
+	positions.Start, positions.End = 0, length
+	if positions.Step < 0 {
+		lower, upper = -1, length-1
+		positions.Start, positions.End = length-1, -1
+	}
+
+	resolve := func(bound *value.Integer, idx *int64) {
+		if bound == nil {
+			return
+		}
+
+		*idx = bound.Value
+		if *idx < 0 {
+			*idx += length
+		}
+
+		*idx = min(max(*idx, lower), upper)
+	}
+
+	resolve(integers[0], &positions.Start)
+	resolve(integers[1], &positions.End)
+
+	return positions, nil
+}
@@ -0,0 +1,85 @@
+package evaluator
+
+import (
+	"github.com/aeremic/cgo/ast"
+	"github.com/aeremic/cgo/value"
+)
+
+// evalStructStatement Binds struct type to its name. Instances are typed by
+// the name, so names of builtin types are rejected.
+func evalStructStatement(node *ast.StructStatement, env *value.Environment) value.Wrapper {
+	if value.IsBuiltinType(node.Name.Value) {
+		return newKindError(value.NAME_ERROR, "struct name %s is reserved for builtin type", node.Name.Value)
+	}
+
+	structType := &value.StructType{
+		Name:       node.Name.Value,
+		Fields:     []string{},
+		Parameters: node.Fields,
+		Methods:    map[string]*value.Function{},
+		Env:        env,
+	}
+
+	for _, field := range node.Fields {
+		structType.Fields = append(structType.Fields, ast.PatternIdentifiers(field)[0].Value)
+	}
+
+	for _, method := range node.Methods {
+		structType.Methods[method.Name.Value] = &value.Function{
+			Name:       node.Name.Value + "." + method.Name.Value,
+			Parameters: method.Function.Parameters,
+			Rest:       method.Function.Rest,
+			Body:       method.Function.Body,
+			Env:        env,
+		}
+	}
+
+	env.Set(node.Name.Value, structType)
+
+	return nil
+}
+
+// constructStruct Binds constructor arguments to fields the same way
+// function arguments are bound to parameters
+func constructStruct(structType *value.StructType, args []value.Wrapper, keywords []keywordArgument) value.Wrapper {
+	constructor := &value.Function{
+		Name:       structType.Name,
+		Parameters: structType.Parameters,
+		Env:        structType.Env,
+	}
+
+	pushCall(constructor)
+	defer popCall()
+
+	fieldsEnv := value.NewEnclosedEnvironment(structType.Env)
+	if err := bindArguments(constructor, fieldsEnv, args, keywords); err != nil {
+		return err
+	}
+
+	instance := &value.Struct{
+		Definition: structType,
+		Values:     make([]value.Wrapper, len(structType.Fields)),
+	}
+
+	for i, name := range structType.Fields {
+		instance.Values[i], _ = fieldsEnv.Get(name)
+	}
+
+	return instance
+}
+
+// evalStructMember Resolves field or method bound to the instance
+func evalStructMember(instance *value.Struct, name string) (value.Wrapper, bool) {
+	if field, ok := instance.Field(name); ok {
+		return field, true
+	}
+
+	method, ok := instance.Definition.Methods[name]
+	if !ok {
+		return nil, false
+	}
+
+	return &value.BuiltIn{Name: name, Method: method, Fn: func(args ...value.Wrapper) value.Wrapper {
+		return applyFunction(method, append([]value.Wrapper{instance}, args...))
+	}}, true
+}
@@ -0,0 +1,132 @@
+package evaluator
+
+import (
+	"time"
+
+	"github.com/aeremic/cgo/ast"
+	"github.com/aeremic/cgo/trace"
+	"github.com/aeremic/cgo/value"
+)
+
+// tracer Receives evaluation events, nil when tracing is off
+var tracer trace.Tracer
+
+// SetTracer Reports calls, errors and let bindings to the tracer, nil turns
+// tracing off
+func SetTracer(t trace.Tracer) {
+	tracer = t
+}
+
+func traceEvent(event trace.Event) {
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+
+	event.Depth = len(callStack)
+	tracer.Trace(event)
+}
+
+// traceFunction Applies user function between enter and exit events. The
+// function is identified by its name and definition position.
+func traceFunction(fn *value.Function, args []value.Wrapper, keywords []keywordArgument) value.Wrapper {
+	event := trace.Event{
+		Kind:      trace.ENTER,
+		Function:  fn.Name,
+		File:      fn.Env.File(),
+		Arguments: tracedArguments(args, keywords),
+	}
+	if event.Function == "" {
+		event.Function = anonymousFunctionName
+	}
+
+	if fn.Body != nil {
+		event.Line = fn.Body.Token.Line
+	}
+
+	traceEvent(event)
+
+	start := time.Now()
+	result := applyUserFunction(fn, args, keywords)
+
+	event.Kind, event.Arguments = trace.EXIT, nil
+	event.Time = time.Now()
+	event.Duration = event.Time.Sub(start)
+	setTracedResult(&event, result)
+	traceEvent(event)
+
+	return result
+}
+
+// traceBuiltin Applies builtin and reports the call once it returns
+func traceBuiltin(fn *value.BuiltIn, args []value.Wrapper) value.Wrapper {
+	start := time.Now()
+	result := fn.Fn(args...)
+
+	event := trace.Event{Kind: trace.BUILTIN, Function: fn.Name, Arguments: tracedArguments(args, nil)}
+	event.Time = time.Now()
+	event.Duration = event.Time.Sub(start)
+	setTracedResult(&event, result)
+	traceEvent(event)
+
+	return result
+}
+
+// traceBindings Reports names bound by the let statement
+func traceBindings(node *ast.LetStatement, env *value.Environment) {
+	names := []*ast.Identifier{node.Name}
+	if node.Pattern != nil {
+		names = ast.PatternIdentifiers(node.Pattern)
+	}
+
+	for _, name := range names {
+		bound, _ := env.Get(name.Value)
+		traceEvent(trace.Event{
+			Kind:  trace.BIND,
+			File:  env.File(),
+			Line:  node.Token.Line,
+			Name:  name.Value,
+			Value: tracedValue(bound),
+		})
+	}
+}
+
+// traceError Reports raised error and returns it
+func traceError(errWrapper *value.Error) *value.Error {
+	if tracer != nil {
+		traceEvent(trace.Event{Kind: trace.ERROR, Error: errWrapper.Message, ErrorKind: errWrapper.Kind})
+	}
+
+	return errWrapper
+}
+
+func tracedArguments(args []value.Wrapper, keywords []keywordArgument) []string {
+	traced := []string{}
+	for _, arg := range args {
+		traced = append(traced, tracedValue(arg))
+	}
+
+	for _, keyword := range keywords {
+		traced = append(traced, keyword.name+"="+tracedValue(keyword.value))
+	}
+
+	return traced
+}
+
+func setTracedResult(event *trace.Event, result value.Wrapper) {
+	if errWrapper, ok := result.(*value.Error); ok {
+		event.Error, event.ErrorKind = errWrapper.Message, errWrapper.Kind
+		return
+	}
+
+	event.Result = tracedValue(result)
+}
+
+// tracedValue Returns printed form of the value, statements without value
+// evaluate to nil and are printed as null
+func tracedValue(v value.Wrapper) string {
+	if v == nil {
+		return NULL.Sprintf()
+	}
+
+	return v.Sprintf()
+}
@@ -0,0 +1,477 @@
+package format
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/aeremic/cgo/ast"
+	"github.com/aeremic/cgo/token"
+)
+
+// Binding strength of expressions, mirrors precedences of the parser
+const (
+	_ int = iota
+	lowest
+	assign
+	pipe
+	coalesce
+	equals
+	lessGreater
+	rangeOperator
+	sum
+	product
+	prefix
+	call
+	index
+	primary // Literals, identifiers and block expressions
+)
+
+var infixPrecedences = map[string]int{
+	"??":  coalesce,
+	"==":  equals,
+	"!=":  equals,
+	"<":   lessGreater,
+	">":   lessGreater,
+	"..":  rangeOperator,
+	"..=": rangeOperator,
+	"+":   sum,
+	"-":   sum,
+	"*":   product,
+	"/":   product,
+}
+
+func precedenceOf(expression ast.Expression) int {
+	switch expression := expression.(type) {
+	case *ast.AssignExpression:
+		return assign
+	case *ast.PipeExpression:
+		return pipe
+	case *ast.InfixExpression:
+		return infixPrecedences[expression.Operator]
+	case *ast.PrefixExpression, *ast.SpreadExpression:
+		return prefix
+	case *ast.CallExpression:
+		return call
+	case *ast.IndexExpression, *ast.MemberExpression:
+		return index
+	default:
+		return primary
+	}
+}
+
+// expression Prints expression, parenthesized when it binds weaker than
+// precedence required by its position
+func (p *printer) expression(expression ast.Expression, precedence int) {
+	if precedenceOf(expression) < precedence {
+		p.write("(")
+		p.expression(expression, lowest)
+		p.write(")")
+
+		return
+	}
+
+	switch expression := expression.(type) {
+	case *ast.Identifier:
+		p.write(expression.Value)
+	case *ast.IntegerLiteral:
+		p.write(strconv.FormatInt(expression.Value, 10))
+	case *ast.StringLiteral:
+		p.write(quote(expression.Value))
+	case *ast.Boolean:
+		p.write(strconv.FormatBool(expression.Value))
+	case *ast.NullLiteral:
+		p.write("null")
+	case *ast.PrefixExpression:
+		p.write(expression.Operator)
+
+		// Keeps -(-x) from reading as --x
+		if right, ok := expression.Right.(*ast.PrefixExpression); ok && right.Operator == "-" && expression.Operator == "-" {
+			p.expression(expression.Right, primary)
+		} else {
+			p.expression(expression.Right, prefix)
+		}
+	case *ast.SpreadExpression:
+		p.write("...")
+		p.expression(expression.Value, prefix)
+	case *ast.InfixExpression:
+		precedence := infixPrecedences[expression.Operator]
+
+		p.expression(expression.Left, precedence)
+		if precedence == rangeOperator {
+			p.write(expression.Operator)
+		} else {
+			p.write(" " + expression.Operator + " ")
+		}
+		p.expression(expression.Right, precedence+1)
+	case *ast.PipeExpression:
+		p.expression(expression.Left, pipe)
+		p.write(" |> ")
+		p.expression(expression.Right, pipe+1)
+	case *ast.AssignExpression:
+		p.expression(expression.Target, call)
+		p.write(" = ")
+		p.expression(expression.Value, assign)
+	case *ast.CallExpression:
+		p.expression(expression.Function, call)
+		if expression.Optional {
+			p.write("?.")
+		}
+		p.list(expression, "(", ")", expressionElements(expression.Arguments))
+	case *ast.IndexExpression:
+		p.expression(expression.Left, call)
+		if expression.Optional {
+			p.write("?.")
+		}
+		p.write("[")
+		p.expression(expression.Index, lowest)
+		p.write("]")
+	case *ast.SliceIndex:
+		p.sliceIndex(expression)
+	case *ast.MemberExpression:
+		p.expression(expression.Object, call)
+		if expression.Optional {
+			p.write("?.")
+		} else {
+			p.write(".")
+		}
+		p.write(expression.Property.Value)
+	case *ast.KeywordArgument:
+		p.write(expression.Name.Value + ": ")
+		p.expression(expression.Value, lowest)
+	case *ast.ArrayLiteral:
+		p.list(expression, "[", "]", expressionElements(expression.Elements))
+	case *ast.DictLiteral:
+		p.list(expression, "{", "}", dictElements(expression.Elements))
+	case *ast.FunctionLiteral:
+		p.write("fn")
+		p.function(expression)
+	case *ast.MacroLiteral:
+		parameters := []string{}
+		for _, parameter := range expression.Parameters {
+			parameters = append(parameters, parameter.Value)
+		}
+
+		p.write("macro(" + strings.Join(parameters, ", ") + ") ")
+		p.block(expression.Body)
+	case *ast.IfExpression:
+		p.write("if (")
+		p.expression(expression.Condition, lowest)
+		p.write(") ")
+		p.block(expression.Consequence)
+
+		if expression.Alternative != nil {
+			p.write(" else ")
+			p.block(expression.Alternative)
+		}
+	case *ast.TryExpression:
+		p.write("try ")
+		p.block(expression.Block)
+
+		if expression.Catch != nil {
+			p.write(" catch (" + expression.CatchParam.Value + ") ")
+			p.block(expression.Catch)
+		}
+
+		if expression.Finally != nil {
+			p.write(" finally ")
+			p.block(expression.Finally)
+		}
+	case *ast.MatchExpression:
+		p.match(expression)
+	case ast.Pattern:
+		p.pattern(expression)
+	}
+}
+
+func (p *printer) sliceIndex(slice *ast.SliceIndex) {
+	if slice.Start != nil {
+		p.expression(slice.Start, lowest)
+	}
+
+	p.write(":")
+
+	if slice.End != nil {
+		p.expression(slice.End, lowest)
+	}
+
+	if slice.Step != nil {
+		p.write(":")
+		p.expression(slice.Step, lowest)
+	}
+}
+
+// function Prints parameters, return type and body of function literal or method
+func (p *printer) function(function *ast.FunctionLiteral) {
+	p.write("(")
+
+	for i, parameter := range function.Parameters {
+		if i > 0 {
+			p.write(", ")
+		}
+
+		p.parameter(parameter, function.ParameterType(i))
+	}
+
+	p.rest(function.Rest, len(function.Parameters) > 0)
+
+	p.write(") ")
+	if function.ReturnType != nil {
+		p.write("-> ")
+		p.typeExpression(function.ReturnType)
+		p.write(" ")
+	}
+
+	p.block(function.Body)
+}
+
+// parameter Prints parameter with its annotation placed before default value
+func (p *printer) parameter(parameter ast.Pattern, annotation ast.TypeExpression) {
+	if annotation == nil {
+		p.pattern(parameter)
+		return
+	}
+
+	defaultPattern, ok := parameter.(*ast.DefaultPattern)
+	if ok {
+		parameter = defaultPattern.Target
+	}
+
+	p.pattern(parameter)
+	p.write(": ")
+	p.typeExpression(annotation)
+
+	if ok {
+		p.write(" = ")
+		p.expression(defaultPattern.Default, assign+1)
+	}
+}
+
+func (p *printer) typeExpression(annotation ast.TypeExpression) {
+	switch annotation := annotation.(type) {
+	case *ast.NamedType:
+		p.write(annotation.Name)
+	case *ast.ArrayType:
+		p.write("[")
+		p.typeExpression(annotation.Element)
+		p.write("]")
+	case *ast.DictType:
+		p.write("{")
+		p.typeExpression(annotation.Key)
+		p.write(": ")
+		p.typeExpression(annotation.Value)
+		p.write("}")
+	case *ast.FunctionType:
+		p.write("fn(")
+		for i, parameter := range annotation.Parameters {
+			if i > 0 {
+				p.write(", ")
+			}
+
+			p.typeExpression(parameter)
+		}
+
+		p.write(")")
+		if annotation.Return != nil {
+			p.write(" -> ")
+			p.typeExpression(annotation.Return)
+		}
+	}
+}
+
+// match Prints match arms one per line, each followed by a comma
+func (p *printer) match(match *ast.MatchExpression) {
+	arms := []element{}
+	for _, arm := range match.Arms {
+		first, _ := ast.Span(arm.Pattern)
+		_, last := ast.Span(arm.Body)
+
+		arms = append(arms, element{first: first, last: last, print: func(p *printer) {
+			p.pattern(arm.Pattern)
+			if arm.Guard != nil {
+				p.write(" if ")
+				p.expression(arm.Guard, lowest)
+			}
+
+			p.write(" => ")
+			p.expression(arm.Body, lowest)
+			p.write(",")
+		}})
+	}
+
+	p.write("match (")
+	p.expression(match.Subject, lowest)
+	p.write(") {")
+
+	p.indent++
+	p.elements(arms, token.Token{})
+	p.indent--
+
+	p.newline()
+	p.write("}")
+}
+
+// list Prints elements separated by commas on the current line. When they
+// don't fit in line width or contain comments every element gets a line.
+func (p *printer) list(node ast.Node, open, close string, elements []element) {
+	split := len(elements) > 0 && !p.flat && (p.commentsWithin(node) || !p.fits(open, close, elements))
+
+	p.write(open)
+
+	if split {
+		for i := range elements {
+			printElement := elements[i].print
+			last := i == len(elements)-1
+
+			elements[i].print = func(p *printer) {
+				printElement(p)
+				if !last {
+					p.write(",")
+				}
+			}
+		}
+
+		p.indent++
+		p.elements(elements, token.Token{})
+		p.indent--
+
+		p.newline()
+		p.write(close)
+
+		return
+	}
+
+	for i, element := range elements {
+		if i > 0 {
+			p.write(", ")
+		}
+
+		element.print(p)
+	}
+
+	p.write(close)
+}
+
+// fits Reports whether elements printed on the current line fit in line width.
+// Lists holding blocks are kept on the line the block starts at.
+func (p *printer) fits(open, close string, elements []element) bool {
+	measure := &printer{indent: p.indent, flat: true}
+	measure.list(nil, open, close, elements)
+
+	text := measure.out.String()
+	if strings.Contains(text, "\n") {
+		return true
+	}
+
+	return p.column()+len(text) <= lineWidth
+}
+
+func expressionElements(expressions []ast.Expression) []element {
+	elements := []element{}
+	for _, expression := range expressions {
+		first, last := ast.Span(expression)
+		elements = append(elements, element{first: first, last: last, print: func(p *printer) {
+			p.expression(expression, lowest)
+		}})
+	}
+
+	return elements
+}
+
+func dictElements(dictElements []ast.DictLiteralElement) []element {
+	elements := []element{}
+	for _, dictElement := range dictElements {
+		first, _ := ast.Span(dictElement.Key)
+		_, last := ast.Span(dictElement.Value)
+
+		elements = append(elements, element{first: first, last: last, print: func(p *printer) {
+			p.expression(dictElement.Key, lowest)
+			p.write(": ")
+			p.expression(dictElement.Value, lowest)
+		}})
+	}
+
+	return elements
+}
+
+func (p *printer) pattern(pattern ast.Pattern) {
+	switch pattern := pattern.(type) {
+	case *ast.Identifier:
+		p.write(pattern.Value)
+	case *ast.WildcardPattern:
+		p.write("_")
+	case *ast.LiteralPattern:
+		p.expression(pattern.Value, lowest)
+	case *ast.TypePattern:
+		p.write(pattern.Name)
+		if pattern.Binding != nil {
+			p.write("(")
+			p.pattern(pattern.Binding)
+			p.write(")")
+		}
+	case *ast.DefaultPattern:
+		p.pattern(pattern.Target)
+		p.write(" = ")
+		p.expression(pattern.Default, assign+1)
+	case *ast.ArrayPattern:
+		p.write("[")
+		for i, element := range pattern.Elements {
+			if i > 0 {
+				p.write(", ")
+			}
+
+			p.pattern(element)
+		}
+
+		p.rest(pattern.Rest, len(pattern.Elements) > 0)
+		p.write("]")
+	case *ast.DictPattern:
+		p.write("{")
+		for i, entry := range pattern.Entries {
+			if i > 0 {
+				p.write(", ")
+			}
+
+			p.dictPatternEntry(entry)
+		}
+
+		p.rest(pattern.Rest, len(pattern.Entries) > 0)
+		p.write("}")
+	}
+}
+
+// rest Prints ...rest element of a pattern if present
+func (p *printer) rest(rest *ast.Identifier, separated bool) {
+	if rest == nil {
+		return
+	}
+
+	if separated {
+		p.write(", ")
+	}
+
+	p.write("..." + rest.Value)
+}
+
+// dictPatternEntry Prints entry in shorthand form when it binds variable
+// named after the key
+func (p *printer) dictPatternEntry(entry ast.DictPatternEntry) {
+	target := entry.Value
+	if defaultPattern, ok := target.(*ast.DefaultPattern); ok {
+		target = defaultPattern.Target
+	}
+
+	switch identifier, ok := target.(*ast.Identifier); {
+	case entry.Key.Token.Type == token.IDENT && ok && identifier.Value == entry.Key.Value:
+	case entry.Key.Token.Type == token.IDENT:
+		p.write(entry.Key.Value + ": ")
+	default:
+		p.write(quote(entry.Key.Value) + ": ")
+	}
+
+	p.pattern(entry.Value)
+}
+
+// quote Returns string literal source, values keep escapes as written
+func quote(value string) string {
+	return "\"" + value + "\""
+}
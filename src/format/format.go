@@ -0,0 +1,369 @@
+package format
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/aeremic/cgo/ast"
+	"github.com/aeremic/cgo/parser"
+	"github.com/aeremic/cgo/token"
+	"github.com/aeremic/cgo/tokenizer"
+)
+
+const (
+	indentation = "    "
+	lineWidth   = 80 // Calls and literals longer than this are split one element per line
+)
+
+// endOfSource Position after every token of the source
+var endOfSource = token.Token{Line: math.MaxInt}
+
+// Source Returns canonical form of the source code. Comments are kept, blank
+// lines between statements are collapsed to one. Formatting the result again
+// returns it unchanged.
+func Source(source []byte) ([]byte, error) {
+	p := parser.New(tokenizer.New(string(source)))
+
+	program := p.ParseProgram()
+	if len(p.Errors()) > 0 {
+		return nil, fmt.Errorf("%s", strings.Join(p.Errors(), "\n"))
+	}
+
+	pr := &printer{
+		lines:    strings.Split(string(source), "\n"),
+		comments: program.Comments,
+	}
+	pr.program(program)
+
+	return pr.out.Bytes(), nil
+}
+
+// Node Returns canonical form of the node without comments
+func Node(node ast.Node) string {
+	pr := &printer{}
+
+	switch node := node.(type) {
+	case *ast.ProgramRoot:
+		pr.program(node)
+	case ast.Statement:
+		pr.statement(node, nil)
+	case ast.Pattern:
+		pr.pattern(node)
+	case ast.TypeExpression:
+		pr.typeExpression(node)
+	case ast.Expression:
+		pr.expression(node, lowest)
+	}
+
+	return pr.out.String()
+}
+
+type printer struct {
+	out      bytes.Buffer
+	indent   int
+	lines    []string      // Source lines, used to keep blank lines and trailing comments
+	comments []token.Token // Comments of the source in order
+	next     int           // Index of the first comment not printed yet
+	line     int           // Source line where the last printed element or comment ends
+	flat     bool          // Set while measuring, line breaks and comments are skipped
+}
+
+func (p *printer) write(s string) {
+	p.out.WriteString(s)
+}
+
+func (p *printer) newline() {
+	p.write("\n" + strings.Repeat(indentation, p.indent))
+}
+
+// lineStart Starts new line for code found at the source line. Blank lines
+// separating it from previous code are kept as one unless the line opens
+// a block or a list.
+func (p *printer) lineStart(line int) {
+	if p.out.Len() == 0 {
+		return
+	}
+
+	output := p.out.Bytes()
+	switch output[len(output)-1] {
+	case '{', '[', '(':
+	default:
+		for l := p.line + 1; l < line && l <= len(p.lines); l++ {
+			if strings.TrimSpace(p.lines[l-1]) == "" {
+				p.write("\n")
+				break
+			}
+		}
+	}
+
+	p.newline()
+}
+
+// column Returns width of the current output line
+func (p *printer) column() int {
+	output := p.out.Bytes()
+	return len(output) - (bytes.LastIndexByte(output, '\n') + 1)
+}
+
+// trailing Reports whether code precedes the comment on its line
+func (p *printer) trailing(comment token.Token) bool {
+	line := p.lines[comment.Line-1]
+	return strings.TrimSpace(line[:comment.Column-1]) != ""
+}
+
+// commentsBefore Reports whether unprinted comments start before position
+func (p *printer) commentsBefore(position token.Token) bool {
+	return !p.flat && p.next < len(p.comments) && p.comments[p.next].Before(position)
+}
+
+// commentsWithin Reports whether unprinted comments are found inside node
+func (p *printer) commentsWithin(node ast.Node) bool {
+	if p.flat {
+		return false
+	}
+
+	first, last := ast.Span(node)
+	for _, comment := range p.comments[p.next:] {
+		if !comment.Before(last) {
+			break
+		}
+
+		if first.Before(comment) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// leadingComments Prints comments found before position. Comments following
+// code on their line stay on the current line, others get a line of their own.
+func (p *printer) leadingComments(position token.Token) {
+	for p.commentsBefore(position) {
+		comment := p.comments[p.next]
+		p.next++
+
+		if p.trailing(comment) && p.out.Len() > 0 {
+			p.write(" ")
+		} else {
+			p.lineStart(comment.Line)
+		}
+
+		p.write(comment.Literal)
+		p.line = comment.Line
+	}
+}
+
+// trailingComments Prints comments up to the line where printed code ends.
+// Comments after the line or from limit on are left for the following code,
+// zero limit stands for no limit.
+func (p *printer) trailingComments(line int, limit token.Token) {
+	for !p.flat && p.next < len(p.comments) {
+		comment := p.comments[p.next]
+		if comment.Line > line || limit.Line > 0 && !comment.Before(limit) {
+			return
+		}
+
+		if p.trailing(comment) {
+			p.write(" ")
+		} else {
+			p.lineStart(comment.Line)
+		}
+
+		p.write(comment.Literal)
+		p.line = comment.Line
+		p.next++
+	}
+}
+
+// element Part of a statement list, struct, match or split literal
+// printed on a line of its own
+type element struct {
+	first, last token.Token
+	print       func(p *printer)
+}
+
+// elements Prints elements on lines of their own together with comments
+// around them. Comments before end are printed after the last element.
+func (p *printer) elements(elements []element, end token.Token) {
+	for i, element := range elements {
+		limit := end
+		if i+1 < len(elements) {
+			limit = elements[i+1].first
+		}
+
+		p.leadingComments(element.first)
+		p.lineStart(element.first.Line)
+		element.print(p)
+		p.line = max(p.line, element.last.Line)
+		p.trailingComments(element.last.Line, limit)
+	}
+
+	p.leadingComments(end)
+}
+
+func (p *printer) program(program *ast.ProgramRoot) {
+	p.statements(program.Statements, endOfSource)
+
+	if p.out.Len() > 0 {
+		p.write("\n")
+	}
+}
+
+// statements Prints statements followed by comments found before end
+func (p *printer) statements(statements []ast.Statement, end token.Token) {
+	elements := []element{}
+	for i, statement := range statements {
+		var next ast.Statement
+		if i+1 < len(statements) {
+			next = statements[i+1]
+		}
+
+		first, last := ast.Span(statement)
+		elements = append(elements, element{first: first, last: last, print: func(p *printer) {
+			p.statement(statement, next)
+		}})
+	}
+
+	p.elements(elements, end)
+}
+
+func (p *printer) block(block *ast.BlockStatement) {
+	p.write("{")
+
+	if len(block.Statements) == 0 && !p.commentsBefore(block.Rbrace) {
+		p.write("}")
+		return
+	}
+
+	p.indent++
+	p.statements(block.Statements, block.Rbrace)
+	p.indent--
+
+	p.newline()
+	p.write("}")
+}
+
+// statement Prints statement, next is the statement following it if any
+func (p *printer) statement(statement ast.Statement, next ast.Statement) {
+	switch statement := statement.(type) {
+	case *ast.LetStatement:
+		p.let(statement)
+	case *ast.ReturnStatement:
+		p.write("return ")
+		p.expression(statement.ReturnValue, lowest)
+		p.write(";")
+	case *ast.ThrowStatement:
+		p.write("throw ")
+		p.expression(statement.Value, lowest)
+		p.write(";")
+	case *ast.ExportStatement:
+		p.write("export ")
+		p.let(statement.Statement)
+	case *ast.ImportStatement:
+		p.importStatement(statement)
+	case *ast.StructStatement:
+		p.structStatement(statement)
+	case *ast.ExpressionStatement:
+		p.expression(statement.Expression, lowest)
+		if !endsWithBlock(statement.Expression) || continuesExpression(next) {
+			p.write(";")
+		}
+	}
+}
+
+func (p *printer) let(statement *ast.LetStatement) {
+	p.write("let ")
+
+	if statement.Pattern != nil {
+		p.pattern(statement.Pattern)
+	} else {
+		p.write(statement.Name.Value)
+	}
+
+	if statement.Type != nil {
+		p.write(": ")
+		p.typeExpression(statement.Type)
+	}
+
+	p.write(" = ")
+	p.expression(statement.Value, lowest)
+	p.write(";")
+}
+
+func (p *printer) importStatement(statement *ast.ImportStatement) {
+	if statement.Token.Type == token.FROM {
+		names := []string{}
+		for _, name := range statement.Names {
+			names = append(names, name.Value)
+		}
+
+		p.write("from " + quote(statement.Path.Value) + " import " + strings.Join(names, ", ") + ";")
+
+		return
+	}
+
+	p.write("import " + quote(statement.Path.Value))
+	if statement.Alias != nil {
+		p.write(" as " + statement.Alias.Value)
+	}
+
+	p.write(";")
+}
+
+// structStatement Prints fields followed by methods, one member per line
+func (p *printer) structStatement(statement *ast.StructStatement) {
+	members := []element{}
+
+	for _, field := range statement.Fields {
+		first, last := ast.Span(field)
+		members = append(members, element{first: first, last: last, print: func(p *printer) {
+			p.pattern(field)
+			p.write(",")
+		}})
+	}
+
+	for _, method := range statement.Methods {
+		first, last := ast.Span(method.Function)
+		members = append(members, element{first: first, last: last, print: func(p *printer) {
+			p.write("fn " + method.Name.Value)
+			p.function(method.Function)
+		}})
+	}
+
+	p.write("struct " + statement.Name.Value + " {")
+	p.indent++
+	p.elements(members, token.Token{})
+	p.indent--
+
+	p.newline()
+	p.write("}")
+}
+
+// endsWithBlock Reports whether expression statement reads as a block and
+// needs no terminating semicolon
+func endsWithBlock(expression ast.Expression) bool {
+	switch expression.(type) {
+	case *ast.IfExpression, *ast.TryExpression, *ast.MatchExpression:
+		return true
+	default:
+		return false
+	}
+}
+
+// continuesExpression Reports whether printed statement starts with a token
+// that would continue previous expression without a semicolon in between
+func continuesExpression(statement ast.Statement) bool {
+	if statement == nil {
+		return false
+	}
+
+	switch Node(statement)[0] {
+	case '(', '[', '-':
+		return true
+	default:
+		return false
+	}
+}
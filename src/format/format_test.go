@@ -0,0 +1,239 @@
+package format
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/aeremic/cgo/ast"
+	"github.com/aeremic/cgo/parser"
+	"github.com/aeremic/cgo/tokenizer"
+)
+
+func parse(t *testing.T, input string) *ast.ProgramRoot {
+	p := parser.New(tokenizer.New(input))
+
+	program := p.ParseProgram()
+	if len(p.Errors()) > 0 {
+		t.Fatalf("parser errors for %q: %v", input, p.Errors())
+	}
+
+	return program
+}
+
+func TestSource(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"", ""},
+		{"let x=5", "let x = 5;\n"},
+		{"let x = 5;let y = x", "let x = 5;\nlet y = x;\n"},
+		{"let x = 1;\n\n\n\nlet y = 2;", "let x = 1;\n\nlet y = 2;\n"},
+		{"1+2*3", "1 + 2 * 3;\n"},
+		{"(1+2)*3", "(1 + 2) * 3;\n"},
+		{"1-(2-3)", "1 - (2 - 3);\n"},
+		{"(1-2)-3", "1 - 2 - 3;\n"},
+		{"-(-x)", "-(-x);\n"},
+		{"!!x", "!!x;\n"},
+		{"(-a)[0]", "(-a)[0];\n"},
+		{"-f(x)[0]", "-f(x)[0];\n"},
+		{"a ?? (b ?? c)", "a ?? (b ?? c);\n"},
+		{"1 .. 10", "1..10;\n"},
+		{"(1 + 1)..=n", "1 + 1..=n;\n"},
+		{"a |> f(1) |> g", "a |> f(1) |> g;\n"},
+		{"a |> (b |> f)", "a |> (b |> f);\n"},
+		{"a[0] = b[1] = 2", "a[0] = b[1] = 2;\n"},
+		{"p . x = 1", "p.x = 1;\n"},
+		{"a?.b?.[1]?.(2)", "a?.b?.[1]?.(2);\n"},
+		{"a[1:2];a[:];a[::2]", "a[1:2];\na[:];\na[::2];\n"},
+		{"f(x, ...rest, key: 1)", "f(x, ...rest, key: 1);\n"},
+		{`{"a":1,"b":[1,2]}`, "{\"a\": 1, \"b\": [1, 2]};\n"},
+		{"fn(){}", "fn() {};\n"},
+		{
+			"let add = fn(a, b = 1, ...rest) { return a + b }",
+			"let add = fn(a, b = 1, ...rest) {\n    return a + b;\n};\n",
+		},
+		{
+			"let [a, [b, c] = [1, 2], ...r] = x; let {a, b: c, \"d e\": f = 1, ...r} = y",
+			"let [a, [b, c] = [1, 2], ...r] = x;\nlet {a, b: c, \"d e\": f = 1, ...r} = y;\n",
+		},
+		{
+			"if (x > 1) { puts(\"a\") } else { puts(\"b\") }",
+			"if (x > 1) {\n    puts(\"a\");\n} else {\n    puts(\"b\");\n}\n",
+		},
+		{
+			"if (x) { 1 }; [1, 2]",
+			"if (x) {\n    1;\n};\n[1, 2];\n",
+		},
+		{
+			"try { throw \"e\" } catch(e) { e } finally { null }",
+			"try {\n    throw \"e\";\n} catch (e) {\n    e;\n} finally {\n    null;\n}\n",
+		},
+		{
+			"match (x) { 1 => \"one\", -1 => \"minus\", int(n) if n > 1 => \"many\", [a, ...r] => a, {name, ...o} => name, _ => \"other\" }",
+			"match (x) {\n    1 => \"one\",\n    -1 => \"minus\",\n    int(n) if n > 1 => \"many\",\n    [a, ...r] => a,\n" +
+				"    {name, ...o} => name,\n    _ => \"other\",\n}\n",
+		},
+		{
+			"struct Point { x, y = 0, fn norm(self) { self.x * self.x } }",
+			"struct Point {\n    x,\n    y = 0,\n    fn norm(self) {\n        self.x * self.x;\n    }\n}\n",
+		},
+		{
+			"from \"lib\" import a, b\nimport \"m\" as m\nimport \"n\"\nexport let z = 1",
+			"from \"lib\" import a, b;\nimport \"m\" as m;\nimport \"n\";\nexport let z = 1;\n",
+		},
+		{
+			"let x:int=5; let f = fn(a:int, b:[string]=[], c, ...r)->{string: fn(int)->bool} { null }",
+			"let x: int = 5;\nlet f = fn(a: int, b: [string] = [], c, ...r) -> {string: fn(int) -> bool} {\n    null;\n};\n",
+		},
+		{
+			"let m = macro(a, b) { quote(unquote(a) + unquote(b)) }",
+			"let m = macro(a, b) {\n    quote(unquote(a) + unquote(b));\n};\n",
+		},
+		{
+			"let result = some_function(first_argument, second_argument, third_argument, fourth)",
+			"let result = some_function(\n    first_argument,\n    second_argument,\n    third_argument,\n    fourth\n);\n",
+		},
+		{
+			"let d = {\"key\": [100000, 200000, 300000, 400000, 500000], \"other\": [600000, 700000]}",
+			"let d = {\n    \"key\": [100000, 200000, 300000, 400000, 500000],\n    \"other\": [600000, 700000]\n};\n",
+		},
+		{
+			"map(some_long_array_name, fn(element) { element * element + another_long_name })",
+			"map(some_long_array_name, fn(element) {\n    element * element + another_long_name;\n});\n",
+		},
+	}
+
+	for _, test := range tests {
+		formatted, err := Source([]byte(test.input))
+		if err != nil {
+			t.Errorf("Source(%q) returned error: %v", test.input, err)
+			continue
+		}
+
+		if string(formatted) != test.expected {
+			t.Errorf("wrong format of %q.\ngot:\n%s\nwant:\n%s", test.input, formatted, test.expected)
+			continue
+		}
+
+		if parse(t, test.input).String() != parse(t, string(formatted)).String() {
+			t.Errorf("formatting changed meaning of %q. got %q", test.input, formatted)
+		}
+	}
+}
+
+func TestSourceComments(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"// only comment", "// only comment\n"},
+		{
+			"// header\n\nlet x = 1; // one\n// before y\nlet y = 2;\n// end",
+			"// header\n\nlet x = 1; // one\n// before y\nlet y = 2;\n// end\n",
+		},
+		{
+			"let f = fn() { // opening\n  // first\n  1\n\n  // last\n}",
+			"let f = fn() { // opening\n    // first\n    1;\n\n    // last\n};\n",
+		},
+		{
+			"let a = [1, // one\n2]",
+			"let a = [\n    1, // one\n    2\n];\n",
+		},
+		{
+			"struct P {\n  // the x\n  x, // x coordinate\n  y\n}",
+			"struct P {\n    // the x\n    x, // x coordinate\n    y,\n}\n",
+		},
+		{
+			"match (x) {\n  // small\n  1 => 2, // two\n  _ => 3\n}",
+			"match (x) {\n    // small\n    1 => 2, // two\n    _ => 3,\n}\n",
+		},
+		{"fn() {\n  // empty\n}", "fn() {\n    // empty\n};\n"},
+	}
+
+	for _, test := range tests {
+		formatted, err := Source([]byte(test.input))
+		if err != nil {
+			t.Errorf("Source(%q) returned error: %v", test.input, err)
+			continue
+		}
+
+		if string(formatted) != test.expected {
+			t.Errorf("wrong format of %q.\ngot:\n%s\nwant:\n%s", test.input, formatted, test.expected)
+		}
+	}
+}
+
+func TestSourceIdempotent(t *testing.T) {
+	input := `// Script header
+
+
+let numbers = [1,2,3]; // numbers
+let double = fn(x) { x * 2 } // doubles
+let result = numbers |> map(double) |> filter(fn(x) { x > 2 })
+
+struct Account { owner, balance = 0, // money
+  fn deposit(self, amount) {
+    // mutate in place
+    self.balance = self.balance + amount
+  }
+}
+
+let describe = fn(value) {
+  match (value) { int(n) if n > 100 => "big", [first, ...rest] => first, _ => "other" }
+}
+let config = {"name": "cgo", "paths": ["/usr/local/lib/cgo", "/usr/lib/cgo", "~/.cgo/modules"], "debug": false}
+// trailing comment
+`
+
+	first, err := Source([]byte(input))
+	if err != nil {
+		t.Fatalf("Source returned error: %v", err)
+	}
+
+	second, err := Source(first)
+	if err != nil {
+		t.Fatalf("Source of formatted code returned error: %v", err)
+	}
+
+	if string(first) != string(second) {
+		t.Errorf("formatting isn't idempotent.\nfirst:\n%s\nsecond:\n%s", first, second)
+	}
+
+	for _, comment := range []string{"// Script header", "// numbers", "// doubles", "// money",
+		"// mutate in place", "// trailing comment"} {
+		if !strings.Contains(string(first), comment) {
+			t.Errorf("comment %q lost. got:\n%s", comment, first)
+		}
+	}
+}
+
+func TestSourceErrors(t *testing.T) {
+	_, err := Source([]byte("let = 5"))
+	if err == nil {
+		t.Fatalf("expected error for invalid source")
+	}
+
+	if !strings.Contains(err.Error(), "Expected IDENT token") {
+		t.Errorf("wrong error message. got=%q", err.Error())
+	}
+}
+
+func TestNode(t *testing.T) {
+	program := parse(t, "let f = fn(x) { x + 1 }; f(2)")
+
+	tests := []struct {
+		node     ast.Node
+		expected string
+	}{
+		{program, "let f = fn(x) {\n    x + 1;\n};\nf(2);\n"},
+		{program.Statements[1], "f(2);"},
+		{program.Statements[1].(*ast.ExpressionStatement).Expression, "f(2)"},
+	}
+
+	for _, test := range tests {
+		if formatted := Node(test.node); formatted != test.expected {
+			t.Errorf("wrong format. got=%q, want=%q", formatted, test.expected)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package lint
+
+import (
+	"github.com/aeremic/cgo/ast"
+	"github.com/aeremic/cgo/format"
+)
+
+// checkUnreachable Reports the first statement following return or throw
+// in every statement list
+func checkUnreachable(l *linter, program *ast.ProgramRoot) {
+	check := func(statements []ast.Statement) {
+		for i, statement := range statements[:max(len(statements)-1, 0)] {
+			switch statement.(type) {
+			case *ast.ReturnStatement, *ast.ThrowStatement:
+				l.report(UNREACHABLE, statements[i+1], "unreachable code after %s", statement.TokenLiteral())
+				return
+			}
+		}
+	}
+
+	check(program.Statements)
+	ast.Inspect(program, func(node ast.Node) bool {
+		if block, ok := node.(*ast.BlockStatement); ok {
+			check(block.Statements)
+		}
+
+		return true
+	})
+}
+
+// checkNotCallable Reports calls and pipes into literals which can't be called
+func checkNotCallable(l *linter, program *ast.ProgramRoot) {
+	ast.Inspect(program, func(node ast.Node) bool {
+		switch node := node.(type) {
+		case *ast.CallExpression:
+			checkCallee(l, node.Function)
+		case *ast.PipeExpression:
+			// Calls on the right side are checked as call expressions
+			checkCallee(l, node.Right)
+		}
+
+		return true
+	})
+}
+
+func checkCallee(l *linter, callee ast.Expression) {
+	var kind string
+
+	switch callee.(type) {
+	case *ast.IntegerLiteral:
+		kind = "integer"
+	case *ast.StringLiteral:
+		kind = "string"
+	case *ast.Boolean:
+		kind = "boolean"
+	case *ast.NullLiteral:
+		kind = "null"
+	case *ast.ArrayLiteral:
+		kind = "array"
+	case *ast.DictLiteral:
+		kind = "dict"
+	default:
+		return
+	}
+
+	l.report(NOT_CALLABLE, callee, "%s literal %s is not callable", kind, format.Node(callee))
+}
@@ -0,0 +1,144 @@
+package lint
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/aeremic/cgo/ast"
+	"github.com/aeremic/cgo/parser"
+	"github.com/aeremic/cgo/tokenizer"
+)
+
+type Severity string
+
+const (
+	ERROR   Severity = "error"
+	WARNING Severity = "warning"
+)
+
+// Rule IDs
+const (
+	UNDEFINED        = "undefined"
+	SHADOWED_BUILTIN = "shadowed-builtin"
+	UNUSED           = "unused"
+	UNUSED_PARAMETER = "unused-parameter"
+	UNREACHABLE      = "unreachable"
+	NOT_CALLABLE     = "not-callable"
+)
+
+type Rule struct {
+	ID          string
+	Severity    Severity
+	Description string
+	Enabled     bool // Whether the rule runs unless configured otherwise
+}
+
+var Rules = []Rule{
+	{UNDEFINED, ERROR, "identifier is not defined in any enclosing scope nor a builtin", true},
+	{SHADOWED_BUILTIN, WARNING, "binding hides builtin function of the same name", true},
+	{UNUSED, WARNING, "let binding or import is never used", true},
+	{UNUSED_PARAMETER, WARNING, "function parameter is never used", false},
+	{UNREACHABLE, WARNING, "statement follows return or throw in the same block", true},
+	{NOT_CALLABLE, ERROR, "literal which isn't a function is called", true},
+}
+
+// Config Rules switched on or off by ID, rules missing from the map run
+// when they are enabled by default
+type Config map[string]bool
+
+func (c Config) enabled(rule Rule) bool {
+	if enabled, ok := c[rule.ID]; ok {
+		return enabled
+	}
+
+	return rule.Enabled
+}
+
+type Diagnostic struct {
+	Rule     string   `json:"rule"`
+	Severity Severity `json:"severity"`
+	Message  string   `json:"message"`
+	Line     int      `json:"line"`
+	Column   int      `json:"column"`
+}
+
+func (d Diagnostic) String() string {
+	return fmt.Sprintf("%d:%d: %s: %s (%s)", d.Line, d.Column, d.Severity, d.Message, d.Rule)
+}
+
+// FindRule Returns rule with the given ID
+func FindRule(id string) (Rule, bool) {
+	for _, rule := range Rules {
+		if rule.ID == id {
+			return rule, true
+		}
+	}
+
+	return Rule{}, false
+}
+
+// Source Parses and lints source code. Syntax errors are returned as error.
+func Source(source []byte, config Config) ([]Diagnostic, error) {
+	p := parser.New(tokenizer.New(string(source)))
+
+	program := p.ParseProgram()
+	if len(p.Errors()) > 0 {
+		return nil, fmt.Errorf("%s", strings.Join(p.Errors(), "\n"))
+	}
+
+	return Program(program, config), nil
+}
+
+// Program Returns diagnostics of enabled rules ordered by position
+func Program(program *ast.ProgramRoot, config Config) []Diagnostic {
+	l := &linter{config: config, diagnostics: []Diagnostic{}}
+
+	l.resolve(program)
+	checkUnreachable(l, program)
+	checkNotCallable(l, program)
+
+	sort.SliceStable(l.diagnostics, func(i, j int) bool {
+		a, b := l.diagnostics[i], l.diagnostics[j]
+		return a.Line < b.Line || a.Line == b.Line && a.Column < b.Column
+	})
+
+	return l.diagnostics
+}
+
+// Definitions Maps identifiers of the program to identifiers declaring names
+// they refer to, declaring identifiers map to themselves. Builtins and
+// undefined names are left out. Imports without alias are declared by
+// identifiers holding the import token.
+func Definitions(program *ast.ProgramRoot) map[*ast.Identifier]*ast.Identifier {
+	config := Config{}
+	for _, rule := range Rules {
+		config[rule.ID] = false
+	}
+
+	l := &linter{config: config}
+
+	return l.resolve(program)
+}
+
+type linter struct {
+	config      Config
+	diagnostics []Diagnostic
+}
+
+// report Adds diagnostic at node position when the rule is enabled
+func (l *linter) report(id string, node ast.Node, format string, args ...interface{}) {
+	rule, _ := FindRule(id)
+	if !l.config.enabled(rule) {
+		return
+	}
+
+	first, _ := ast.Span(node)
+	l.diagnostics = append(l.diagnostics, Diagnostic{
+		Rule:     rule.ID,
+		Severity: rule.Severity,
+		Message:  fmt.Sprintf(format, args...),
+		Line:     first.Line,
+		Column:   first.Column,
+	})
+}
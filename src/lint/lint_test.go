@@ -0,0 +1,180 @@
+package lint
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/aeremic/cgo/ast"
+	"github.com/aeremic/cgo/parser"
+	"github.com/aeremic/cgo/tokenizer"
+)
+
+func TestSource(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{"let x = 1; puts(x)", []string{}},
+		{"puts(y)", []string{"1:6: error: undefined: y (undefined)"}},
+		{"let x = x + 1; x", []string{"1:9: error: undefined: x (undefined)"}},
+		{"let len = 1; len", []string{"1:5: warning: len shadows builtin function (shadowed-builtin)"}},
+		{"let f = fn(puts) { puts }; f(1)", []string{"1:12: warning: puts shadows builtin function (shadowed-builtin)"}},
+		{"let x = 1", []string{"1:5: warning: x declared and not used (unused)"}},
+		{"let [a, b] = [1, 2]; a", []string{"1:9: warning: b declared and not used (unused)"}},
+		{"let _x = 1; export let y = 2", []string{}},
+		{"import \"lib/math\"", []string{"1:1: warning: math imported and not used (unused)"}},
+		{"import \"lib/math\" as m; m.sqrt(4)", []string{}},
+		{"from \"lib\" import a, b; a", []string{"1:22: warning: b imported and not used (unused)"}},
+		{
+			"let f = fn() { return 1; puts(2); puts(3) }; f()",
+			[]string{"1:26: warning: unreachable code after return (unreachable)"},
+		},
+		{"throw \"e\"; 1", []string{"1:12: warning: unreachable code after throw (unreachable)"}},
+		{"5(1)", []string{"1:1: error: integer literal 5 is not callable (not-callable)"}},
+		{"[1](2)", []string{"1:1: error: array literal [1] is not callable (not-callable)"}},
+		{"1 |> \"f\"(2)", []string{"1:6: error: string literal \"f\" is not callable (not-callable)"}},
+		{"1 |> null", []string{"1:6: error: null literal null is not callable (not-callable)"}},
+		// Function bodies can refer to names bound after them
+		{"let f = fn() { g() }; let g = fn() { 1 }; f()", []string{}},
+		// If blocks share the scope they are in
+		{"if (true) { let x = 1 }; x", []string{}},
+		{"try { 1 } catch (e) { e }; e", []string{"1:28: error: undefined: e (undefined)"}},
+		{"try { let x = 1; x } finally { let y = 2; y }; [x, y]", []string{
+			"1:49: error: undefined: x (undefined)",
+			"1:52: error: undefined: y (undefined)",
+		}},
+		{"match (1) { [a, ...r] => r, {name} => name, int(n) if n > 1 => n, _ => a }", []string{
+			"1:72: error: undefined: a (undefined)",
+		}},
+		{"let f = fn(a, b = a, ...r) { [b, r] }; f(1)", []string{}},
+		{"struct P { x } match (1) { P(p) => p, Q(q) => q, null(n) => n }", []string{
+			"1:39: error: undefined: Q (undefined)",
+		}},
+		{"let p = {}; p.missing; puts(key: 1)", []string{}},
+		{"struct P { x, y = x, fn len(self) { self.x + z } }; P(1)", []string{
+			"1:46: error: undefined: z (undefined)",
+		}},
+		{"let m = macro(a) { quote(unquote(a) + not_evaluated) }; m(1)", []string{}},
+		{"quote(unquote(missing))", []string{"1:15: error: undefined: missing (undefined)"}},
+	}
+
+	for _, test := range tests {
+		diagnostics, err := Source([]byte(test.input), nil)
+		if err != nil {
+			t.Errorf("Source(%q) returned error: %v", test.input, err)
+			continue
+		}
+
+		got := []string{}
+		for _, diagnostic := range diagnostics {
+			got = append(got, diagnostic.String())
+		}
+
+		if strings.Join(got, "\n") != strings.Join(test.expected, "\n") {
+			t.Errorf("wrong diagnostics for %q.\ngot:\n%s\nwant:\n%s", test.input,
+				strings.Join(got, "\n"), strings.Join(test.expected, "\n"))
+		}
+	}
+}
+
+func TestConfig(t *testing.T) {
+	input := "let f = fn(a, b) { a + c }"
+
+	tests := []struct {
+		config   Config
+		expected []string
+	}{
+		{nil, []string{UNUSED, UNDEFINED}},
+		{Config{UNUSED_PARAMETER: true}, []string{UNUSED, UNUSED_PARAMETER, UNDEFINED}},
+		{Config{UNUSED: false, UNDEFINED: false}, []string{}},
+	}
+
+	for _, test := range tests {
+		diagnostics, err := Source([]byte(input), test.config)
+		if err != nil {
+			t.Fatalf("Source returned error: %v", err)
+		}
+
+		rules := []string{}
+		for _, diagnostic := range diagnostics {
+			rules = append(rules, diagnostic.Rule)
+		}
+
+		if strings.Join(rules, ",") != strings.Join(test.expected, ",") {
+			t.Errorf("wrong rules reported with %v. got=%v, want=%v", test.config, rules, test.expected)
+		}
+	}
+}
+
+func TestDiagnosticJSON(t *testing.T) {
+	diagnostics, err := Source([]byte("\n  missing"), nil)
+	if err != nil {
+		t.Fatalf("Source returned error: %v", err)
+	}
+
+	encoded, err := json.Marshal(diagnostics)
+	if err != nil {
+		t.Fatalf("json.Marshal returned error: %v", err)
+	}
+
+	expected := `[{"rule":"undefined","severity":"error","message":"undefined: missing","line":2,"column":3}]`
+	if string(encoded) != expected {
+		t.Errorf("wrong JSON. got=%s, want=%s", encoded, expected)
+	}
+}
+
+func TestRules(t *testing.T) {
+	for _, rule := range Rules {
+		found, ok := FindRule(rule.ID)
+		if !ok || found.ID != rule.ID {
+			t.Errorf("rule %q not found", rule.ID)
+		}
+
+		if rule.Severity != ERROR && rule.Severity != WARNING {
+			t.Errorf("rule %q has wrong severity %q", rule.ID, rule.Severity)
+		}
+	}
+
+	if _, err := Source([]byte("let = 1"), nil); err == nil {
+		t.Errorf("expected error for invalid source")
+	}
+}
+
+func TestDefinitions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string // Identifier position -> declaration position, sorted as strings
+	}{
+		{"let x = 1; x + x", []string{"1:12->1:5", "1:16->1:5", "1:5->1:5"}},
+		{"let f = fn(a) { a + b }; let b = 1", []string{
+			"1:12->1:12", "1:17->1:12", "1:21->1:30", "1:30->1:30", "1:5->1:5",
+		}},
+		{"let x = 1; let g = fn(x) { x }", []string{"1:16->1:16", "1:23->1:23", "1:28->1:23", "1:5->1:5"}},
+		{"import \"lib\"; lib.f(len(y))", []string{"1:1->1:1", "1:15->1:1"}},
+	}
+
+	for _, test := range tests {
+		p := parser.New(tokenizer.New(test.input))
+		program := p.ParseProgram()
+		if len(p.Errors()) > 0 {
+			t.Fatalf("parser errors for %q: %v", test.input, p.Errors())
+		}
+
+		got := []string{}
+		for identifier, declaration := range Definitions(program) {
+			got = append(got, fmt.Sprintf("%s->%s", position(identifier), position(declaration)))
+		}
+
+		sort.Strings(got)
+		if strings.Join(got, " ") != strings.Join(test.expected, " ") {
+			t.Errorf("wrong definitions for %q. got=%v, want=%v", test.input, got, test.expected)
+		}
+	}
+}
+
+func position(identifier *ast.Identifier) string {
+	return fmt.Sprintf("%d:%d", identifier.Token.Line, identifier.Token.Column)
+}
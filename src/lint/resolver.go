@@ -0,0 +1,334 @@
+package lint
+
+import (
+	"strings"
+
+	"github.com/aeremic/cgo/ast"
+	"github.com/aeremic/cgo/evaluator"
+	"github.com/aeremic/cgo/token"
+	"github.com/aeremic/cgo/value"
+)
+
+// Kinds of bindings, only lets, imports and parameters are checked for use
+const (
+	letBinding = iota
+	importBinding
+	parameterBinding
+	otherBinding
+)
+
+type binding struct {
+	name     *ast.Identifier
+	kind     int
+	exported bool
+	used     bool
+}
+
+// scope Names bound by a program, function call, catch block or match arm.
+// Blocks of if and try share the scope they are found in, same as when
+// they are evaluated.
+type scope struct {
+	outer *scope
+	names map[string]*binding
+}
+
+func (s *scope) lookup(name string) (*binding, bool) {
+	for current := s; current != nil; current = current.outer {
+		if b, ok := current.names[name]; ok {
+			return b, true
+		}
+	}
+
+	return nil, false
+}
+
+// resolver Matches identifiers to bindings. Function bodies run after the
+// enclosing code defined them, so they are resolved once the whole program
+// has been seen and can refer to names bound later.
+type resolver struct {
+	linter      *linter
+	scope       *scope
+	builtins    map[string]bool
+	bindings    []*binding
+	definitions map[*ast.Identifier]*ast.Identifier
+	deferred    []func()
+}
+
+// resolve Reports scope diagnostics and returns identifiers of the program
+// mapped to identifiers declaring names they refer to
+func (l *linter) resolve(program *ast.ProgramRoot) map[*ast.Identifier]*ast.Identifier {
+	r := &resolver{
+		linter:      l,
+		scope:       &scope{names: map[string]*binding{}},
+		builtins:    map[string]bool{},
+		definitions: map[*ast.Identifier]*ast.Identifier{},
+	}
+
+	for _, name := range evaluator.BuiltinNames() {
+		r.builtins[name] = true
+	}
+
+	ast.Walk(program, r)
+
+	for len(r.deferred) > 0 {
+		next := r.deferred[0]
+		r.deferred = r.deferred[1:]
+		next()
+	}
+
+	for _, b := range r.bindings {
+		if b.used || b.exported || strings.HasPrefix(b.name.Value, "_") {
+			continue
+		}
+
+		switch b.kind {
+		case letBinding:
+			l.report(UNUSED, b.name, "%s declared and not used", b.name.Value)
+		case importBinding:
+			l.report(UNUSED, b.name, "%s imported and not used", b.name.Value)
+		case parameterBinding:
+			l.report(UNUSED_PARAMETER, b.name, "parameter %s is not used", b.name.Value)
+		}
+	}
+
+	return r.definitions
+}
+
+// Visit Handles nodes which bind names or hold identifiers that aren't
+// references, children of other nodes are walked as they are
+func (r *resolver) Visit(node ast.Node) ast.Visitor {
+	switch node := node.(type) {
+	case *ast.Identifier:
+		r.reference(node)
+	case *ast.LetStatement:
+		r.let(node, false)
+	case *ast.ExportStatement:
+		r.let(node.Statement, true)
+	case *ast.ImportStatement:
+		r.importStatement(node)
+	case *ast.StructStatement:
+		r.structStatement(node)
+	case *ast.FunctionLiteral:
+		r.function(node.Parameters, node.Rest, node.Body)
+	case *ast.MacroLiteral:
+		parameters := []ast.Pattern{}
+		for _, parameter := range node.Parameters {
+			parameters = append(parameters, parameter)
+		}
+
+		r.function(parameters, nil, node.Body)
+	case *ast.TryExpression:
+		r.enclosed(func() { r.walk(node.Block) })
+
+		if node.Catch != nil {
+			r.enclosed(func() {
+				r.declare(node.CatchParam, otherBinding)
+				r.walk(node.Catch)
+			})
+		}
+
+		if node.Finally != nil {
+			r.enclosed(func() { r.walk(node.Finally) })
+		}
+	case *ast.MatchExpression:
+		r.walk(node.Subject)
+		for _, arm := range node.Arms {
+			r.enclosed(func() {
+				r.pattern(arm.Pattern, otherBinding)
+				r.walk(arm.Guard)
+				r.walk(arm.Body)
+			})
+		}
+	case *ast.MemberExpression:
+		r.walk(node.Object)
+	case *ast.KeywordArgument:
+		r.walk(node.Value)
+	case *ast.CallExpression:
+		if !isCallOf(node, "quote") {
+			return r
+		}
+
+		// Quoted code is data, only unquoted parts are evaluated
+		for _, argument := range node.Arguments {
+			ast.Inspect(argument, func(n ast.Node) bool {
+				if call, ok := n.(*ast.CallExpression); ok && isCallOf(call, "unquote") {
+					for _, unquoted := range call.Arguments {
+						r.walk(unquoted)
+					}
+
+					return false
+				}
+
+				return true
+			})
+		}
+	default:
+		return r
+	}
+
+	return nil
+}
+
+// walk Resolves node if present
+func (r *resolver) walk(node ast.Node) {
+	if node != nil {
+		ast.Walk(node, r)
+	}
+}
+
+// enclosed Runs resolve in a new scope nested in the current one
+func (r *resolver) enclosed(resolve func()) {
+	r.within(r.scope, resolve)
+}
+
+// within Runs resolve in a new scope nested in outer
+func (r *resolver) within(outer *scope, resolve func()) {
+	current := r.scope
+	r.scope = &scope{outer: outer, names: map[string]*binding{}}
+	resolve()
+	r.scope = current
+}
+
+func (r *resolver) reference(identifier *ast.Identifier) {
+	if b, ok := r.scope.lookup(identifier.Value); ok {
+		b.used = true
+		r.definitions[identifier] = b.name
+		return
+	}
+
+	if r.builtins[identifier.Value] || identifier.Value == "quote" || identifier.Value == "unquote" {
+		return
+	}
+
+	r.linter.report(UNDEFINED, identifier, "undefined: %s", identifier.Value)
+}
+
+func (r *resolver) declare(identifier *ast.Identifier, kind int) *binding {
+	if r.builtins[identifier.Value] {
+		r.linter.report(SHADOWED_BUILTIN, identifier, "%s shadows builtin function", identifier.Value)
+	}
+
+	b := &binding{name: identifier, kind: kind}
+	r.scope.names[identifier.Value] = b
+	r.definitions[identifier] = identifier
+	r.bindings = append(r.bindings, b)
+
+	return b
+}
+
+// pattern Declares names bound by the pattern in order. Defaults are
+// resolved before their target, they can refer to names bound earlier.
+func (r *resolver) pattern(pattern ast.Pattern, kind int) {
+	switch pattern := pattern.(type) {
+	case *ast.Identifier:
+		r.declare(pattern, kind)
+	case *ast.DefaultPattern:
+		r.walk(pattern.Default)
+		r.pattern(pattern.Target, kind)
+	case *ast.LiteralPattern:
+		r.walk(pattern.Value)
+	case *ast.TypePattern:
+		// Patterns of struct types refer to the struct by name
+		if _, ok := value.PatternTypes(pattern.Name); !ok {
+			r.reference(&ast.Identifier{Token: pattern.Token, Value: pattern.Name})
+		}
+
+		if pattern.Binding != nil {
+			r.pattern(pattern.Binding, kind)
+		}
+	case *ast.ArrayPattern:
+		for _, element := range pattern.Elements {
+			r.pattern(element, kind)
+		}
+
+		if pattern.Rest != nil {
+			r.declare(pattern.Rest, kind)
+		}
+	case *ast.DictPattern:
+		for _, entry := range pattern.Entries {
+			r.pattern(entry.Value, kind)
+		}
+
+		if pattern.Rest != nil {
+			r.declare(pattern.Rest, kind)
+		}
+	}
+}
+
+func (r *resolver) let(statement *ast.LetStatement, exported bool) {
+	r.walk(statement.Value)
+
+	if statement.Pattern != nil {
+		start := len(r.bindings)
+		r.pattern(statement.Pattern, letBinding)
+
+		for _, b := range r.bindings[start:] {
+			b.exported = exported
+		}
+
+		return
+	}
+
+	r.declare(statement.Name, letBinding).exported = exported
+}
+
+func (r *resolver) importStatement(statement *ast.ImportStatement) {
+	if statement.Token.Type == token.FROM {
+		for _, name := range statement.Names {
+			r.declare(name, importBinding)
+		}
+
+		return
+	}
+
+	alias := statement.Alias
+	if alias == nil {
+		// Module file name has no identifier in source, reported at import
+		alias = &ast.Identifier{Token: statement.Token, Value: evaluator.ImportAlias(statement)}
+	}
+
+	r.declare(alias, importBinding)
+}
+
+// structStatement Resolves field defaults as parameters of the constructor
+// and methods as functions defined where the struct is
+func (r *resolver) structStatement(statement *ast.StructStatement) {
+	r.declare(statement.Name, otherBinding)
+
+	outer := r.scope
+	r.deferred = append(r.deferred, func() {
+		r.within(outer, func() {
+			for _, field := range statement.Fields {
+				r.pattern(field, otherBinding)
+			}
+		})
+	})
+
+	for _, method := range statement.Methods {
+		r.function(method.Function.Parameters, method.Function.Rest, method.Function.Body)
+	}
+}
+
+// function Defers resolving of function body until the enclosing code is
+// resolved, the scope it's defined in is kept for it
+func (r *resolver) function(parameters []ast.Pattern, rest *ast.Identifier, body *ast.BlockStatement) {
+	outer := r.scope
+	r.deferred = append(r.deferred, func() {
+		r.within(outer, func() {
+			for _, parameter := range parameters {
+				r.pattern(parameter, parameterBinding)
+			}
+
+			if rest != nil {
+				r.declare(rest, parameterBinding)
+			}
+
+			r.walk(body)
+		})
+	})
+}
+
+func isCallOf(call *ast.CallExpression, name string) bool {
+	identifier, ok := call.Function.(*ast.Identifier)
+	return ok && identifier.Value == name
+}
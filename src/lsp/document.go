@@ -0,0 +1,209 @@
+package lsp
+
+import (
+	"sort"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/aeremic/cgo/ast"
+	"github.com/aeremic/cgo/lint"
+	"github.com/aeremic/cgo/parser"
+	"github.com/aeremic/cgo/token"
+	"github.com/aeremic/cgo/tokenizer"
+	"github.com/aeremic/cgo/types"
+)
+
+// document Open source file with results of analysis. Program, definitions
+// and types are nil when the source has syntax errors.
+type document struct {
+	uri         string
+	text        string
+	lines       []string
+	diagnostics []Diagnostic
+	program     *ast.ProgramRoot
+	identifiers []*ast.Identifier // Identifiers written in source, in order
+	definitions map[*ast.Identifier]*ast.Identifier
+	types       map[ast.Node]types.Type
+}
+
+func newDocument(uri string, text string) *document {
+	d := &document{uri: uri, text: text, lines: strings.Split(text, "\n"), diagnostics: []Diagnostic{}}
+
+	p := parser.New(tokenizer.New(text))
+	program := p.ParseProgram()
+
+	if len(p.Errors()) > 0 {
+		for i, message := range p.Errors() {
+			position := p.ErrorPositions()[i]
+			d.diagnostics = append(d.diagnostics, d.diagnostic(position.Line, position.Column,
+				severityError, "", "cgo", message))
+		}
+
+		return d
+	}
+
+	d.program = program
+	d.definitions = lint.Definitions(program)
+	d.types = types.Infer(program)
+
+	ast.Inspect(program, func(node ast.Node) bool {
+		if identifier, ok := node.(*ast.Identifier); ok && identifier.Token.Type == token.IDENT {
+			d.identifiers = append(d.identifiers, identifier)
+		}
+
+		return true
+	})
+
+	sort.SliceStable(d.identifiers, func(i, j int) bool {
+		return d.identifiers[i].Token.Before(d.identifiers[j].Token)
+	})
+
+	for _, found := range lint.Program(program, nil) {
+		severity := severityWarning
+		if found.Severity == lint.ERROR {
+			severity = severityError
+		}
+
+		d.diagnostics = append(d.diagnostics, d.diagnostic(found.Line, found.Column, severity, found.Rule,
+			"cgo lint", found.Message))
+	}
+
+	for _, err := range types.Check(program) {
+		d.diagnostics = append(d.diagnostics, d.diagnostic(err.Line, err.Column, severityError, "",
+			"cgo types", err.Message))
+	}
+
+	return d
+}
+
+// diagnostic Returns diagnostic covering word starting at line and column
+// of source, or a single character when no word starts there
+func (d *document) diagnostic(line, column, severity int, code, source, message string) Diagnostic {
+	start := d.position(line, column)
+	end := start
+
+	if line >= 1 && line <= len(d.lines) && column >= 1 && column <= len(d.lines[line-1]) {
+		text := d.lines[line-1]
+		last := column
+		for last <= len(text) && isIdentifierChar(text[last-1]) {
+			last++
+		}
+
+		if last == column {
+			_, size := utf8.DecodeRuneInString(text[column-1:])
+			last += size
+		}
+
+		end = d.position(line, last)
+	}
+
+	return Diagnostic{
+		Range:    Range{Start: start, End: end},
+		Severity: severity,
+		Code:     code,
+		Source:   source,
+		Message:  message,
+	}
+}
+
+// position Converts one based line and byte column of source to LSP position
+func (d *document) position(line, column int) Position {
+	if line < 1 {
+		return Position{}
+	}
+
+	if line > len(d.lines) {
+		return d.end()
+	}
+
+	text := d.lines[line-1]
+	if column-1 > len(text) {
+		column = len(text) + 1
+	}
+
+	return Position{Line: line - 1, Character: utf16Length(text[:max(column-1, 0)])}
+}
+
+// column Converts LSP position to one based line and byte column of source
+func (d *document) column(position Position) (int, int) {
+	if position.Line < 0 || position.Line >= len(d.lines) {
+		return 0, 0
+	}
+
+	text := d.lines[position.Line]
+	units := 0
+	for offset, r := range text {
+		if units >= position.Character {
+			return position.Line + 1, offset + 1
+		}
+
+		units += len(utf16.Encode([]rune{r}))
+	}
+
+	return position.Line + 1, len(text) + 1
+}
+
+// end Returns position after the last character of the document
+func (d *document) end() Position {
+	last := len(d.lines) - 1
+	return Position{Line: last, Character: utf16Length(d.lines[last])}
+}
+
+// tokenRange Returns range of the token in source
+func (d *document) tokenRange(t token.Token) Range {
+	return Range{
+		Start: d.position(t.Line, t.Column),
+		End:   d.position(t.Line, t.Column+len(t.Literal)),
+	}
+}
+
+// nodeRange Returns range from the first to the last token of the node
+func (d *document) nodeRange(node ast.Node) Range {
+	first, last := ast.Span(node)
+	return Range{
+		Start: d.position(first.Line, first.Column),
+		End:   d.tokenRange(last).End,
+	}
+}
+
+// identifierAt Returns identifier covering position, including position right
+// after its last character
+func (d *document) identifierAt(position Position) *ast.Identifier {
+	line, column := d.column(position)
+
+	for _, identifier := range d.identifiers {
+		t := identifier.Token
+		if t.Line == line && column >= t.Column && column <= t.Column+len(t.Literal) {
+			return identifier
+		}
+	}
+
+	return nil
+}
+
+// references Returns identifiers referring to the name declared by declaration,
+// declaration itself included
+func (d *document) references(declaration *ast.Identifier) []*ast.Identifier {
+	found := []*ast.Identifier{}
+	for _, identifier := range d.identifiers {
+		if d.definitions[identifier] == declaration {
+			found = append(found, identifier)
+		}
+	}
+
+	return found
+}
+
+func utf16Length(s string) int {
+	length := 0
+	for _, r := range s {
+		length += len(utf16.Encode([]rune{r}))
+	}
+
+	return length
+}
+
+func isIdentifierChar(ch byte) bool {
+	return ch >= 'a' && ch <= 'z' || ch >= 'A' && ch <= 'Z' || ch == '_' || ch >= '0' && ch <= '9'
+}
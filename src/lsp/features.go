@@ -0,0 +1,318 @@
+package lsp
+
+import (
+	"fmt"
+
+	"github.com/aeremic/cgo/ast"
+	"github.com/aeremic/cgo/evaluator"
+	"github.com/aeremic/cgo/format"
+	"github.com/aeremic/cgo/token"
+	"github.com/aeremic/cgo/tokenizer"
+	"github.com/aeremic/cgo/types"
+)
+
+// hover Shows inferred type of the name under cursor and kind of values it holds
+func (d *document) hover(position Position) *Hover {
+	identifier := d.identifierAt(position)
+	if identifier == nil {
+		return nil
+	}
+
+	var contents string
+	if _, ok := d.definitions[identifier]; !ok && isBuiltin(identifier.Value) {
+		contents = fmt.Sprintf("```cgo\n%s\n```\nbuiltin function", identifier.Value)
+	} else {
+		var t types.Type = types.Any
+		if inferred, ok := d.types[identifier]; ok {
+			t = inferred
+		}
+
+		contents = fmt.Sprintf("```cgo\n%s: %s\n```", identifier.Value, t)
+		if t.Kind() != "" {
+			contents += fmt.Sprintf("\nvalue kind %s", t.Kind())
+		}
+	}
+
+	return &Hover{
+		Contents: MarkupContent{Kind: "markdown", Value: contents},
+		Range:    d.tokenRange(identifier.Token),
+	}
+}
+
+// definition Returns location of the identifier declaring name under cursor
+func (d *document) definition(position Position) *Location {
+	identifier := d.identifierAt(position)
+	if identifier == nil || d.definitions[identifier] == nil {
+		return nil
+	}
+
+	declaration := d.definitions[identifier]
+
+	return &Location{URI: d.uri, Range: d.tokenRange(declaration.Token)}
+}
+
+// referenceLocations Returns locations of every use of the name under cursor
+func (d *document) referenceLocations(position Position, includeDeclaration bool) []Location {
+	locations := []Location{}
+
+	identifier := d.identifierAt(position)
+	if identifier == nil || d.definitions[identifier] == nil {
+		return locations
+	}
+
+	declaration := d.definitions[identifier]
+	for _, reference := range d.references(declaration) {
+		if reference == declaration && !includeDeclaration {
+			continue
+		}
+
+		locations = append(locations, Location{URI: d.uri, Range: d.tokenRange(reference.Token)})
+	}
+
+	return locations
+}
+
+// rename Replaces declaration of the name under cursor and every reference to
+// it. Shorthand dict pattern entries keep their key, {name} becomes {name: new}.
+func (d *document) rename(position Position, newName string) (*WorkspaceEdit, error) {
+	if !isIdentifier(newName) {
+		return nil, fmt.Errorf("%q is not a valid identifier", newName)
+	}
+
+	identifier := d.identifierAt(position)
+	if identifier == nil {
+		return nil, fmt.Errorf("no name to rename at position")
+	}
+
+	declaration := d.definitions[identifier]
+	if declaration == nil {
+		return nil, fmt.Errorf("%s is a builtin or undefined name", identifier.Value)
+	}
+
+	if declaration.Token.Type != token.IDENT {
+		return nil, fmt.Errorf("%s is named after imported module, add alias to rename it", identifier.Value)
+	}
+
+	if d.isStructField(declaration) {
+		return nil, fmt.Errorf("%s is a struct field, member accesses can't be renamed", identifier.Value)
+	}
+
+	shorthands := d.shorthandKeys()
+
+	edits := []TextEdit{}
+	for _, reference := range d.references(declaration) {
+		newText := newName
+		if shorthands[reference.Token] {
+			newText = reference.Value + ": " + newName
+		}
+
+		edits = append(edits, TextEdit{Range: d.tokenRange(reference.Token), NewText: newText})
+	}
+
+	return &WorkspaceEdit{Changes: map[string][]TextEdit{d.uri: edits}}, nil
+}
+
+// shorthandKeys Returns tokens of dict pattern entries binding name of the key
+func (d *document) shorthandKeys() map[token.Token]bool {
+	keys := map[token.Token]bool{}
+	ast.Inspect(d.program, func(node ast.Node) bool {
+		if pattern, ok := node.(*ast.DictPattern); ok {
+			for _, entry := range pattern.Entries {
+				first, _ := ast.Span(entry.Value)
+				if first == entry.Key.Token {
+					keys[first] = true
+				}
+			}
+		}
+
+		return true
+	})
+
+	return keys
+}
+
+func (d *document) isStructField(declaration *ast.Identifier) bool {
+	field := false
+	ast.Inspect(d.program, func(node ast.Node) bool {
+		if statement, ok := node.(*ast.StructStatement); ok {
+			for _, pattern := range statement.Fields {
+				first, _ := ast.Span(pattern)
+				field = field || first == declaration.Token
+			}
+		}
+
+		return !field
+	})
+
+	return field
+}
+
+// symbols Returns top level declarations of the document, struct fields and
+// methods are children of their struct
+func (d *document) symbols() []DocumentSymbol {
+	symbols := []DocumentSymbol{}
+	for _, statement := range d.program.Statements {
+		if export, ok := statement.(*ast.ExportStatement); ok {
+			statement = export.Statement
+		}
+
+		switch statement := statement.(type) {
+		case *ast.LetStatement:
+			if statement.Pattern != nil {
+				for _, name := range patternNames(statement.Pattern) {
+					symbols = append(symbols, d.symbol(name, symbolVariable, "", statement))
+				}
+
+				continue
+			}
+
+			kind := symbolVariable
+			if _, ok := statement.Value.(*ast.FunctionLiteral); ok {
+				kind = symbolFunction
+			}
+
+			symbols = append(symbols, d.symbol(statement.Name, kind, d.typeOf(statement.Name), statement))
+		case *ast.ImportStatement:
+			names := statement.Names
+			if statement.Alias != nil {
+				names = []*ast.Identifier{statement.Alias}
+			}
+
+			for _, name := range names {
+				symbols = append(symbols, d.symbol(name, symbolModule, statement.Path.Value, statement))
+			}
+		case *ast.StructStatement:
+			symbol := d.symbol(statement.Name, symbolStruct, "", statement)
+			for _, field := range statement.Fields {
+				for _, name := range patternNames(field) {
+					symbol.Children = append(symbol.Children, d.symbol(name, symbolField, "", field))
+				}
+			}
+
+			for _, method := range statement.Methods {
+				symbol.Children = append(symbol.Children, d.symbol(method.Name, symbolMethod, "",
+					method.Function))
+			}
+
+			symbols = append(symbols, symbol)
+		}
+	}
+
+	return symbols
+}
+
+func (d *document) symbol(name *ast.Identifier, kind int, detail string, node ast.Node) DocumentSymbol {
+	return DocumentSymbol{
+		Name:           name.Value,
+		Detail:         detail,
+		Kind:           kind,
+		Range:          d.nodeRange(node),
+		SelectionRange: d.tokenRange(name.Token),
+	}
+}
+
+// typeOf Returns inferred type of node, empty when nothing is known about it
+func (d *document) typeOf(node ast.Node) string {
+	if t, ok := d.types[node]; ok && t != types.Any {
+		return t.String()
+	}
+
+	return ""
+}
+
+// completion Offers keywords, builtins and names declared in the document.
+// Names are offered when the source has no syntax errors only.
+func (d *document) completion() []CompletionItem {
+	items := []CompletionItem{}
+
+	seen := map[string]bool{}
+	for _, identifier := range d.identifiers {
+		if d.definitions[identifier] != identifier || seen[identifier.Value] {
+			continue
+		}
+
+		seen[identifier.Value] = true
+		items = append(items, CompletionItem{
+			Label:  identifier.Value,
+			Kind:   completionVariable,
+			Detail: d.typeOf(identifier),
+		})
+	}
+
+	for _, name := range evaluator.BuiltinNames() {
+		if !seen[name] {
+			items = append(items, CompletionItem{Label: name, Kind: completionFunction, Detail: "builtin function"})
+		}
+	}
+
+	for _, keyword := range token.Keywords() {
+		items = append(items, CompletionItem{Label: keyword, Kind: completionKeyword})
+	}
+
+	return items
+}
+
+// formatting Returns edit replacing whole document with its canonical form
+func (d *document) formatting() ([]TextEdit, error) {
+	formatted, err := format.Source([]byte(d.text))
+	if err != nil {
+		return nil, err
+	}
+
+	if string(formatted) == d.text {
+		return []TextEdit{}, nil
+	}
+
+	return []TextEdit{{Range: Range{End: d.end()}, NewText: string(formatted)}}, nil
+}
+
+// patternNames Returns identifiers bound by the pattern
+func patternNames(pattern ast.Pattern) []*ast.Identifier {
+	names := []*ast.Identifier{}
+	switch pattern := pattern.(type) {
+	case *ast.Identifier:
+		names = append(names, pattern)
+	case *ast.DefaultPattern:
+		names = append(names, patternNames(pattern.Target)...)
+	case *ast.TypePattern:
+		if pattern.Binding != nil {
+			names = append(names, patternNames(pattern.Binding)...)
+		}
+	case *ast.ArrayPattern:
+		for _, element := range pattern.Elements {
+			names = append(names, patternNames(element)...)
+		}
+
+		if pattern.Rest != nil {
+			names = append(names, pattern.Rest)
+		}
+	case *ast.DictPattern:
+		for _, entry := range pattern.Entries {
+			names = append(names, patternNames(entry.Value)...)
+		}
+
+		if pattern.Rest != nil {
+			names = append(names, pattern.Rest)
+		}
+	}
+
+	return names
+}
+
+func isBuiltin(name string) bool {
+	for _, builtin := range evaluator.BuiltinNames() {
+		if builtin == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isIdentifier Reports whether name is tokenized as single identifier
+func isIdentifier(name string) bool {
+	t := tokenizer.New(name)
+	first := t.NextToken()
+
+	return first.Type == token.IDENT && first.Literal == name && t.NextToken().Type == token.EOF
+}
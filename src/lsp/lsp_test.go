@@ -0,0 +1,381 @@
+package lsp
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+const uri = "file:///test.cgo"
+
+// frame Returns messages framed as sent by an editor
+func frame(messages ...string) io.Reader {
+	var in bytes.Buffer
+	for _, msg := range messages {
+		fmt.Fprintf(&in, "Content-Length: %d\r\n\r\n%s", len(msg), msg)
+	}
+
+	return &in
+}
+
+// unframe Returns messages written by server decoded as generic JSON
+func unframe(t *testing.T, out *bytes.Buffer) []map[string]interface{} {
+	messages := []map[string]interface{}{}
+
+	reader := bufio.NewReader(out)
+	for {
+		header, err := textproto.NewReader(reader).ReadMIMEHeader()
+		if err == io.EOF {
+			return messages
+		}
+
+		if err != nil {
+			t.Fatalf("invalid header: %v", err)
+		}
+
+		length, _ := strconv.Atoi(header.Get("Content-Length"))
+		content := make([]byte, length)
+		if _, err := io.ReadFull(reader, content); err != nil {
+			t.Fatalf("short message: %v", err)
+		}
+
+		var msg map[string]interface{}
+		if err := json.Unmarshal(content, &msg); err != nil {
+			t.Fatalf("invalid message %s: %v", content, err)
+		}
+
+		messages = append(messages, msg)
+	}
+}
+
+func didOpen(text string) string {
+	params, _ := json.Marshal(map[string]interface{}{
+		"textDocument": map[string]interface{}{"uri": uri, "languageId": "cgo", "version": 1, "text": text},
+	})
+
+	return `{"jsonrpc":"2.0","method":"textDocument/didOpen","params":` + string(params) + `}`
+}
+
+func TestServe(t *testing.T) {
+	var out bytes.Buffer
+	in := frame(
+		`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"capabilities":{}}}`,
+		`{"jsonrpc":"2.0","method":"initialized","params":{}}`,
+		didOpen("let x = 1;\nlet = 2"),
+		`{"jsonrpc":"2.0","method":"textDocument/didChange","params":{"textDocument":{"uri":"`+uri+`","version":2},`+
+			`"contentChanges":[{"text":"let x = 1;\nx"}]}}`,
+		`{"jsonrpc":"2.0","id":2,"method":"textDocument/hover","params":{"textDocument":{"uri":"`+uri+`"},`+
+			`"position":{"line":1,"character":0}}}`,
+		`{"jsonrpc":"2.0","id":3,"method":"textDocument/unknown","params":{}}`,
+		`{"jsonrpc":"2.0","id":4,"method":"shutdown"}`,
+		`{"jsonrpc":"2.0","method":"exit"}`,
+	)
+
+	if err := NewServer(in, &out).Serve(); err != nil {
+		t.Fatalf("Serve returned error: %v", err)
+	}
+
+	messages := unframe(t, &out)
+	if len(messages) != 6 {
+		t.Fatalf("wrong number of messages. got=%d, want=6", len(messages))
+	}
+
+	capabilities := messages[0]["result"].(map[string]interface{})["capabilities"].(map[string]interface{})
+	if capabilities["renameProvider"] != true || capabilities["textDocumentSync"] != 1.0 {
+		t.Errorf("wrong capabilities: %v", capabilities)
+	}
+
+	diagnostics := messages[1]["params"].(map[string]interface{})["diagnostics"].([]interface{})
+	if len(diagnostics) == 0 || messages[1]["method"] != "textDocument/publishDiagnostics" {
+		t.Fatalf("expected syntax error diagnostics. got=%v", messages[1])
+	}
+
+	encoded, _ := json.Marshal(diagnostics[0].(map[string]interface{})["range"])
+	if string(encoded) != `{"end":{"character":5,"line":1},"start":{"character":4,"line":1}}` {
+		t.Errorf("wrong range of syntax error: %s", encoded)
+	}
+
+	diagnostics = messages[2]["params"].(map[string]interface{})["diagnostics"].([]interface{})
+	if len(diagnostics) != 0 {
+		t.Errorf("expected no diagnostics after change. got=%v", diagnostics)
+	}
+
+	hover := messages[3]["result"].(map[string]interface{})["contents"].(map[string]interface{})
+	if hover["value"] != "```cgo\nx: int\n```\nvalue kind INTEGER" {
+		t.Errorf("wrong hover: %q", hover["value"])
+	}
+
+	failure := messages[4]["error"].(map[string]interface{})
+	if failure["code"] != float64(methodNotFound) || messages[4]["id"] != 3.0 {
+		t.Errorf("wrong error response: %v", messages[4])
+	}
+
+	if _, ok := messages[5]["result"]; !ok || messages[5]["result"] != nil {
+		t.Errorf("wrong shutdown response: %v", messages[5])
+	}
+}
+
+func TestServeExitWithoutShutdown(t *testing.T) {
+	var out bytes.Buffer
+	in := frame(`{"jsonrpc":"2.0","method":"exit"}`)
+
+	if err := NewServer(in, &out).Serve(); err == nil {
+		t.Errorf("expected error on exit without shutdown")
+	}
+}
+
+func TestDiagnostics(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{"let x = 1; x", []string{}},
+		{"let x = 1", []string{"0:4-0:5 warning unused: x declared and not used"}},
+		{"puts(\"é\", y)", []string{"0:10-0:11 error undefined: undefined: y"}},
+		{"let s = 1 + \"a\"; s", []string{"0:10-0:11 error : type mismatch: INTEGER + STRING"}},
+		{"let f = fn(a) {\n  a +\n}", []string{"2:0-2:1 error : No prefix parse function found for type }"}},
+	}
+
+	for _, test := range tests {
+		got := []string{}
+		for _, d := range newDocument(uri, test.input).diagnostics {
+			severity := "error"
+			if d.Severity == severityWarning {
+				severity = "warning"
+			}
+
+			got = append(got, fmt.Sprintf("%s %s %s: %s", formatRange(d.Range), severity, d.Code, d.Message))
+		}
+
+		if strings.Join(got, "\n") != strings.Join(test.expected, "\n") {
+			t.Errorf("wrong diagnostics for %q.\ngot:\n%s\nwant:\n%s", test.input,
+				strings.Join(got, "\n"), strings.Join(test.expected, "\n"))
+		}
+	}
+}
+
+func formatRange(r Range) string {
+	return fmt.Sprintf("%d:%d-%d:%d", r.Start.Line, r.Start.Character, r.End.Line, r.End.Character)
+}
+
+func TestHover(t *testing.T) {
+	source := "let xs = [1];\nlet f = fn(a: string, b) { len(b) };\n\"é\" + f(\"a\", xs)"
+
+	tests := []struct {
+		position Position
+		expected string
+	}{
+		{Position{0, 5}, "```cgo\nxs: [int]\n```\nvalue kind ARRAY"},
+		{Position{1, 11}, "```cgo\na: string\n```\nvalue kind STRING"},
+		{Position{1, 22}, "```cgo\nb: any\n```"},
+		{Position{1, 28}, "```cgo\nlen\n```\nbuiltin function"},
+		{Position{2, 6}, "```cgo\nf: fn(string, any) -> any\n```\nvalue kind FUNCTION"},
+		{Position{2, 14}, "```cgo\nxs: [int]\n```\nvalue kind ARRAY"},
+		{Position{1, 3}, ""},
+	}
+
+	d := newDocument(uri, source)
+	for _, test := range tests {
+		hover := d.hover(test.position)
+
+		got := ""
+		if hover != nil {
+			got = hover.Contents.Value
+		}
+
+		if got != test.expected {
+			t.Errorf("wrong hover at %v. got=%q, want=%q", test.position, got, test.expected)
+		}
+	}
+}
+
+func TestDefinitionAndReferences(t *testing.T) {
+	source := "let x = 1;\nlet f = fn(x) { x + y };\nlet y = x;\nf(x)"
+
+	tests := []struct {
+		position   Position
+		definition string
+		references []string // Without declaration
+	}{
+		{Position{0, 4}, "0:4-0:5", []string{"2:8-2:9", "3:2-3:3"}},
+		{Position{3, 3}, "0:4-0:5", []string{"2:8-2:9", "3:2-3:3"}},
+		{Position{1, 16}, "1:11-1:12", []string{"1:16-1:17"}},
+		{Position{1, 20}, "2:4-2:5", []string{"1:20-1:21"}},
+		{Position{3, 0}, "1:4-1:5", []string{"3:0-3:1"}},
+		{Position{1, 13}, "", []string{}},
+	}
+
+	d := newDocument(uri, source)
+	for _, test := range tests {
+		definition := ""
+		if location := d.definition(test.position); location != nil {
+			definition = formatRange(location.Range)
+		}
+
+		if definition != test.definition {
+			t.Errorf("wrong definition at %v. got=%s, want=%s", test.position, definition, test.definition)
+		}
+
+		references := []string{}
+		for _, location := range d.referenceLocations(test.position, false) {
+			references = append(references, formatRange(location.Range))
+		}
+
+		if strings.Join(references, " ") != strings.Join(test.references, " ") {
+			t.Errorf("wrong references at %v. got=%v, want=%v", test.position, references, test.references)
+		}
+	}
+
+	if len(d.referenceLocations(Position{0, 4}, true)) != 3 {
+		t.Errorf("declaration not included in references")
+	}
+}
+
+func TestRename(t *testing.T) {
+	tests := []struct {
+		input    string
+		position Position
+		newName  string
+		expected string // Source after applying edits or error message
+	}{
+		{"let x = 1; let g = fn(x) { x }; x + g(x)", Position{0, 4}, "count",
+			"let count = 1; let g = fn(x) { x }; count + g(count)"},
+		{"let x = 1; let g = fn(x) { x }; x + g(x)", Position{0, 27}, "n",
+			"let x = 1; let g = fn(n) { n }; x + g(x)"},
+		{"let {name, age} = d; name", Position{0, 5}, "n", "let {name: n, age} = d; n"},
+		{"import \"lib\" as l; l.f()", Position{0, 19}, "m", "import \"lib\" as m; m.f()"},
+		{"let x = 1; x", Position{0, 4}, "fn", "\"fn\" is not a valid identifier"},
+		{"let x = 1; x", Position{0, 4}, "a b", "\"a b\" is not a valid identifier"},
+		{"len([1])", Position{0, 1}, "size", "len is a builtin or undefined name"},
+		{"import \"lib\"; lib.f()", Position{0, 15}, "l", "lib is named after imported module, add alias to rename it"},
+		{"struct P { x, fn m(self) { self.x } }; P(1)", Position{0, 11}, "y",
+			"x is a struct field, member accesses can't be renamed"},
+	}
+
+	for _, test := range tests {
+		d := newDocument(uri, test.input)
+
+		got := ""
+		edit, err := d.rename(test.position, test.newName)
+		if err != nil {
+			got = err.Error()
+		} else {
+			got = apply(test.input, edit.Changes[uri])
+		}
+
+		if got != test.expected {
+			t.Errorf("wrong rename in %q. got=%q, want=%q", test.input, got, test.expected)
+		}
+	}
+}
+
+// apply Returns single line source with edits applied, edits are in order
+func apply(source string, edits []TextEdit) string {
+	var out strings.Builder
+
+	last := 0
+	for _, edit := range edits {
+		out.WriteString(source[last:edit.Range.Start.Character])
+		out.WriteString(edit.NewText)
+		last = edit.Range.End.Character
+	}
+
+	out.WriteString(source[last:])
+
+	return out.String()
+}
+
+func TestSymbols(t *testing.T) {
+	source := "import \"lib\" as l;\nexport let add = fn(a, b) { a + b };\nlet [p, q] = [1, 2];\n" +
+		"struct Point { x, y = 0, fn norm(self) { self.x } }"
+
+	var describe func(symbols []DocumentSymbol) string
+	describe = func(symbols []DocumentSymbol) string {
+		described := []string{}
+		for _, s := range symbols {
+			entry := fmt.Sprintf("%s/%d@%s", s.Name, s.Kind, formatRange(s.SelectionRange))
+			if len(s.Children) > 0 {
+				entry += "{" + describe(s.Children) + "}"
+			}
+
+			described = append(described, entry)
+		}
+
+		return strings.Join(described, " ")
+	}
+
+	expected := "l/2@0:16-0:17 add/12@1:11-1:14 p/13@2:5-2:6 q/13@2:8-2:9 " +
+		"Point/23@3:7-3:12{x/8@3:15-3:16 y/8@3:18-3:19 norm/6@3:28-3:32}"
+
+	d := newDocument(uri, source)
+	if got := describe(d.symbols()); got != expected {
+		t.Errorf("wrong symbols.\ngot:  %s\nwant: %s", got, expected)
+	}
+
+	if got := formatRange(d.symbols()[1].Range); got != "1:7-1:35" {
+		t.Errorf("wrong range of let statement. got=%s", got)
+	}
+
+	if d.symbols()[1].Detail != "fn(any, any) -> any" {
+		t.Errorf("wrong detail of function. got=%q", d.symbols()[1].Detail)
+	}
+}
+
+func TestCompletion(t *testing.T) {
+	d := newDocument(uri, "let total = 1; let len = 2; total + len")
+
+	items := map[string]CompletionItem{}
+	for _, item := range d.completion() {
+		items[item.Label] = item
+	}
+
+	expected := []struct {
+		label  string
+		kind   int
+		detail string
+	}{
+		{"total", completionVariable, "int"},
+		{"len", completionVariable, "int"},
+		{"puts", completionFunction, "builtin function"},
+		{"match", completionKeyword, ""},
+		{"fn", completionKeyword, ""},
+	}
+
+	for _, e := range expected {
+		item, ok := items[e.label]
+		if !ok || item.Kind != e.kind || item.Detail != e.detail {
+			t.Errorf("wrong completion of %s. got=%+v", e.label, item)
+		}
+	}
+
+	if items := newDocument(uri, "let = 1").completion(); len(items) == 0 {
+		t.Errorf("expected builtins and keywords for source with syntax errors")
+	}
+}
+
+func TestFormatting(t *testing.T) {
+	d := newDocument(uri, "let   x=1\nputs( x )")
+
+	edits, err := d.formatting()
+	if err != nil {
+		t.Fatalf("formatting returned error: %v", err)
+	}
+
+	if len(edits) != 1 || edits[0].NewText != "let x = 1;\nputs(x);\n" || formatRange(edits[0].Range) != "0:0-1:9" {
+		t.Errorf("wrong edits: %+v", edits)
+	}
+
+	if edits, _ := newDocument(uri, "let x = 1;\n").formatting(); len(edits) != 0 {
+		t.Errorf("expected no edits for formatted source. got=%+v", edits)
+	}
+
+	if _, err := newDocument(uri, "let = 1").formatting(); err == nil {
+		t.Errorf("expected error for source with syntax errors")
+	}
+}
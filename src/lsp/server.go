@@ -0,0 +1,357 @@
+package lsp
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/textproto"
+	"strconv"
+)
+
+// Server Language server for cgo source files. Documents are analyzed when
+// opened or changed and their diagnostics published right after.
+type Server struct {
+	reader    *bufio.Reader
+	writer    io.Writer
+	documents map[string]*document
+	shutdown  bool // Set once shutdown request is received, only exit may follow
+}
+
+// handler Handles request parameters and returns result of the request
+type handler func(s *Server, params json.RawMessage) (interface{}, error)
+
+var handlers = map[string]handler{
+	"initialize":                  (*Server).initialize,
+	"shutdown":                    (*Server).shutdownRequest,
+	"textDocument/hover":          (*Server).hover,
+	"textDocument/definition":     (*Server).definition,
+	"textDocument/references":     (*Server).references,
+	"textDocument/rename":         (*Server).rename,
+	"textDocument/documentSymbol": (*Server).documentSymbol,
+	"textDocument/completion":     (*Server).completion,
+	"textDocument/formatting":     (*Server).formatting,
+}
+
+type notificationHandler func(s *Server, params json.RawMessage) error
+
+var notificationHandlers = map[string]notificationHandler{
+	"initialized":            func(*Server, json.RawMessage) error { return nil },
+	"textDocument/didOpen":   (*Server).didOpen,
+	"textDocument/didChange": (*Server).didChange,
+	"textDocument/didClose":  (*Server).didClose,
+}
+
+// errExit Returned by message handling once exit notification is received
+var errExit = errors.New("exit")
+
+// NewServer Constructor of server reading messages from in and writing to out
+func NewServer(in io.Reader, out io.Writer) *Server {
+	return &Server{reader: bufio.NewReader(in), writer: out, documents: map[string]*document{}}
+}
+
+// Serve Handles messages until exit notification. Error is returned when
+// the connection is closed or exit comes without shutdown request before it.
+func (s *Server) Serve() error {
+	for {
+		content, err := s.read()
+		if err != nil {
+			return err
+		}
+
+		err = s.handle(content)
+		if err == errExit {
+			if !s.shutdown {
+				return fmt.Errorf("exit notification received before shutdown request")
+			}
+
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// read Returns content of the next message framed by Content-Length header
+func (s *Server) read() ([]byte, error) {
+	header, err := textproto.NewReader(s.reader).ReadMIMEHeader()
+	if err != nil {
+		return nil, err
+	}
+
+	length, err := strconv.Atoi(header.Get("Content-Length"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid Content-Length header: %q", header.Get("Content-Length"))
+	}
+
+	content := make([]byte, length)
+	if _, err := io.ReadFull(s.reader, content); err != nil {
+		return nil, err
+	}
+
+	return content, nil
+}
+
+func (s *Server) write(value interface{}) error {
+	content, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	if _, err := fmt.Fprintf(s.writer, "Content-Length: %d\r\n\r\n", len(content)); err != nil {
+		return err
+	}
+
+	_, err = s.writer.Write(content)
+
+	return err
+}
+
+// handle Dispatches message to its handler. Only failures of the connection
+// are returned, failed requests are answered with an error response.
+func (s *Server) handle(content []byte) error {
+	var msg message
+	if err := json.Unmarshal(content, &msg); err != nil {
+		return s.respond(nil, nil, &responseError{Code: parseError, Message: err.Error()})
+	}
+
+	if msg.ID == nil {
+		if msg.Method == "exit" {
+			return errExit
+		}
+
+		if handle, ok := notificationHandlers[msg.Method]; ok && !s.shutdown {
+			return handle(s, msg.Params)
+		}
+
+		// Unknown notifications are ignored
+		return nil
+	}
+
+	handle, ok := handlers[msg.Method]
+	if !ok {
+		return s.respond(msg.ID, nil, &responseError{Code: methodNotFound, Message: "method not found: " + msg.Method})
+	}
+
+	result, err := handle(s, msg.Params)
+	if err != nil {
+		failure, ok := err.(*responseError)
+		if !ok {
+			failure = &responseError{Code: requestFailed, Message: err.Error()}
+		}
+
+		return s.respond(msg.ID, nil, failure)
+	}
+
+	return s.respond(msg.ID, result, nil)
+}
+
+func (s *Server) respond(id *json.RawMessage, result interface{}, failure *responseError) error {
+	if failure != nil {
+		return s.write(errorResponse{JSONRPC: "2.0", ID: id, Error: failure})
+	}
+
+	return s.write(response{JSONRPC: "2.0", ID: id, Result: result})
+}
+
+// decode Unmarshals request parameters
+func decode(params json.RawMessage, target interface{}) error {
+	if err := json.Unmarshal(params, target); err != nil {
+		return &responseError{Code: invalidParams, Message: err.Error()}
+	}
+
+	return nil
+}
+
+// document Returns open document with the URI
+func (s *Server) document(uri string) (*document, error) {
+	d, ok := s.documents[uri]
+	if !ok {
+		return nil, &responseError{Code: invalidParams, Message: "document is not open: " + uri}
+	}
+
+	return d, nil
+}
+
+// analyzed Returns open document with the URI when its source has no syntax
+// errors, nil otherwise
+func (s *Server) analyzed(uri string) (*document, error) {
+	d, err := s.document(uri)
+	if err != nil || d.program == nil {
+		return nil, err
+	}
+
+	return d, nil
+}
+
+func (s *Server) initialize(json.RawMessage) (interface{}, error) {
+	return map[string]interface{}{
+		"capabilities": map[string]interface{}{
+			"textDocumentSync":           1, // Full text of document is sent on change
+			"hoverProvider":              true,
+			"definitionProvider":         true,
+			"referencesProvider":         true,
+			"renameProvider":             true,
+			"documentSymbolProvider":     true,
+			"documentFormattingProvider": true,
+			"completionProvider":         map[string]interface{}{},
+		},
+		"serverInfo": map[string]string{"name": "cgo"},
+	}, nil
+}
+
+func (s *Server) shutdownRequest(json.RawMessage) (interface{}, error) {
+	s.shutdown = true
+	return nil, nil
+}
+
+// open Analyzes document text and publishes its diagnostics
+func (s *Server) open(uri string, text string) error {
+	d := newDocument(uri, text)
+	s.documents[uri] = d
+
+	return s.publishDiagnostics(uri, d.diagnostics)
+}
+
+func (s *Server) publishDiagnostics(uri string, diagnostics []Diagnostic) error {
+	return s.write(notification{
+		JSONRPC: "2.0",
+		Method:  "textDocument/publishDiagnostics",
+		Params:  publishDiagnosticsParams{URI: uri, Diagnostics: diagnostics},
+	})
+}
+
+func (s *Server) didOpen(params json.RawMessage) error {
+	var p didOpenParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil
+	}
+
+	return s.open(p.TextDocument.URI, p.TextDocument.Text)
+}
+
+func (s *Server) didChange(params json.RawMessage) error {
+	var p didChangeParams
+	if err := json.Unmarshal(params, &p); err != nil || len(p.ContentChanges) == 0 {
+		return nil
+	}
+
+	return s.open(p.TextDocument.URI, p.ContentChanges[len(p.ContentChanges)-1].Text)
+}
+
+// didClose Forgets document and clears its diagnostics
+func (s *Server) didClose(params json.RawMessage) error {
+	var p documentParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil
+	}
+
+	delete(s.documents, p.TextDocument.URI)
+
+	return s.publishDiagnostics(p.TextDocument.URI, []Diagnostic{})
+}
+
+func (s *Server) hover(params json.RawMessage) (interface{}, error) {
+	var p positionParams
+	if err := decode(params, &p); err != nil {
+		return nil, err
+	}
+
+	d, err := s.analyzed(p.TextDocument.URI)
+	if d == nil {
+		return nil, err
+	}
+
+	return d.hover(p.Position), nil
+}
+
+func (s *Server) definition(params json.RawMessage) (interface{}, error) {
+	var p positionParams
+	if err := decode(params, &p); err != nil {
+		return nil, err
+	}
+
+	d, err := s.analyzed(p.TextDocument.URI)
+	if d == nil {
+		return nil, err
+	}
+
+	return d.definition(p.Position), nil
+}
+
+func (s *Server) references(params json.RawMessage) (interface{}, error) {
+	var p referenceParams
+	if err := decode(params, &p); err != nil {
+		return nil, err
+	}
+
+	d, err := s.analyzed(p.TextDocument.URI)
+	if d == nil {
+		return []Location{}, err
+	}
+
+	return d.referenceLocations(p.Position, p.Context.IncludeDeclaration), nil
+}
+
+func (s *Server) rename(params json.RawMessage) (interface{}, error) {
+	var p renameParams
+	if err := decode(params, &p); err != nil {
+		return nil, err
+	}
+
+	d, err := s.analyzed(p.TextDocument.URI)
+	if d == nil {
+		if err == nil {
+			err = fmt.Errorf("can't rename in source with syntax errors")
+		}
+
+		return nil, err
+	}
+
+	return d.rename(p.Position, p.NewName)
+}
+
+func (s *Server) documentSymbol(params json.RawMessage) (interface{}, error) {
+	var p documentParams
+	if err := decode(params, &p); err != nil {
+		return nil, err
+	}
+
+	d, err := s.analyzed(p.TextDocument.URI)
+	if d == nil {
+		return []DocumentSymbol{}, err
+	}
+
+	return d.symbols(), nil
+}
+
+func (s *Server) completion(params json.RawMessage) (interface{}, error) {
+	var p positionParams
+	if err := decode(params, &p); err != nil {
+		return nil, err
+	}
+
+	d, err := s.document(p.TextDocument.URI)
+	if err != nil {
+		return nil, err
+	}
+
+	return d.completion(), nil
+}
+
+func (s *Server) formatting(params json.RawMessage) (interface{}, error) {
+	var p documentParams
+	if err := decode(params, &p); err != nil {
+		return nil, err
+	}
+
+	d, err := s.document(p.TextDocument.URI)
+	if err != nil {
+		return nil, err
+	}
+
+	return d.formatting()
+}
@@ -4,11 +4,22 @@ import (
 	"fmt"
 	"os"
 	"os/user"
+	"path/filepath"
 
+	"github.com/aeremic/cgo/evaluator"
 	"github.com/aeremic/cgo/repl"
 )
 
+// Directories listed in CGO_PATH are searched for imported modules
+const searchPathEnv = "CGO_PATH"
+
 func main() {
+	evaluator.SetModuleSearchPath(filepath.SplitList(os.Getenv(searchPathEnv)))
+
+	if len(os.Args) > 1 {
+		os.Exit(run(os.Args[1:]))
+	}
+
 	user, err := user.Current()
 	if err != nil {
 		panic(err)
@@ -17,3 +28,23 @@ func main() {
 	fmt.Printf("Welcome %s to C Go programming langauge!\n", user.Name)
 	repl.Start(os.Stdin, os.Stdout)
 }
+
+// run Executes `cgo [run] [flags] file.cgo` or a tool command and returns process exit code
+func run(args []string) int {
+	switch args[0] {
+	case "fmt":
+		return formatFiles(args[1:])
+	case "lint":
+		return lintFiles(args[1:])
+	case "debug":
+		return debugFile(args[1:])
+	case "dap":
+		return serveDebugAdapter(args[1:])
+	case "lsp":
+		return serveLanguageServer(args[1:])
+	case "run":
+		args = args[1:]
+	}
+
+	return runFile(args)
+}
@@ -15,8 +15,12 @@ type Parser struct {
 	peekToken      token.Token
 	prefixParseFns map[token.Type]prefixParseFn
 	infixParseFns  map[token.Type]infixParseFn
+	blockDepth     int  // Number of enclosing blocks, zero at top level
+	matchPatterns  bool // Set while parsing match arm patterns
+	comments       []token.Token
 
-	errors []string
+	errors         []string
+	errorPositions []token.Token // Tokens parser was at when each error was logged
 }
 
 // New Constructor
@@ -36,23 +40,35 @@ func New(t *tokenizer.Tokenizer) *Parser {
 	p.registerPrefix(token.MINUS, p.parsePrefixExpression)
 	p.registerPrefix(token.TRUE, p.parseBoolean)
 	p.registerPrefix(token.FALSE, p.parseBoolean)
+	p.registerPrefix(token.NULL, p.parseNullLiteral)
 	p.registerPrefix(token.LPAREN, p.parseGroupedExpression)
 	p.registerPrefix(token.IF, p.parseIfExpression)
 	p.registerPrefix(token.FUNC, p.parseFunctionLiteral)
 	p.registerPrefix(token.LBRACKET, p.parseArrayLiteral)
 	p.registerPrefix(token.LBRACE, p.parseDictLiteral)
+	p.registerPrefix(token.TRY, p.parseTryExpression)
+	p.registerPrefix(token.ELLIPSIS, p.parseSpreadExpression)
+	p.registerPrefix(token.MATCH, p.parseMatchExpression)
+	p.registerPrefix(token.MACRO, p.parseMacroLiteral)
 
 	p.infixParseFns = make(map[token.Type]infixParseFn)
 	p.registerInfix(token.EQUALS, p.parseInfixExpression)
 	p.registerInfix(token.NOT_EQUALS, p.parseInfixExpression)
 	p.registerInfix(token.LT, p.parseInfixExpression)
 	p.registerInfix(token.GT, p.parseInfixExpression)
+	p.registerInfix(token.RANGE, p.parseInfixExpression)
+	p.registerInfix(token.RANGE_INCL, p.parseInfixExpression)
 	p.registerInfix(token.PLUS, p.parseInfixExpression)
 	p.registerInfix(token.MINUS, p.parseInfixExpression)
 	p.registerInfix(token.SLASH, p.parseInfixExpression)
 	p.registerInfix(token.ASTERISK, p.parseInfixExpression)
 	p.registerInfix(token.LPAREN, p.parseCallExpression)
 	p.registerInfix(token.LBRACKET, p.parseIndexExpression)
+	p.registerInfix(token.ASSIGN, p.parseAssignExpression)
+	p.registerInfix(token.COALESCE, p.parseInfixExpression)
+	p.registerInfix(token.OPTIONAL, p.parseOptionalExpression)
+	p.registerInfix(token.PIPE, p.parsePipeExpression)
+	p.registerInfix(token.DOT, p.parseMemberExpression)
 
 	return p
 }
@@ -63,9 +79,19 @@ func (p *Parser) Errors() []string {
 	return p.errors
 }
 
+// ErrorPositions Returns tokens errors were found at, indexed like Errors
+func (p *Parser) ErrorPositions() []token.Token {
+	return p.errorPositions
+}
+
+func (p *Parser) logError(position token.Token, msg string) {
+	p.errors = append(p.errors, msg)
+	p.errorPositions = append(p.errorPositions, position)
+}
+
 func (p *Parser) LogPeekError(t token.Type) {
 	msg := fmt.Sprintf("Expected %s token. Got %s instead", t, p.peekToken.Type)
-	p.errors = append(p.errors, msg)
+	p.logError(p.peekToken, msg)
 }
 
 func (p *Parser) ParseProgram() *ast.ProgramRoot {
@@ -81,6 +107,8 @@ func (p *Parser) ParseProgram() *ast.ProgramRoot {
 		p.nextToken()
 	}
 
+	program.Comments = p.comments
+
 	return program
 }
 
@@ -90,6 +118,14 @@ func (p *Parser) parseStatement() ast.Statement {
 		return p.parseLetStatement()
 	case token.RETURN:
 		return p.parseReturnStatement()
+	case token.IMPORT, token.FROM:
+		return p.parseImportStatement()
+	case token.EXPORT:
+		return p.parseExportStatement()
+	case token.THROW:
+		return p.parseThrowStatement()
+	case token.STRUCT:
+		return p.parseStructStatement()
 	default:
 		return p.parseExpressionStatement()
 	}
@@ -98,11 +134,27 @@ func (p *Parser) parseStatement() ast.Statement {
 func (p *Parser) parseLetStatement() *ast.LetStatement {
 	statement := &ast.LetStatement{Token: p.currentToken}
 
-	if !p.peekAndMove(token.IDENT) {
-		return nil
-	}
+	if p.checkPeekTokenType(token.LBRACKET) || p.checkPeekTokenType(token.LBRACE) {
+		p.nextToken()
 
-	statement.Name = &ast.Identifier{Token: p.currentToken, Value: p.currentToken.Literal}
+		statement.Pattern = p.parsePattern()
+		if statement.Pattern == nil {
+			return nil
+		}
+	} else {
+		if !p.peekAndMove(token.IDENT) {
+			return nil
+		}
+
+		statement.Name = &ast.Identifier{Token: p.currentToken, Value: p.currentToken.Literal}
+
+		annotation, ok := p.parseTypeAnnotation()
+		if !ok {
+			return nil
+		}
+
+		statement.Type = annotation
+	}
 
 	if !p.peekAndMove(token.ASSIGN) {
 		return nil
@@ -112,7 +164,9 @@ func (p *Parser) parseLetStatement() *ast.LetStatement {
 
 	statement.Value = p.parseExpression(LOWEST)
 
-	for !p.checkCurrentTokenType(token.SEMICOLON) {
+	// Semicolon is optional, statement ends with its value like expression
+	// statements do and the next statement isn't skipped looking for one
+	if p.checkPeekTokenType(token.SEMICOLON) {
 		p.nextToken()
 	}
 
@@ -126,7 +180,87 @@ func (p *Parser) parseReturnStatement() *ast.ReturnStatement {
 
 	statement.ReturnValue = p.parseExpression(LOWEST)
 
-	for !p.checkCurrentTokenType(token.SEMICOLON) {
+	if p.checkPeekTokenType(token.SEMICOLON) {
+		p.nextToken()
+	}
+
+	return statement
+}
+
+func (p *Parser) parseImportStatement() *ast.ImportStatement {
+	statement := &ast.ImportStatement{Token: p.currentToken}
+
+	if !p.peekAndMove(token.STRING) {
+		return nil
+	}
+
+	statement.Path = &ast.StringLiteral{Token: p.currentToken, Value: p.currentToken.Literal}
+
+	if statement.Token.Type == token.FROM {
+		if !p.peekAndMove(token.IMPORT) {
+			return nil
+		}
+
+		statement.Names = []*ast.Identifier{}
+		for {
+			if !p.peekAndMove(token.IDENT) {
+				return nil
+			}
+
+			statement.Names = append(statement.Names,
+				&ast.Identifier{Token: p.currentToken, Value: p.currentToken.Literal})
+
+			if !p.checkPeekTokenType(token.COMMA) {
+				break
+			}
+
+			p.nextToken()
+		}
+	} else if p.checkPeekTokenType(token.AS) {
+		p.nextToken()
+
+		if !p.peekAndMove(token.IDENT) {
+			return nil
+		}
+
+		statement.Alias = &ast.Identifier{Token: p.currentToken, Value: p.currentToken.Literal}
+	}
+
+	if p.checkPeekTokenType(token.SEMICOLON) {
+		p.nextToken()
+	}
+
+	return statement
+}
+
+func (p *Parser) parseExportStatement() *ast.ExportStatement {
+	statement := &ast.ExportStatement{Token: p.currentToken}
+
+	if p.blockDepth > 0 {
+		p.logError(p.currentToken, "Export is only allowed at module top level")
+		return nil
+	}
+
+	if !p.peekAndMove(token.LET) {
+		return nil
+	}
+
+	statement.Statement = p.parseLetStatement()
+	if statement.Statement == nil {
+		return nil
+	}
+
+	return statement
+}
+
+func (p *Parser) parseThrowStatement() *ast.ThrowStatement {
+	statement := &ast.ThrowStatement{Token: p.currentToken}
+
+	p.nextToken()
+
+	statement.Value = p.parseExpression(LOWEST)
+
+	if p.checkPeekTokenType(token.SEMICOLON) {
 		p.nextToken()
 	}
 
@@ -172,6 +306,9 @@ func (p *Parser) parseBlockStatement() *ast.BlockStatement {
 	block := &ast.BlockStatement{Token: p.currentToken}
 	block.Statements = []ast.Statement{}
 
+	p.blockDepth++
+	defer func() { p.blockDepth-- }()
+
 	p.nextToken()
 
 	for !p.checkCurrentTokenType(token.RBRACE) && !p.checkCurrentTokenType(token.EOF) {
@@ -183,6 +320,10 @@ func (p *Parser) parseBlockStatement() *ast.BlockStatement {
 		p.nextToken()
 	}
 
+	if p.checkCurrentTokenType(token.RBRACE) {
+		block.Rbrace = p.currentToken
+	}
+
 	return block
 }
 
@@ -196,7 +337,7 @@ func (p *Parser) parseIntegerLiteral() ast.Expression {
 	value, err := strconv.ParseInt(p.currentToken.Literal, 0, 64)
 	if err != nil {
 		message := fmt.Sprintf("Could not parse %q as integer.", p.currentToken.Literal)
-		p.errors = append(p.errors, message)
+		p.logError(p.currentToken, message)
 
 		return nil
 	}
@@ -246,7 +387,7 @@ func (p *Parser) parseCallExpression(function ast.Expression) ast.Expression {
 		Function: function,
 	}
 
-	expression.Arguments = p.parseExpressionList(token.RPAREN)
+	expression.Arguments = p.parseCallArguments()
 
 	return expression
 }
@@ -258,7 +399,13 @@ func (p *Parser) parseIndexExpression(left ast.Expression) ast.Expression {
 	}
 
 	p.nextToken()
-	expression.Index = p.parseExpression(LOWEST)
+	if !p.checkCurrentTokenType(token.COLON) {
+		expression.Index = p.parseExpression(LOWEST)
+	}
+
+	if p.checkCurrentTokenType(token.COLON) || p.checkPeekTokenType(token.COLON) {
+		expression.Index = p.parseSliceIndex(expression.Index)
+	}
 
 	if !p.peekAndMove(token.RBRACKET) {
 		return nil
@@ -267,6 +414,123 @@ func (p *Parser) parseIndexExpression(left ast.Expression) ast.Expression {
 	return expression
 }
 
+// parseSliceIndex Parses [start]:[end][:step] following optional start
+func (p *Parser) parseSliceIndex(start ast.Expression) ast.Expression {
+	if !p.checkCurrentTokenType(token.COLON) {
+		p.nextToken()
+	}
+
+	slice := &ast.SliceIndex{Token: p.currentToken, Start: start}
+
+	if !p.checkPeekTokenType(token.COLON) && !p.checkPeekTokenType(token.RBRACKET) {
+		p.nextToken()
+		slice.End = p.parseExpression(LOWEST)
+	}
+
+	if p.checkPeekTokenType(token.COLON) {
+		p.nextToken()
+
+		if !p.checkPeekTokenType(token.RBRACKET) {
+			p.nextToken()
+			slice.Step = p.parseExpression(LOWEST)
+		}
+	}
+
+	return slice
+}
+
+func (p *Parser) parseMemberExpression(object ast.Expression) ast.Expression {
+	expression := &ast.MemberExpression{
+		Token:  p.currentToken,
+		Object: object,
+	}
+
+	if !p.peekAndMove(token.IDENT) {
+		return nil
+	}
+
+	expression.Property = &ast.Identifier{Token: p.currentToken, Value: p.currentToken.Literal}
+
+	return expression
+}
+
+// parseOptionalExpression Parses a?.[index], a?.member and f?.(args)
+func (p *Parser) parseOptionalExpression(left ast.Expression) ast.Expression {
+	switch p.peekToken.Type {
+	case token.IDENT:
+		expression, ok := p.parseMemberExpression(left).(*ast.MemberExpression)
+		if !ok {
+			return nil
+		}
+
+		expression.Optional = true
+
+		return expression
+	case token.LBRACKET:
+		p.nextToken()
+
+		expression, ok := p.parseIndexExpression(left).(*ast.IndexExpression)
+		if !ok {
+			return nil
+		}
+
+		expression.Optional = true
+
+		return expression
+	case token.LPAREN:
+		p.nextToken()
+
+		expression, ok := p.parseCallExpression(left).(*ast.CallExpression)
+		if !ok {
+			return nil
+		}
+
+		expression.Optional = true
+
+		return expression
+	default:
+		msg := fmt.Sprintf("Expected [, ( or member name after ?. Got %s instead", p.peekToken.Type)
+		p.logError(p.peekToken, msg)
+
+		return nil
+	}
+}
+
+func (p *Parser) parsePipeExpression(left ast.Expression) ast.Expression {
+	expression := &ast.PipeExpression{
+		Token: p.currentToken,
+		Left:  left,
+	}
+
+	p.nextToken()
+	expression.Right = p.parseExpression(PIPE)
+
+	return expression
+}
+
+func (p *Parser) parseAssignExpression(target ast.Expression) ast.Expression {
+	expression := &ast.AssignExpression{
+		Token:  p.currentToken,
+		Target: target,
+	}
+
+	switch target.(type) {
+	case *ast.IndexExpression, *ast.MemberExpression:
+	default:
+		msg := fmt.Sprintf("Invalid assignment target %s", target)
+		p.logError(p.currentToken, msg)
+
+		return nil
+	}
+
+	// Assignment is right associative so parse the value with lower precedence
+	p.nextToken()
+	expression.Value = p.parseExpression(ASSIGN - 1)
+
+	return expression
+}
+
+// parseCallArguments Parses positional, spread and keyword arguments
 func (p *Parser) parseCallArguments() []ast.Expression {
 	args := []ast.Expression{}
 
@@ -275,14 +539,29 @@ func (p *Parser) parseCallArguments() []ast.Expression {
 		return args
 	}
 
-	p.nextToken()
-
-	args = append(args, p.parseExpression(LOWEST))
-	for p.checkPeekTokenType(token.COMMA) {
-		p.nextToken()
+	for {
 		p.nextToken()
 
-		args = append(args, p.parseExpression(LOWEST))
+		if p.checkCurrentTokenType(token.IDENT) && p.checkPeekTokenType(token.COLON) {
+			argument := &ast.KeywordArgument{
+				Token: p.currentToken,
+				Name:  &ast.Identifier{Token: p.currentToken, Value: p.currentToken.Literal},
+			}
+
+			p.nextToken()
+			p.nextToken()
+			argument.Value = p.parseExpression(LOWEST)
+
+			args = append(args, argument)
+		} else {
+			args = append(args, p.parseExpression(LOWEST))
+		}
+
+		if !p.checkPeekTokenType(token.COMMA) {
+			break
+		}
+
+		p.nextToken()
 	}
 
 	if !p.peekAndMove(token.RPAREN) {
@@ -292,6 +571,15 @@ func (p *Parser) parseCallArguments() []ast.Expression {
 	return args
 }
 
+func (p *Parser) parseSpreadExpression() ast.Expression {
+	expression := &ast.SpreadExpression{Token: p.currentToken}
+
+	p.nextToken()
+	expression.Value = p.parseExpression(PREFIX)
+
+	return expression
+}
+
 func (p *Parser) parseBoolean() ast.Expression {
 	expression := &ast.Boolean{
 		Token: p.currentToken,
@@ -301,6 +589,10 @@ func (p *Parser) parseBoolean() ast.Expression {
 	return expression
 }
 
+func (p *Parser) parseNullLiteral() ast.Expression {
+	return &ast.NullLiteral{Token: p.currentToken}
+}
+
 func (p *Parser) parseGroupedExpression() ast.Expression {
 	p.nextToken()
 
@@ -352,16 +644,279 @@ func (p *Parser) parseIfExpression() ast.Expression {
 	return expression
 }
 
+func (p *Parser) parseTryExpression() ast.Expression {
+	expression := &ast.TryExpression{Token: p.currentToken}
+
+	if !p.peekAndMove(token.LBRACE) {
+		return nil
+	}
+
+	expression.Block = p.parseBlockStatement()
+
+	if p.checkPeekTokenType(token.CATCH) {
+		p.nextToken()
+
+		if !p.peekAndMove(token.LPAREN) || !p.peekAndMove(token.IDENT) {
+			return nil
+		}
+
+		expression.CatchParam = &ast.Identifier{Token: p.currentToken, Value: p.currentToken.Literal}
+
+		if !p.peekAndMove(token.RPAREN) || !p.peekAndMove(token.LBRACE) {
+			return nil
+		}
+
+		expression.Catch = p.parseBlockStatement()
+	}
+
+	if p.checkPeekTokenType(token.FINALLY) {
+		p.nextToken()
+
+		if !p.peekAndMove(token.LBRACE) {
+			return nil
+		}
+
+		expression.Finally = p.parseBlockStatement()
+	}
+
+	if expression.Catch == nil && expression.Finally == nil {
+		p.logError(p.currentToken, "Expected catch or finally after try block")
+		return nil
+	}
+
+	return expression
+}
+
+// parseStructStatement Parses struct Name { field, field = default, fn method(self) { ... } }.
+// Fields are separated by commas and come before methods.
+func (p *Parser) parseStructStatement() ast.Statement {
+	statement := &ast.StructStatement{Token: p.currentToken}
+
+	if !p.peekAndMove(token.IDENT) {
+		return nil
+	}
+
+	statement.Name = &ast.Identifier{Token: p.currentToken, Value: p.currentToken.Literal}
+
+	if !p.peekAndMove(token.LBRACE) {
+		return nil
+	}
+
+	members := map[string]bool{}
+	declare := func(name *ast.Identifier) bool {
+		if members[name.Value] {
+			msg := fmt.Sprintf("Duplicate struct member %s", name.Value)
+			p.logError(name.Token, msg)
+
+			return false
+		}
+
+		members[name.Value] = true
+
+		return true
+	}
+
+	for !p.checkPeekTokenType(token.RBRACE) {
+		p.nextToken()
+
+		switch {
+		case p.checkCurrentTokenType(token.IDENT) && len(statement.Methods) == 0:
+			field := p.parsePatternElement()
+			if field == nil || !declare(ast.PatternIdentifiers(field)[0]) {
+				return nil
+			}
+
+			statement.Fields = append(statement.Fields, field)
+		case p.checkCurrentTokenType(token.FUNC):
+			method := p.parseStructMethod()
+			if method == nil || !declare(method.Name) {
+				return nil
+			}
+
+			statement.Methods = append(statement.Methods, method)
+		default:
+			msg := fmt.Sprintf("Expected struct field or method. Got %s instead", p.currentToken.Type)
+			p.logError(p.currentToken, msg)
+
+			return nil
+		}
+
+		if p.checkPeekTokenType(token.COMMA) {
+			p.nextToken()
+		} else if !p.checkPeekTokenType(token.RBRACE) && !p.checkPeekTokenType(token.FUNC) {
+			p.LogPeekError(token.COMMA)
+			return nil
+		}
+	}
+
+	p.nextToken()
+
+	if p.checkPeekTokenType(token.SEMICOLON) {
+		p.nextToken()
+	}
+
+	return statement
+}
+
+func (p *Parser) parseStructMethod() *ast.StructMethod {
+	method := &ast.StructMethod{
+		Function: &ast.FunctionLiteral{Token: p.currentToken},
+	}
+
+	if !p.peekAndMove(token.IDENT) {
+		return nil
+	}
+
+	method.Name = &ast.Identifier{Token: p.currentToken, Value: p.currentToken.Literal}
+
+	if !p.peekAndMove(token.LPAREN) || !p.parseFunctionSignature(method.Function) {
+		return nil
+	}
+
+	if !p.peekAndMove(token.LBRACE) {
+		return nil
+	}
+
+	method.Function.Body = p.parseBlockStatement()
+
+	return method
+}
+
+func (p *Parser) parseMatchExpression() ast.Expression {
+	expression := &ast.MatchExpression{Token: p.currentToken, Arms: []ast.MatchArm{}}
+
+	if !p.peekAndMove(token.LPAREN) {
+		return nil
+	}
+
+	p.nextToken()
+	expression.Subject = p.parseExpression(LOWEST)
+
+	if !p.peekAndMove(token.RPAREN) || !p.peekAndMove(token.LBRACE) {
+		return nil
+	}
+
+	for !p.checkPeekTokenType(token.RBRACE) {
+		p.nextToken()
+
+		arm, ok := p.parseMatchArm()
+		if !ok {
+			return nil
+		}
+
+		expression.Arms = append(expression.Arms, arm)
+
+		if !p.checkPeekTokenType(token.RBRACE) && !p.peekAndMove(token.COMMA) {
+			return nil
+		}
+	}
+
+	p.nextToken()
+
+	if len(expression.Arms) == 0 {
+		p.logError(p.currentToken, "Expected at least one match arm")
+		return nil
+	}
+
+	return expression
+}
+
+// parseMatchArm Parses pattern [if guard] => body
+func (p *Parser) parseMatchArm() (ast.MatchArm, bool) {
+	arm := ast.MatchArm{}
+
+	matchPatterns := p.matchPatterns
+	p.matchPatterns = true
+	arm.Pattern = p.parsePattern()
+	p.matchPatterns = matchPatterns
+
+	if arm.Pattern == nil {
+		return arm, false
+	}
+
+	if p.checkPeekTokenType(token.IF) {
+		p.nextToken()
+		p.nextToken()
+
+		arm.Guard = p.parseExpression(LOWEST)
+	}
+
+	if !p.peekAndMove(token.ARROW) {
+		return arm, false
+	}
+
+	p.nextToken()
+	arm.Body = p.parseExpression(LOWEST)
+
+	return arm, arm.Body != nil
+}
+
 func (p *Parser) parseFunctionLiteral() ast.Expression {
 	literal := &ast.FunctionLiteral{
 		Token: p.currentToken,
 	}
 
+	if !p.peekAndMove(token.LPAREN) || !p.parseFunctionSignature(literal) {
+		return nil
+	}
+
+	if !p.peekAndMove(token.LBRACE) {
+		return nil
+	}
+
+	literal.Body = p.parseBlockStatement()
+
+	return literal
+}
+
+// parseFunctionSignature Parses parameters and return type annotation of
+// function or method
+func (p *Parser) parseFunctionSignature(function *ast.FunctionLiteral) bool {
+	function.Parameters, function.ParameterTypes, function.Rest = p.parseFunctionParameters()
+	if function.Parameters == nil {
+		return false
+	}
+
+	returnType, ok := p.parseReturnType()
+	function.ReturnType = returnType
+
+	return ok
+}
+
+// parseMacroLiteral Parses macro(params) { body }, parameters are plain names
+func (p *Parser) parseMacroLiteral() ast.Expression {
+	literal := &ast.MacroLiteral{Token: p.currentToken, Parameters: []*ast.Identifier{}}
+
 	if !p.peekAndMove(token.LPAREN) {
 		return nil
 	}
 
-	literal.Parameters = p.parseFunctionParameters()
+	parameters, types, rest := p.parseFunctionParameters()
+	if parameters == nil {
+		return nil
+	}
+
+	for i, parameter := range parameters {
+		if types[i] != nil {
+			p.logError(p.currentToken, "Macro parameter can't have type annotation")
+			return nil
+		}
+
+		identifier, ok := parameter.(*ast.Identifier)
+		if !ok {
+			msg := fmt.Sprintf("Macro parameter must be an identifier. Got %s instead", parameter)
+			p.logError(p.currentToken, msg)
+
+			return nil
+		}
+
+		literal.Parameters = append(literal.Parameters, identifier)
+	}
+
+	if rest != nil {
+		p.logError(p.currentToken, "Macro can't have rest parameter")
+		return nil
+	}
 
 	if !p.peekAndMove(token.LBRACE) {
 		return nil
@@ -372,37 +927,58 @@ func (p *Parser) parseFunctionLiteral() ast.Expression {
 	return literal
 }
 
-func (p *Parser) parseFunctionParameters() []*ast.Identifier {
-	identifiers := []*ast.Identifier{}
+// parseFunctionParameters Parses parameter patterns with optional type
+// annotations and defaults followed by an optional ...rest parameter.
+// Annotations are returned by parameter index, nil for unannotated ones.
+func (p *Parser) parseFunctionParameters() ([]ast.Pattern, []ast.TypeExpression, *ast.Identifier) {
+	parameters := []ast.Pattern{}
+	types := []ast.TypeExpression{}
 
 	if p.checkPeekTokenType(token.RPAREN) {
 		p.nextToken()
 
-		return identifiers
+		return parameters, types, nil
 	}
 
-	p.nextToken()
+	for {
+		p.nextToken()
 
-	identifiers = append(identifiers, &ast.Identifier{
-		Token: p.currentToken,
-		Value: p.currentToken.Literal,
-	})
+		if p.checkCurrentTokenType(token.ELLIPSIS) {
+			rest := p.parseRestIdentifier(token.RPAREN)
+			if rest == nil {
+				return nil, nil, nil
+			}
 
-	for p.checkPeekTokenType(token.COMMA) {
-		p.nextToken()
-		p.nextToken()
+			p.nextToken()
 
-		identifiers = append(identifiers, &ast.Identifier{
-			Token: p.currentToken,
-			Value: p.currentToken.Literal,
-		})
+			return parameters, types, rest
+		}
+
+		parameter := p.parsePattern()
+		if parameter == nil {
+			return nil, nil, nil
+		}
+
+		annotation, ok := p.parseTypeAnnotation()
+		if !ok {
+			return nil, nil, nil
+		}
+
+		parameters = append(parameters, p.parseDefault(parameter))
+		types = append(types, annotation)
+
+		if !p.checkPeekTokenType(token.COMMA) {
+			break
+		}
+
+		p.nextToken()
 	}
 
 	if !p.peekAndMove(token.RPAREN) {
-		return nil
+		return nil, nil, nil
 	}
 
-	return identifiers
+	return parameters, types, nil
 }
 
 func (p *Parser) parseArrayLiteral() ast.Expression {
@@ -417,7 +993,7 @@ func (p *Parser) parseArrayLiteral() ast.Expression {
 
 func (p *Parser) parseDictLiteral() ast.Expression {
 	dict := &ast.DictLiteral{Token: p.currentToken}
-	dict.Elements = make(map[ast.Expression]ast.Expression)
+	dict.Elements = []ast.DictLiteralElement{}
 
 	for !p.checkPeekTokenType(token.RBRACE) {
 		p.nextToken()
@@ -430,7 +1006,7 @@ func (p *Parser) parseDictLiteral() ast.Expression {
 		p.nextToken()
 		value := p.parseExpression(LOWEST)
 
-		dict.Elements[key] = value
+		dict.Elements = append(dict.Elements, ast.DictLiteralElement{Key: key, Value: value})
 
 		if !p.checkPeekTokenType(token.RBRACE) && !p.peekAndMove(token.COMMA) {
 			return nil
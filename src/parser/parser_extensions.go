@@ -10,26 +10,37 @@ import (
 const (
 	_ int = iota
 	LOWEST
+	ASSIGN      // arr[index] = X
+	PIPE        // X |> f()
+	COALESCE    // X ?? Y
 	EQUALS      // ==
 	LESSGREATER // < or >
+	RANGE       // X..Y or X..=Y
 	SUM         // +
 	PRODUCT     // *
 	PREFIX      // -X or !X
 	CALL        // myFunction(X)
-	INDEX       // array[index]
+	INDEX       // array[index] or value.member
 )
 
 var precedences = map[token.Type]int{
+	token.ASSIGN:     ASSIGN,
+	token.PIPE:       PIPE,
+	token.COALESCE:   COALESCE,
 	token.EQUALS:     EQUALS,
 	token.NOT_EQUALS: EQUALS,
 	token.LT:         LESSGREATER,
 	token.GT:         LESSGREATER,
+	token.RANGE:      RANGE,
+	token.RANGE_INCL: RANGE,
 	token.PLUS:       SUM,
 	token.MINUS:      SUM,
 	token.SLASH:      PRODUCT,
 	token.ASTERISK:   PRODUCT,
 	token.LPAREN:     CALL,
 	token.LBRACKET:   INDEX,
+	token.OPTIONAL:   INDEX,
+	token.DOT:        INDEX,
 }
 
 type (
@@ -45,9 +56,16 @@ func (p *Parser) registerInfix(tokenType token.Type, fn infixParseFn) {
 	p.infixParseFns[tokenType] = fn
 }
 
+// nextToken Advances both tokens. Comments are collected aside since they
+// can appear between any two tokens.
 func (p *Parser) nextToken() {
 	p.currentToken = p.peekToken
 	p.peekToken = p.tokenizer.NextToken()
+
+	for p.peekToken.Type == token.COMMENT {
+		p.comments = append(p.comments, p.peekToken)
+		p.peekToken = p.tokenizer.NextToken()
+	}
 }
 
 func (p *Parser) checkCurrentTokenType(t token.Type) bool {
@@ -71,7 +89,7 @@ func (p *Parser) peekAndMove(t token.Type) bool {
 
 func (p *Parser) noPrefixParseFnError(t token.Type) {
 	msg := fmt.Sprintf("No prefix parse function found for type %s", t)
-	p.errors = append(p.errors, msg)
+	p.logError(p.currentToken, msg)
 }
 
 func (p *Parser) peekTokenPrecedence() int {
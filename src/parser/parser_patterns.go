@@ -0,0 +1,234 @@
+package parser
+
+import (
+	"fmt"
+
+	"github.com/aeremic/cgo/ast"
+	"github.com/aeremic/cgo/token"
+	"github.com/aeremic/cgo/value"
+)
+
+// parsePattern Parses binding pattern starting at current token
+func (p *Parser) parsePattern() ast.Pattern {
+	if p.matchPatterns {
+		if pattern, ok := p.parseMatchPattern(); ok {
+			return pattern
+		}
+	}
+
+	switch p.currentToken.Type {
+	case token.IDENT:
+		return &ast.Identifier{Token: p.currentToken, Value: p.currentToken.Literal}
+	case token.LBRACKET:
+		return p.parseArrayPattern()
+	case token.LBRACE:
+		return p.parseDictPattern()
+	default:
+		msg := fmt.Sprintf("Expected pattern. Got %s instead", p.currentToken.Type)
+		p.logError(p.currentToken, msg)
+
+		return nil
+	}
+}
+
+// parsePatternElement Parses pattern optionally followed by default value
+func (p *Parser) parsePatternElement() ast.Pattern {
+	pattern := p.parsePattern()
+	if pattern == nil {
+		return nil
+	}
+
+	return p.parseDefault(pattern)
+}
+
+// parseDefault Wraps pattern into default pattern when = follows it
+func (p *Parser) parseDefault(pattern ast.Pattern) ast.Pattern {
+	if !p.checkPeekTokenType(token.ASSIGN) {
+		return pattern
+	}
+
+	p.nextToken()
+	defaultPattern := &ast.DefaultPattern{Token: p.currentToken, Target: pattern}
+
+	p.nextToken()
+	defaultPattern.Default = p.parseExpression(ASSIGN)
+
+	return defaultPattern
+}
+
+// parseRestIdentifier Parses ...name, rest must be the last element
+func (p *Parser) parseRestIdentifier(end token.Type) *ast.Identifier {
+	if !p.peekAndMove(token.IDENT) {
+		return nil
+	}
+
+	identifier := &ast.Identifier{Token: p.currentToken, Value: p.currentToken.Literal}
+
+	if !p.checkPeekTokenType(end) {
+		msg := fmt.Sprintf("Rest element must be last. Got %s instead of %s", p.peekToken.Type, end)
+		p.logError(p.peekToken, msg)
+
+		return nil
+	}
+
+	return identifier
+}
+
+func (p *Parser) parseArrayPattern() ast.Pattern {
+	pattern := &ast.ArrayPattern{Token: p.currentToken, Elements: []ast.Pattern{}}
+
+	for !p.checkPeekTokenType(token.RBRACKET) {
+		p.nextToken()
+
+		if p.checkCurrentTokenType(token.ELLIPSIS) {
+			pattern.Rest = p.parseRestIdentifier(token.RBRACKET)
+			if pattern.Rest == nil {
+				return nil
+			}
+
+			break
+		}
+
+		element := p.parsePatternElement()
+		if element == nil {
+			return nil
+		}
+
+		pattern.Elements = append(pattern.Elements, element)
+
+		if !p.checkPeekTokenType(token.RBRACKET) && !p.peekAndMove(token.COMMA) {
+			return nil
+		}
+	}
+
+	if !p.peekAndMove(token.RBRACKET) {
+		return nil
+	}
+
+	return pattern
+}
+
+// parseDictPattern Parses {name, "key": pattern, name: pattern, ...rest}.
+// Shorthand entries bind the key to a variable of the same name.
+func (p *Parser) parseDictPattern() ast.Pattern {
+	pattern := &ast.DictPattern{Token: p.currentToken, Entries: []ast.DictPatternEntry{}}
+
+	for !p.checkPeekTokenType(token.RBRACE) {
+		p.nextToken()
+
+		if p.checkCurrentTokenType(token.ELLIPSIS) {
+			pattern.Rest = p.parseRestIdentifier(token.RBRACE)
+			if pattern.Rest == nil {
+				return nil
+			}
+
+			break
+		}
+
+		entry := ast.DictPatternEntry{
+			Key: &ast.StringLiteral{Token: p.currentToken, Value: p.currentToken.Literal},
+		}
+
+		switch {
+		case p.checkCurrentTokenType(token.IDENT) && !p.checkPeekTokenType(token.COLON):
+			entry.Value = p.parsePatternElement()
+		case p.checkCurrentTokenType(token.IDENT) || p.checkCurrentTokenType(token.STRING):
+			if !p.peekAndMove(token.COLON) {
+				return nil
+			}
+
+			p.nextToken()
+			entry.Value = p.parsePatternElement()
+		default:
+			msg := fmt.Sprintf("Expected dict pattern key. Got %s instead", p.currentToken.Type)
+			p.logError(p.currentToken, msg)
+
+			return nil
+		}
+
+		if entry.Value == nil {
+			return nil
+		}
+
+		pattern.Entries = append(pattern.Entries, entry)
+
+		if !p.checkPeekTokenType(token.RBRACE) && !p.peekAndMove(token.COMMA) {
+			return nil
+		}
+	}
+
+	if !p.peekAndMove(token.RBRACE) {
+		return nil
+	}
+
+	return pattern
+}
+
+// parseMatchPattern Parses patterns which are only allowed in match arms:
+// literals, wildcard and type patterns. Reports false for other patterns.
+func (p *Parser) parseMatchPattern() (ast.Pattern, bool) {
+	switch p.currentToken.Type {
+	case token.NULL:
+		if p.checkPeekTokenType(token.LPAREN) {
+			return p.parseTypePattern(), true
+		}
+
+		return p.parseLiteralPattern(), true
+	case token.INT, token.STRING, token.TRUE, token.FALSE:
+		return p.parseLiteralPattern(), true
+	case token.MINUS:
+		pattern := &ast.LiteralPattern{Token: p.currentToken}
+		if !p.checkPeekTokenType(token.INT) {
+			p.LogPeekError(token.INT)
+			return nil, true
+		}
+
+		pattern.Value = p.parsePrefixExpression()
+
+		return pattern, true
+	case token.IDENT:
+		if p.currentToken.Literal == "_" {
+			return &ast.WildcardPattern{Token: p.currentToken}, true
+		}
+
+		// Struct names are only told apart from bindings by their parentheses
+		_, ok := value.PatternTypes(p.currentToken.Literal)
+		if !ok && !p.checkPeekTokenType(token.LPAREN) {
+			return nil, false
+		}
+
+		return p.parseTypePattern(), true
+	default:
+		return nil, false
+	}
+}
+
+// parseLiteralPattern Parses literal starting at current token as pattern
+func (p *Parser) parseLiteralPattern() ast.Pattern {
+	pattern := &ast.LiteralPattern{Token: p.currentToken}
+	pattern.Value = p.prefixParseFns[p.currentToken.Type]()
+	if pattern.Value == nil {
+		return nil
+	}
+
+	return pattern
+}
+
+// parseTypePattern Parses type name at current token with optional binding
+// in parentheses e.g. int or Point(p)
+func (p *Parser) parseTypePattern() ast.Pattern {
+	pattern := &ast.TypePattern{Token: p.currentToken, Name: p.currentToken.Literal}
+	if !p.checkPeekTokenType(token.LPAREN) {
+		return pattern
+	}
+
+	p.nextToken()
+	p.nextToken()
+
+	pattern.Binding = p.parsePattern()
+	if pattern.Binding == nil || !p.peekAndMove(token.RPAREN) {
+		return nil
+	}
+
+	return pattern
+}
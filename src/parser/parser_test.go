@@ -498,6 +498,15 @@ func TestOperatorPrecedenceParsing(t *testing.T) {
 		{"add(a + b + c * d / f + g)", "add((((a + b) + ((c * d) / f)) + g))"},
 		{"a * [1, 2, 3, 4][b * c] * d", "((a * ([1, 2, 3, 4][(b * c)])) * d)"},
 		{"add(a * b[2], b[1], 2 * [1, 2][1])", "add((a * (b[2])), (b[1]), (2 * ([1, 2][1])))"},
+		{"a[0] = 1 + 2", "((a[0]) = (1 + 2))"},
+		{"a[0] = b[1] = c", "((a[0]) = ((b[1]) = c))"},
+		{"a[i == j] = x == y", "((a[(i == j)]) = (x == y))"},
+		{"a ?? b == c", "(a ?? (b == c))"},
+		{"a ?? b ?? c", "((a ?? b) ?? c)"},
+		{"x[0] = a ?? b", "((x[0]) = (a ?? b))"},
+		{"a?.[b]?.(c) ?? d", "((a?.[b])?.(c) ?? d)"},
+		{"a?.[1] + 2", "((a?.[1]) + 2)"},
+		{"null", "null"},
 	}
 
 	for _, test := range tests {
@@ -882,21 +891,28 @@ func TestParsingDictLiteralsStringKeys(t *testing.T) {
 			len(dict.Elements), 3)
 	}
 
-	expected := map[string]int64{
-		"one":   1,
-		"two":   2,
-		"three": 3,
+	expected := []struct {
+		key   string
+		value int64
+	}{
+		{"one", 1},
+		{"two", 2},
+		{"three", 3},
 	}
 
-	for key, value := range dict.Elements {
-		literal, ok := key.(*ast.StringLiteral)
+	for i, element := range dict.Elements {
+		literal, ok := element.Key.(*ast.StringLiteral)
 		if !ok {
-			t.Errorf("invalid element key. got %T", key)
+			t.Errorf("invalid element key. got %T", element.Key)
+			continue
 		}
 
-		expectedValue := expected[literal.String()]
+		if literal.String() != expected[i].key {
+			t.Errorf("invalid element key at %d. got %s instead of %s",
+				i, literal.String(), expected[i].key)
+		}
 
-		testIntegerLiteralExpression(t, value, expectedValue)
+		testIntegerLiteralExpression(t, element.Value, expected[i].value)
 	}
 }
 
@@ -927,3 +943,617 @@ func TestParsingEmptyDictLiteral(t *testing.T) {
 	}
 
 }
+
+func TestParsingAssignExpressions(t *testing.T) {
+	input := `dict["key"] = 5 * 2;`
+
+	program := setUpTest(t, input)
+
+	if len(program.Statements) != 1 {
+		t.Fatalf("program.Statements doesn't contain 1 statements. Got %d",
+			len(program.Statements))
+	}
+
+	statement := program.Statements[0].(*ast.ExpressionStatement)
+	assign, ok := statement.Expression.(*ast.AssignExpression)
+	if !ok {
+		t.Fatalf("statement.Expression is not AssignExpression. Got %T",
+			statement.Expression)
+	}
+
+	target, ok := assign.Target.(*ast.IndexExpression)
+	if !ok {
+		t.Fatalf("assign.Target is not IndexExpression. Got %T", assign.Target)
+	}
+
+	testIdentifier(t, target.Left, "dict")
+	testInfixExpression(t, assign.Value, 5, "*", 2)
+}
+
+func TestParsingInvalidAssignTarget(t *testing.T) {
+	inputs := []string{"x = 5;", "1 = 2;", "f() = 3;"}
+
+	for _, input := range inputs {
+		p := New(tokenizer.New(input))
+		p.ParseProgram()
+
+		if len(p.Errors()) == 0 {
+			t.Errorf("expected parse error for %q", input)
+		}
+	}
+}
+
+func TestImportStatements(t *testing.T) {
+	tests := []struct {
+		input         string
+		expectedPath  string
+		expectedAlias string
+		expectedNames []string
+		expected      string
+	}{
+		{`import "lib/math.cgo" as m;`, "lib/math.cgo", "m", nil, `import "lib/math.cgo" as m;`},
+		{`import "lib"`, "lib", "", nil, `import "lib";`},
+		{`from "lib" import a, b;`, "lib", "", []string{"a", "b"}, `from "lib" import a, b;`},
+	}
+
+	for _, test := range tests {
+		program := setUpTest(t, test.input)
+
+		if len(program.Statements) != 1 {
+			t.Fatalf("program.Statements doesn't contain 1 statement. Got %d",
+				len(program.Statements))
+		}
+
+		statement, ok := program.Statements[0].(*ast.ImportStatement)
+		if !ok {
+			t.Fatalf("statement is not ImportStatement. Got %T", program.Statements[0])
+		}
+
+		if statement.Path.Value != test.expectedPath {
+			t.Errorf("invalid path. Got %s instead of %s", statement.Path.Value, test.expectedPath)
+		}
+
+		if test.expectedAlias != "" {
+			testIdentifier(t, statement.Alias, test.expectedAlias)
+		} else if statement.Alias != nil {
+			t.Errorf("unexpected alias %s", statement.Alias)
+		}
+
+		if len(statement.Names) != len(test.expectedNames) {
+			t.Fatalf("invalid number of names. Got %d instead of %d",
+				len(statement.Names), len(test.expectedNames))
+		}
+
+		for i, name := range test.expectedNames {
+			testIdentifier(t, statement.Names[i], name)
+		}
+
+		if statement.String() != test.expected {
+			t.Errorf("invalid String(). Got %q instead of %q", statement.String(), test.expected)
+		}
+	}
+}
+
+func TestExportStatement(t *testing.T) {
+	program := setUpTest(t, "export let x = 5;")
+
+	statement, ok := program.Statements[0].(*ast.ExportStatement)
+	if !ok {
+		t.Fatalf("statement is not ExportStatement. Got %T", program.Statements[0])
+	}
+
+	testLetStatement(t, statement.Statement, "x")
+
+	p := New(tokenizer.New("fn() { export let x = 5; }"))
+	p.ParseProgram()
+	if len(p.Errors()) == 0 {
+		t.Errorf("expected error for nested export")
+	}
+}
+
+func TestLetStatementWithoutSemicolon(t *testing.T) {
+	program := setUpTest(t, "let x = 5\nlet y = x")
+
+	if len(program.Statements) != 2 {
+		t.Fatalf("program.Statements doesn't contain 2 statements. Got %d",
+			len(program.Statements))
+	}
+
+	testLetStatement(t, program.Statements[0], "x")
+	testLetStatement(t, program.Statements[1], "y")
+}
+
+func TestStatementsWithoutSemicolon(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{"let x = 5", []string{"let x = 5;"}},
+		{"let x = 5\nputs(x)\nlet y = 1;", []string{"let x = 5;", "puts(x)", "let y = 1;"}},
+		{"return 5\nx", []string{"return 5;", "x"}},
+		{"export let a = 1\nexport let b = a", []string{"export let a = 1;", "export let b = a;"}},
+	}
+
+	for _, test := range tests {
+		program := setUpTest(t, test.input)
+
+		statements := []string{}
+		for _, statement := range program.Statements {
+			statements = append(statements, statement.String())
+		}
+
+		if fmt.Sprint(statements) != fmt.Sprint(test.expected) {
+			t.Errorf("wrong statements of %q. got=%q, want=%q", test.input, statements, test.expected)
+		}
+	}
+}
+
+func TestTryExpression(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`try { x } catch (e) { y }`, "try x catch(e) y"},
+		{`try { x } finally { y }`, "try x finally y"},
+		{`try { x } catch (e) { y } finally { z }`, "try x catch(e) y finally z"},
+		{`throw x + 1;`, "throw (x + 1);"},
+	}
+
+	for _, test := range tests {
+		program := setUpTest(t, test.input)
+
+		if program.String() != test.expected {
+			t.Errorf("invalid program. Got %q instead of %q", program.String(), test.expected)
+		}
+	}
+
+	statement := setUpTest(t, `try { x } catch (err) { y }`).Statements[0].(*ast.ExpressionStatement)
+	try, ok := statement.Expression.(*ast.TryExpression)
+	if !ok {
+		t.Fatalf("expression is not TryExpression. Got %T", statement.Expression)
+	}
+
+	testIdentifier(t, try.CatchParam, "err")
+
+	p := New(tokenizer.New("try { x }"))
+	p.ParseProgram()
+	if len(p.Errors()) == 0 {
+		t.Errorf("expected error for try without catch or finally")
+	}
+}
+
+func TestInvalidOptionalChaining(t *testing.T) {
+	p := New(tokenizer.New("a?.1"))
+	p.ParseProgram()
+
+	if len(p.Errors()) == 0 {
+		t.Errorf("expected error for ?. not followed by [, ( or member name")
+	}
+}
+
+func TestDestructuringPatterns(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let [a, b] = x;", "let [a, b] = x;"},
+		{"let [a, ...rest] = x;", "let [a, ...rest] = x;"},
+		{"let [x = 0, [y, z]] = arr;", "let [x = 0, [y, z]] = arr;"},
+		{`let {name, age} = person;`, "let {name:name, age:age} = person;"},
+		{`let {name: n, "full name": f = "?", ...others} = p;`,
+			"let {name:n, full name:f = ?, ...others} = p;"},
+		{"let [{a}, {b: [c]}] = x;", "let [{a:a}, {b:[c]}] = x;"},
+		{"fn([a, b], {c}) { a }", "fn([a, b],{c:c})a"},
+	}
+
+	for _, test := range tests {
+		program := setUpTest(t, test.input)
+
+		if program.String() != test.expected {
+			t.Errorf("invalid program. Got %q instead of %q", program.String(), test.expected)
+		}
+	}
+
+	statement := setUpTest(t, "let [a, {b}] = x;").Statements[0].(*ast.LetStatement)
+	if statement.Name != nil {
+		t.Errorf("destructuring let should not have a Name. Got %s", statement.Name)
+	}
+
+	names := ast.PatternIdentifiers(statement.Pattern)
+	if len(names) != 2 || names[0].Value != "a" || names[1].Value != "b" {
+		t.Errorf("invalid pattern identifiers. Got %v", names)
+	}
+
+	invalid := []string{
+		"let [a, ...rest, b] = x;",
+		"let [1] = x;",
+		"let {1: a} = x;",
+		"fn(1) { 1 }",
+	}
+
+	for _, input := range invalid {
+		p := New(tokenizer.New(input))
+		p.ParseProgram()
+
+		if len(p.Errors()) == 0 {
+			t.Errorf("expected parse error for %q", input)
+		}
+	}
+}
+
+func TestParametersAndArguments(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"fn(x, y = 10) { x }", "fn(x,y = 10)x"},
+		{"fn(first, ...rest) { rest }", "fn(first,...rest)rest"},
+		{"fn(...rest) { rest }", "fn(...rest)rest"},
+		{"f(...args)", "f(...args)"},
+		{"f(1, ...a, ...b)", "f(1, ...a, ...b)"},
+		{"f(y: 2, x: 1 + 1)", "f(y: 2, x: (1 + 1))"},
+		{"[0, ...xs]", "[0, ...xs]"},
+	}
+
+	for _, test := range tests {
+		program := setUpTest(t, test.input)
+
+		if program.String() != test.expected {
+			t.Errorf("invalid program. Got %q instead of %q", program.String(), test.expected)
+		}
+	}
+
+	statement := setUpTest(t, "fn(a, ...b) { a }").Statements[0].(*ast.ExpressionStatement)
+	function := statement.Expression.(*ast.FunctionLiteral)
+	if len(function.Parameters) != 1 {
+		t.Fatalf("invalid number of parameters. Got %d", len(function.Parameters))
+	}
+
+	testIdentifier(t, function.Rest, "b")
+
+	p := New(tokenizer.New("fn(...a, b) { a }"))
+	p.ParseProgram()
+	if len(p.Errors()) == 0 {
+		t.Errorf("expected error for parameter after rest parameter")
+	}
+}
+
+func TestMatchExpression(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`match (x) { 1 => "one", _ => "other" }`, `match (x) { 1 => one, _ => other }`},
+		{`match (x) { -1 => a, null => b, true => c }`, `match (x) { (-1) => a, null => b, true => c }`},
+		{`match (x) { int(n) if n > 0 => n, int => 0, }`, `match (x) { int(n) if (n > 0) => n, int => 0 }`},
+		{`match (x) { [a, ...rest] => a, {"kind": "circle", r} => r }`,
+			`match (x) { [a, ...rest] => a, {kind:circle, r:r} => r }`},
+		{`match (x) { [1, _, string(s)] => s }`, `match (x) { [1, _, string(s)] => s }`},
+		{`match (x) { y => y + 1 }`, `match (x) { y => (y + 1) }`},
+		{`match (x) { null(n) => n, Point(p) => p, Point(_) => 0 }`,
+			`match (x) { null(n) => n, Point(p) => p, Point(_) => 0 }`},
+	}
+
+	for _, test := range tests {
+		program := setUpTest(t, test.input)
+
+		if program.String() != test.expected {
+			t.Errorf("invalid program. Got %q instead of %q", program.String(), test.expected)
+		}
+	}
+
+	statement := setUpTest(t, `match (x) { int(n) if n > 0 => n }`).Statements[0].(*ast.ExpressionStatement)
+	match, ok := statement.Expression.(*ast.MatchExpression)
+	if !ok {
+		t.Fatalf("expression is not MatchExpression. Got %T", statement.Expression)
+	}
+
+	if len(match.Arms) != 1 {
+		t.Fatalf("invalid number of arms. Got %d", len(match.Arms))
+	}
+
+	typePattern, ok := match.Arms[0].Pattern.(*ast.TypePattern)
+	if !ok {
+		t.Fatalf("pattern is not TypePattern. Got %T", match.Arms[0].Pattern)
+	}
+
+	testIdentifier(t, typePattern.Binding, "n")
+
+	if !testInfixExpression(t, match.Arms[0].Guard, "n", ">", 0) {
+		return
+	}
+
+	for _, input := range []string{"match (x) { }", "match (x) { 1 }", "let [1] = x;"} {
+		p := New(tokenizer.New(input))
+		p.ParseProgram()
+		if len(p.Errors()) == 0 {
+			t.Errorf("expected parse error for %q", input)
+		}
+	}
+}
+
+func TestSliceAndRangeExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"a[1:3]", "(a[1:3])"},
+		{"a[:-1]", "(a[:(-1)])"},
+		{"a[2:]", "(a[2:])"},
+		{"a[:]", "(a[:])"},
+		{"a[::2]", "(a[::2])"},
+		{"a[1:b + 1:-1]", "(a[1:(b + 1):(-1)])"},
+		{"a?.[1:]", "(a?.[1:])"},
+		{"1..10", "(1 .. 10)"},
+		{"1..=n + 1", "(1 ..= (n + 1))"},
+		{"a..b < c..d", "((a .. b) < (c .. d))"},
+		{"a..b == c", "((a .. b) == c)"},
+	}
+
+	for _, test := range tests {
+		program := setUpTest(t, test.input)
+
+		if program.String() != test.expected {
+			t.Errorf("invalid program. Got %q instead of %q", program.String(), test.expected)
+		}
+	}
+}
+
+func TestPipeExpression(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"xs |> f", "(xs |> f)"},
+		{"xs |> map(f) |> filter(g) |> sum()", "(((xs |> map(f)) |> filter(g)) |> sum())"},
+		{"a + b |> f(c)", "((a + b) |> f(c))"},
+		{"a |> f() == b", "(a |> (f() == b))"},
+		{"a ?? b |> f()", "((a ?? b) |> f())"},
+		{"a[0] = b |> f()", "((a[0]) = (b |> f()))"},
+		{"1..n |> to_array()", "((1 .. n) |> to_array())"},
+	}
+
+	for _, test := range tests {
+		program := setUpTest(t, test.input)
+
+		if program.String() != test.expected {
+			t.Errorf("invalid program. Got %q instead of %q", program.String(), test.expected)
+		}
+	}
+}
+
+func TestMemberExpression(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"a.b", "(a.b)"},
+		{"a.b.c", "((a.b).c)"},
+		{"a.b(1)", "(a.b)(1)"},
+		{"a?.b.c", "((a?.b).c)"},
+		{"a.b[0] + c.d", "(((a.b)[0]) + (c.d))"},
+		{"-a.b", "(-(a.b))"},
+		{"a.b = 1 + 2", "((a.b) = (1 + 2))"},
+		{"xs |> ys.map(f)", "(xs |> (ys.map)(f))"},
+	}
+
+	for _, test := range tests {
+		program := setUpTest(t, test.input)
+
+		if program.String() != test.expected {
+			t.Errorf("invalid program. Got %q instead of %q", program.String(), test.expected)
+		}
+	}
+
+	for _, input := range []string{"a.", "a.1"} {
+		p := New(tokenizer.New(input))
+		p.ParseProgram()
+		if len(p.Errors()) == 0 {
+			t.Errorf("expected parse error for %q", input)
+		}
+	}
+}
+
+func TestStructStatement(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"struct Point { x, y }", "struct Point { x, y }"},
+		{"struct Point { x, y = 1 + 1, }", "struct Point { x, y = (1 + 1) }"},
+		{"struct Empty {};", "struct Empty {  }"},
+		{"struct P { x fn get(self) { self.x } fn set(self, v) { v } }",
+			"struct P { x, fn get(self)(self.x), fn set(self,v)v }"},
+	}
+
+	for _, test := range tests {
+		program := setUpTest(t, test.input)
+
+		if program.String() != test.expected {
+			t.Errorf("invalid program. Got %q instead of %q", program.String(), test.expected)
+		}
+	}
+
+	statement, ok := setUpTest(t, "struct P { x, fn get(self) { self.x } }").Statements[0].(*ast.StructStatement)
+	if !ok {
+		t.Fatalf("statement is not StructStatement")
+	}
+
+	testIdentifier(t, statement.Name, "P")
+
+	if len(statement.Fields) != 1 || len(statement.Methods) != 1 {
+		t.Fatalf("invalid struct members. Got %d fields and %d methods",
+			len(statement.Fields), len(statement.Methods))
+	}
+
+	testIdentifier(t, statement.Methods[0].Name, "get")
+
+	invalid := []string{
+		"struct { x }",
+		"struct P { x y }",
+		"struct P { x, x }",
+		"struct P { x, fn x(self) { 1 } }",
+		"struct P { fn f(self) { 1 } y }",
+		"struct P { 1 }",
+	}
+
+	for _, input := range invalid {
+		p := New(tokenizer.New(input))
+		p.ParseProgram()
+		if len(p.Errors()) == 0 {
+			t.Errorf("expected parse error for %q", input)
+		}
+	}
+}
+
+func TestMacroLiteral(t *testing.T) {
+	program := setUpTest(t, "macro(x, y) { x + y; }")
+
+	statement := program.Statements[0].(*ast.ExpressionStatement)
+	macro, ok := statement.Expression.(*ast.MacroLiteral)
+	if !ok {
+		t.Fatalf("expression is not MacroLiteral. Got %T", statement.Expression)
+	}
+
+	if len(macro.Parameters) != 2 {
+		t.Fatalf("invalid number of macro parameters. Got %d", len(macro.Parameters))
+	}
+
+	testIdentifier(t, macro.Parameters[0], "x")
+	testIdentifier(t, macro.Parameters[1], "y")
+
+	if macro.String() != "macro(x,y)(x + y)" {
+		t.Errorf("invalid macro. Got %q", macro.String())
+	}
+
+	for _, input := range []string{"macro([a]) { a }", "macro(x = 1) { x }", "macro(...xs) { xs }"} {
+		p := New(tokenizer.New(input))
+		p.ParseProgram()
+		if len(p.Errors()) == 0 {
+			t.Errorf("expected parse error for %q", input)
+		}
+	}
+}
+
+func TestComments(t *testing.T) {
+	input := `// leading
+let x = 5; // five
+let f = fn(a) {
+	// inside
+	a * 2
+};`
+
+	p := New(tokenizer.New(input))
+	program := p.ParseProgram()
+	checkParseErrors(t, p)
+
+	if program.String() != "let x = 5;let f = fn(a)(a * 2);" {
+		t.Errorf("comments changed program. Got %q", program.String())
+	}
+
+	expected := []struct {
+		literal string
+		line    int
+		column  int
+	}{
+		{"// leading", 1, 1},
+		{"// five", 2, 12},
+		{"// inside", 4, 2},
+	}
+
+	if len(program.Comments) != len(expected) {
+		t.Fatalf("wrong number of comments. Got %d", len(program.Comments))
+	}
+
+	for i, comment := range expected {
+		got := program.Comments[i]
+		if got.Literal != comment.literal || got.Line != comment.line || got.Column != comment.column {
+			t.Errorf("comments[%d] wrong. Got %q at %d:%d", i, got.Literal, got.Line, got.Column)
+		}
+	}
+
+	block := program.Statements[1].(*ast.LetStatement).Value.(*ast.FunctionLiteral).Body
+	if block.Rbrace.Line != 6 || block.Rbrace.Column != 1 {
+		t.Errorf("wrong closing brace position %d:%d", block.Rbrace.Line, block.Rbrace.Column)
+	}
+}
+
+func TestTypeAnnotations(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let x: int = 5", "let x: int = 5;"},
+		{"let xs: [string] = []", "let xs: [string] = [];"},
+		{"let d: {string: [int]} = {}", "let d: {string: [int]} = {};"},
+		{"let f: fn(int, any) -> null = g", "let f: fn(int, any) -> null = g;"},
+		{"let f: fn() = g", "let f: fn() = g;"},
+		{"fn(a: int, b) -> bool { a }", "fn(a: int,b) -> bool a"},
+		{"fn(a: int = 1, ...rest) { a }", "fn(a: int = 1,...rest)a"},
+		{"fn(f: fn(int) -> int) -> fn() -> int { f }", "fn(f: fn(int) -> int) -> fn() -> int f"},
+		{"struct P { x, fn m(self, y: int) -> int { y } }", "struct P { x, fn m(self,y: int) -> int y }"},
+	}
+
+	for _, test := range tests {
+		program := setUpTest(t, test.input)
+
+		if program.String() != test.expected {
+			t.Errorf("invalid program. Got %q instead of %q", program.String(), test.expected)
+		}
+	}
+
+	statement := setUpTest(t, "fn(a, b: [int]) -> int { a }").Statements[0].(*ast.ExpressionStatement)
+	function := statement.Expression.(*ast.FunctionLiteral)
+	if function.ParameterType(0) != nil {
+		t.Errorf("unannotated parameter has type %s", function.ParameterType(0))
+	}
+
+	arrayType, ok := function.ParameterType(1).(*ast.ArrayType)
+	if !ok || arrayType.Element.(*ast.NamedType).Name != "int" {
+		t.Errorf("wrong parameter type. Got %v", function.ParameterType(1))
+	}
+
+	if function.ReturnType.String() != "int" {
+		t.Errorf("wrong return type. Got %v", function.ReturnType)
+	}
+
+	for _, input := range []string{"let x: = 1", "let [a]: int = b", "fn(a: 1) { a }", "fn() -> { 1 }",
+		"let x: [int = 1", "let d: {string} = 1", "macro(a: int) { a }"} {
+		p := New(tokenizer.New(input))
+		p.ParseProgram()
+		if len(p.Errors()) == 0 {
+			t.Errorf("expected parse error for %q", input)
+		}
+	}
+}
+
+func TestErrorPositions(t *testing.T) {
+	tests := []struct {
+		input          string
+		expectedLine   int
+		expectedColumn int
+	}{
+		{"let x = 1;\nlet = 2", 2, 5},
+		{"let f = fn(a) {\n  a +\n}", 3, 1},
+		{"struct P { x,\n  x }", 2, 3},
+		{"export let x = 1;\nif (true) { export let y = 2 }", 2, 13},
+		{"a?.1", 1, 4},
+	}
+
+	for _, test := range tests {
+		p := New(tokenizer.New(test.input))
+		p.ParseProgram()
+
+		if len(p.ErrorPositions()) == 0 || len(p.ErrorPositions()) != len(p.Errors()) {
+			t.Errorf("wrong number of error positions for %q. Got %d for %d errors", test.input,
+				len(p.ErrorPositions()), len(p.Errors()))
+			continue
+		}
+
+		first := p.ErrorPositions()[0]
+		if first.Line != test.expectedLine || first.Column != test.expectedColumn {
+			t.Errorf("wrong position of %q in %q. Got %d:%d instead of %d:%d", p.Errors()[0], test.input,
+				first.Line, first.Column, test.expectedLine, test.expectedColumn)
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package parser
+
+import (
+	"fmt"
+
+	"github.com/aeremic/cgo/ast"
+	"github.com/aeremic/cgo/token"
+)
+
+// parseTypeAnnotation Parses `: type` following a name when present
+func (p *Parser) parseTypeAnnotation() (ast.TypeExpression, bool) {
+	if !p.checkPeekTokenType(token.COLON) {
+		return nil, true
+	}
+
+	p.nextToken()
+	p.nextToken()
+
+	annotation := p.parseTypeExpression()
+
+	return annotation, annotation != nil
+}
+
+// parseTypeExpression Parses type starting at current token: a name like
+// int, [element], {key: value} or fn(parameters) -> result
+func (p *Parser) parseTypeExpression() ast.TypeExpression {
+	switch p.currentToken.Type {
+	case token.IDENT, token.NULL:
+		return &ast.NamedType{Token: p.currentToken, Name: p.currentToken.Literal}
+	case token.LBRACKET:
+		return p.parseArrayType()
+	case token.LBRACE:
+		return p.parseDictType()
+	case token.FUNC:
+		return p.parseFunctionType()
+	default:
+		msg := fmt.Sprintf("Expected type. Got %s instead", p.currentToken.Type)
+		p.logError(p.currentToken, msg)
+
+		return nil
+	}
+}
+
+func (p *Parser) parseArrayType() ast.TypeExpression {
+	arrayType := &ast.ArrayType{Token: p.currentToken}
+
+	p.nextToken()
+
+	arrayType.Element = p.parseTypeExpression()
+	if arrayType.Element == nil || !p.peekAndMove(token.RBRACKET) {
+		return nil
+	}
+
+	return arrayType
+}
+
+func (p *Parser) parseDictType() ast.TypeExpression {
+	dictType := &ast.DictType{Token: p.currentToken}
+
+	p.nextToken()
+
+	dictType.Key = p.parseTypeExpression()
+	if dictType.Key == nil || !p.peekAndMove(token.COLON) {
+		return nil
+	}
+
+	p.nextToken()
+
+	dictType.Value = p.parseTypeExpression()
+	if dictType.Value == nil || !p.peekAndMove(token.RBRACE) {
+		return nil
+	}
+
+	return dictType
+}
+
+func (p *Parser) parseFunctionType() ast.TypeExpression {
+	functionType := &ast.FunctionType{Token: p.currentToken, Parameters: []ast.TypeExpression{}}
+
+	if !p.peekAndMove(token.LPAREN) {
+		return nil
+	}
+
+	for !p.checkPeekTokenType(token.RPAREN) {
+		if len(functionType.Parameters) > 0 && !p.peekAndMove(token.COMMA) {
+			return nil
+		}
+
+		p.nextToken()
+
+		parameter := p.parseTypeExpression()
+		if parameter == nil {
+			return nil
+		}
+
+		functionType.Parameters = append(functionType.Parameters, parameter)
+	}
+
+	p.nextToken()
+
+	returnType, ok := p.parseReturnType()
+	if !ok {
+		return nil
+	}
+
+	functionType.Return = returnType
+
+	return functionType
+}
+
+// parseReturnType Parses `-> type` following parameter list when present
+func (p *Parser) parseReturnType() (ast.TypeExpression, bool) {
+	if !p.checkPeekTokenType(token.RETURNS) {
+		return nil, true
+	}
+
+	p.nextToken()
+	p.nextToken()
+
+	returnType := p.parseTypeExpression()
+
+	return returnType, returnType != nil
+}
@@ -0,0 +1,188 @@
+package profile
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"strings"
+)
+
+// Field numbers of messages in pprof profile.proto
+const (
+	profileSampleType        = 1
+	profileSample            = 2
+	profileLocation          = 4
+	profileFunction          = 5
+	profileStringTable       = 6
+	profileTimeNanos         = 9
+	profileDurationNanos     = 10
+	profileDefaultSampleType = 14
+
+	valueTypeType = 1
+	valueTypeUnit = 2
+
+	sampleLocationID = 1
+	sampleValue      = 2
+
+	locationID   = 1
+	locationLine = 4
+
+	lineFunctionID = 1
+	lineLine       = 2
+
+	functionID         = 1
+	functionName       = 2
+	functionSystemName = 3
+	functionFilename   = 4
+	functionStartLine  = 5
+)
+
+// Protobuf wire types
+const (
+	wireVarint = 0
+	wireBytes  = 2
+)
+
+// encoder Writes protobuf fields of a single message
+type encoder struct {
+	bytes.Buffer
+}
+
+func (e *encoder) varint(v uint64) {
+	for v >= 0x80 {
+		e.WriteByte(byte(v) | 0x80)
+		v >>= 7
+	}
+
+	e.WriteByte(byte(v))
+}
+
+func (e *encoder) key(field int, wireType int) {
+	e.varint(uint64(field)<<3 | uint64(wireType))
+}
+
+// integer Writes integer field, zero values are left out as protobuf defaults
+func (e *encoder) integer(field int, v int64) {
+	if v == 0 {
+		return
+	}
+
+	e.key(field, wireVarint)
+	e.varint(uint64(v))
+}
+
+func (e *encoder) bytes(field int, b []byte) {
+	e.key(field, wireBytes)
+	e.varint(uint64(len(b)))
+	e.Write(b)
+}
+
+func (e *encoder) message(field int, m *encoder) {
+	e.bytes(field, m.Bytes())
+}
+
+// packed Writes repeated integer field in packed encoding
+func (e *encoder) packed(field int, values []int64) {
+	var packed encoder
+	for _, v := range values {
+		packed.varint(uint64(v))
+	}
+
+	e.bytes(field, packed.Bytes())
+}
+
+// stringTable Strings of a profile referenced by index, the first one is empty
+type stringTable struct {
+	strings []string
+	indexes map[string]int64
+}
+
+func newStringTable() *stringTable {
+	return &stringTable{strings: []string{""}, indexes: map[string]int64{"": 0}}
+}
+
+func (t *stringTable) index(s string) int64 {
+	index, ok := t.indexes[s]
+	if !ok {
+		index = int64(len(t.strings))
+		t.strings = append(t.strings, s)
+		t.indexes[s] = index
+	}
+
+	return index
+}
+
+// WritePprof Writes gzipped profile in the protobuf format read by `go tool
+// pprof`. Samples have call count and time values, each function gets
+// a location at its definition line.
+func (p *Profile) WritePprof(w io.Writer) error {
+	table := newStringTable()
+	var out encoder
+
+	for _, sampleType := range [][2]string{{"calls", "count"}, {"time", "nanoseconds"}} {
+		var valueType encoder
+		valueType.integer(valueTypeType, table.index(sampleType[0]))
+		valueType.integer(valueTypeUnit, table.index(sampleType[1]))
+		out.message(profileSampleType, &valueType)
+	}
+
+	for _, sample := range p.Samples {
+		locations := make([]int64, len(sample.Stack))
+		for i, index := range sample.Stack {
+			locations[i] = int64(index + 1)
+		}
+
+		var s encoder
+		s.packed(sampleLocationID, locations)
+		s.packed(sampleValue, []int64{sample.Calls, int64(sample.Time)})
+		out.message(profileSample, &s)
+	}
+
+	// Functions and their locations share IDs, one based
+	for i, function := range p.Functions {
+		id := int64(i + 1)
+
+		var line encoder
+		line.integer(lineFunctionID, id)
+		line.integer(lineLine, int64(function.Line))
+
+		var location encoder
+		location.integer(locationID, id)
+		location.message(locationLine, &line)
+		out.message(profileLocation, &location)
+
+		file := function.File
+		if function.Builtin {
+			file = "builtin"
+		}
+
+		// pprof drops text in angle brackets from names as C++ template arguments
+		var f encoder
+		f.integer(functionID, id)
+		f.integer(functionName, table.index(strings.Trim(function.Name, "<>")))
+		f.integer(functionSystemName, table.index(function.Name))
+		f.integer(functionFilename, table.index(file))
+		f.integer(functionStartLine, int64(function.Line))
+		out.message(profileFunction, &f)
+	}
+
+	defaultSampleType := table.index("time")
+
+	for _, s := range table.strings {
+		out.bytes(profileStringTable, []byte(s))
+	}
+
+	if !p.Start.IsZero() {
+		out.integer(profileTimeNanos, p.Start.UnixNano())
+	}
+
+	out.integer(profileDurationNanos, int64(p.Duration))
+	out.integer(profileDefaultSampleType, defaultSampleType)
+
+	zw := gzip.NewWriter(w)
+	if _, err := zw.Write(out.Bytes()); err != nil {
+		return err
+	}
+
+	return zw.Close()
+}
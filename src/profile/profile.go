@@ -0,0 +1,138 @@
+package profile
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// MAIN Name of the function standing for top level code of the profiled file
+const MAIN = "<main>"
+
+// Function Profiled user function, builtin or top level code. User functions
+// are told apart by their definition position, so closures made by the same
+// literal share a function.
+type Function struct {
+	Name    string
+	File    string // Empty for builtins
+	Line    int
+	Column  int
+	Builtin bool
+}
+
+func (f Function) String() string {
+	if f.Builtin {
+		return f.Name + " (builtin)"
+	}
+
+	if f.File == "" {
+		return f.Name
+	}
+
+	return fmt.Sprintf("%s (%s:%d)", f.Name, filepath.Base(f.File), f.Line)
+}
+
+// Sample Calls that ended with the same stack and time spent in them,
+// excluding time of calls they made
+type Sample struct {
+	Stack []int // Indexes of Functions, callee first
+	Calls int64
+	Time  time.Duration
+}
+
+// Profile Calls recorded while evaluating a program
+type Profile struct {
+	Functions []Function
+	Samples   []*Sample
+	Start     time.Time
+	Duration  time.Duration
+}
+
+// Entry Totals of a function over all samples. Flat time is spent in the
+// function itself, cumulative time includes calls it made.
+type Entry struct {
+	Function   Function
+	Calls      int64
+	Flat       time.Duration
+	Cumulative time.Duration
+}
+
+// Entries Returns totals of functions ordered by flat time, then by
+// cumulative time and name
+func (p *Profile) Entries() []Entry {
+	entries := make([]Entry, len(p.Functions))
+	for i, function := range p.Functions {
+		entries[i].Function = function
+	}
+
+	for _, sample := range p.Samples {
+		leaf := &entries[sample.Stack[0]]
+		leaf.Calls += sample.Calls
+		leaf.Flat += sample.Time
+
+		// Recursive functions appear in the stack more than once but count once
+		seen := map[int]bool{}
+		for _, index := range sample.Stack {
+			if !seen[index] {
+				seen[index] = true
+				entries[index].Cumulative += sample.Time
+			}
+		}
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		if entries[i].Flat != entries[j].Flat {
+			return entries[i].Flat > entries[j].Flat
+		}
+
+		if entries[i].Cumulative != entries[j].Cumulative {
+			return entries[i].Cumulative > entries[j].Cumulative
+		}
+
+		return entries[i].Function.String() < entries[j].Function.String()
+	})
+
+	return entries
+}
+
+// WriteReport Writes table of n functions with the most flat time, all
+// when n isn't positive
+func (p *Profile) WriteReport(w io.Writer, n int) error {
+	entries := p.Entries()
+	if n > 0 && n < len(entries) {
+		entries = entries[:n]
+	}
+
+	calls := int64(0)
+	for _, sample := range p.Samples {
+		calls += sample.Calls
+	}
+
+	if _, err := fmt.Fprintf(w, "Duration: %s, Calls: %d\n", p.Duration, calls); err != nil {
+		return err
+	}
+
+	if _, err := fmt.Fprintf(w, "%12s %7s %12s %7s %8s  %s\n", "flat", "flat%", "cum", "cum%", "calls", "function"); err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		_, err := fmt.Fprintf(w, "%12s %6.2f%% %12s %6.2f%% %8d  %s\n",
+			entry.Flat, p.percent(entry.Flat), entry.Cumulative, p.percent(entry.Cumulative), entry.Calls, entry.Function)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (p *Profile) percent(d time.Duration) float64 {
+	if p.Duration <= 0 {
+		return 0
+	}
+
+	return float64(d) / float64(p.Duration) * 100
+}
@@ -0,0 +1,220 @@
+package profile
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+// recursive Profile of main calling fib, which calls itself and len
+func recursive() *Profile {
+	return &Profile{
+		Functions: []Function{
+			{Name: MAIN},
+			{Name: "fib", File: "/src/main.cgo", Line: 1, Column: 17},
+			{Name: "len", Builtin: true},
+		},
+		Samples: []*Sample{
+			{Stack: []int{1, 1, 0}, Calls: 2, Time: 4 * time.Millisecond},
+			{Stack: []int{2, 1, 0}, Calls: 1, Time: 1 * time.Millisecond},
+			{Stack: []int{1, 0}, Calls: 1, Time: 3 * time.Millisecond},
+			{Stack: []int{0}, Calls: 1, Time: 2 * time.Millisecond},
+		},
+		Start:    time.Unix(1700000000, 0),
+		Duration: 10 * time.Millisecond,
+	}
+}
+
+func TestEntries(t *testing.T) {
+	expected := []Entry{
+		{Function{Name: "fib", File: "/src/main.cgo", Line: 1, Column: 17}, 3, 7 * time.Millisecond, 8 * time.Millisecond},
+		{Function{Name: MAIN}, 1, 2 * time.Millisecond, 10 * time.Millisecond},
+		{Function{Name: "len", Builtin: true}, 1, 1 * time.Millisecond, 1 * time.Millisecond},
+	}
+
+	entries := recursive().Entries()
+	if len(entries) != len(expected) {
+		t.Fatalf("wrong number of entries. got=%d, want=%d", len(entries), len(expected))
+	}
+
+	for i, entry := range entries {
+		if entry != expected[i] {
+			t.Errorf("entries[%d] wrong. got=%+v, want=%+v", i, entry, expected[i])
+		}
+	}
+}
+
+func TestWriteReport(t *testing.T) {
+	tests := []struct {
+		top      int
+		expected []string
+	}{
+		{
+			2,
+			[]string{
+				"Duration: 10ms, Calls: 5",
+				"        flat   flat%          cum    cum%    calls  function",
+				"         7ms  70.00%          8ms  80.00%        3  fib (main.cgo:1)",
+				"         2ms  20.00%         10ms 100.00%        1  <main>",
+			},
+		},
+		{
+			0,
+			[]string{
+				"Duration: 10ms, Calls: 5",
+				"        flat   flat%          cum    cum%    calls  function",
+				"         7ms  70.00%          8ms  80.00%        3  fib (main.cgo:1)",
+				"         2ms  20.00%         10ms 100.00%        1  <main>",
+				"         1ms  10.00%          1ms  10.00%        1  len (builtin)",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		var out bytes.Buffer
+		if err := recursive().WriteReport(&out, test.top); err != nil {
+			t.Fatalf("report failed: %s", err)
+		}
+
+		expected := strings.Join(test.expected, "\n") + "\n"
+		if out.String() != expected {
+			t.Errorf("wrong report of top %d.\ngot:\n%s\nwant:\n%s", test.top, out.String(), expected)
+		}
+	}
+}
+
+// field Decoded protobuf field, varints have no data
+type field struct {
+	number int
+	value  uint64
+	data   []byte
+}
+
+func readVarint(t *testing.T, data []byte) (uint64, []byte) {
+	v := uint64(0)
+	for shift := 0; len(data) > 0; shift += 7 {
+		b := data[0]
+		data = data[1:]
+		v |= uint64(b&0x7f) << shift
+		if b < 0x80 {
+			return v, data
+		}
+	}
+
+	t.Fatalf("truncated varint")
+	return 0, nil
+}
+
+// fields Decodes fields of protobuf message
+func fields(t *testing.T, data []byte) []field {
+	decoded := []field{}
+	for len(data) > 0 {
+		var key uint64
+		key, data = readVarint(t, data)
+
+		f := field{number: int(key >> 3)}
+		switch key & 7 {
+		case wireVarint:
+			f.value, data = readVarint(t, data)
+		case wireBytes:
+			var length uint64
+			length, data = readVarint(t, data)
+			f.data, data = data[:length], data[length:]
+		default:
+			t.Fatalf("unexpected wire type %d", key&7)
+		}
+
+		decoded = append(decoded, f)
+	}
+
+	return decoded
+}
+
+func TestWritePprof(t *testing.T) {
+	var out bytes.Buffer
+	if err := recursive().WritePprof(&out); err != nil {
+		t.Fatalf("writing pprof failed: %s", err)
+	}
+
+	reader, err := gzip.NewReader(&out)
+	if err != nil {
+		t.Fatalf("profile isn't gzipped: %s", err)
+	}
+
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatalf("unable to decompress profile: %s", err)
+	}
+
+	table := []string{}
+	var functions, samples [][]field
+	var defaultSampleType, duration uint64
+	for _, f := range fields(t, data) {
+		switch f.number {
+		case profileStringTable:
+			table = append(table, string(f.data))
+		case profileFunction:
+			functions = append(functions, fields(t, f.data))
+		case profileSample:
+			samples = append(samples, fields(t, f.data))
+		case profileDefaultSampleType:
+			defaultSampleType = f.value
+		case profileDurationNanos:
+			duration = f.value
+		}
+	}
+
+	expectedTable := "|calls|count|time|nanoseconds|main|<main>|fib|/src/main.cgo|len|builtin"
+	if strings.Join(table, "|") != expectedTable {
+		t.Errorf("wrong string table. got=%q, want=%q", strings.Join(table, "|"), expectedTable)
+	}
+
+	if table[defaultSampleType] != "time" {
+		t.Errorf("wrong default sample type. got=%q", table[defaultSampleType])
+	}
+
+	if duration != uint64(10*time.Millisecond) {
+		t.Errorf("wrong duration. got=%d", duration)
+	}
+
+	names := []string{}
+	for _, function := range functions {
+		for _, f := range function {
+			if f.number == functionName {
+				names = append(names, table[f.value])
+			}
+		}
+	}
+
+	if strings.Join(names, ",") != "main,fib,len" {
+		t.Errorf("wrong function names. got=%v", names)
+	}
+
+	// First sample: fib called from fib called from main, 2 calls taking 4ms
+	if len(samples) != 4 {
+		t.Fatalf("wrong number of samples. got=%d", len(samples))
+	}
+
+	for _, f := range samples[0] {
+		var values []uint64
+		for rest := f.data; len(rest) > 0; {
+			var v uint64
+			v, rest = readVarint(t, rest)
+			values = append(values, v)
+		}
+
+		switch f.number {
+		case sampleLocationID:
+			if len(values) != 3 || values[0] != 2 || values[1] != 2 || values[2] != 1 {
+				t.Errorf("wrong sample locations. got=%v", values)
+			}
+		case sampleValue:
+			if len(values) != 2 || values[0] != 2 || values[1] != uint64(4*time.Millisecond) {
+				t.Errorf("wrong sample values. got=%v", values)
+			}
+		}
+	}
+}
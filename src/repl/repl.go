@@ -17,6 +17,7 @@ func Start(in io.Reader, out io.Writer) {
 	scanner := bufio.NewScanner(in)
 
 	env := value.NewEnvironment()
+	macroEnv := value.NewEnvironment()
 
 	for {
 		_, err := fmt.Fprintf(out, PROMPT)
@@ -42,7 +43,16 @@ func Start(in io.Reader, out io.Writer) {
 			continue
 		}
 
-		evaluated := evaluator.Eval(program, env)
+		evaluator.DefineMacros(program, macroEnv)
+		expanded, errWrapper := evaluator.ExpandMacros(program, macroEnv)
+		if errWrapper != nil {
+			io.WriteString(out, errWrapper.Sprintf())
+			io.WriteString(out, "\n")
+
+			continue
+		}
+
+		evaluated := evaluator.Eval(expanded, env)
 		if evaluated != nil {
 			// io.WriteString(out, program.String())
 			io.WriteString(out, evaluated.Sprintf())
@@ -1,11 +1,14 @@
 package token
 
+import "sort"
+
 type Type string
 
 const (
 	// Special types
 	ILLEGAL = "ILLEGAL"
 	EOF     = "EOF"
+	COMMENT = "COMMENT" // Line comment starting with //
 
 	// Identifiers and literals
 	IDENT  = "IDENT"
@@ -23,11 +26,20 @@ const (
 	GT         = ">"
 	EQUALS     = "=="
 	NOT_EQUALS = "!="
+	COALESCE   = "??"
+	OPTIONAL   = "?."
+	ARROW      = "=>"
+	RETURNS    = "->" // Separates function parameters from return type annotation
+	PIPE       = "|>"
+	RANGE      = ".."
+	RANGE_INCL = "..="
 
 	// Delimiters
 	COMMA     = ","
 	SEMICOLON = ";"
 	COLON     = ":"
+	ELLIPSIS  = "..."
+	DOT       = "."
 
 	LPAREN   = "("
 	RPAREN   = ")"
@@ -37,28 +49,59 @@ const (
 	RBRACKET = "]"
 
 	// Keywords
-	FUNC   = "FUNC"
-	LET    = "LET"
-	TRUE   = "TRUE"
-	FALSE  = "FALSE"
-	IF     = "IF"
-	ELSE   = "ELSE"
-	RETURN = "RETURN"
+	FUNC    = "FUNC"
+	LET     = "LET"
+	TRUE    = "TRUE"
+	FALSE   = "FALSE"
+	NULL    = "NULL"
+	IF      = "IF"
+	ELSE    = "ELSE"
+	RETURN  = "RETURN"
+	IMPORT  = "IMPORT"
+	FROM    = "FROM"
+	AS      = "AS"
+	EXPORT  = "EXPORT"
+	THROW   = "THROW"
+	TRY     = "TRY"
+	CATCH   = "CATCH"
+	FINALLY = "FINALLY"
+	MATCH   = "MATCH"
+	STRUCT  = "STRUCT"
+	MACRO   = "MACRO"
 )
 
 type Token struct {
 	Type    Type
 	Literal string
+	Line    int // Line of the first character starting from 1, zero for synthesized tokens
+	Column  int // Byte offset of the first character within the line starting from 1
+}
+
+// Before Reports whether t starts before other in source
+func (t Token) Before(other Token) bool {
+	return t.Line < other.Line || t.Line == other.Line && t.Column < other.Column
 }
 
 var keywords = map[string]Type{
-	"fn":     FUNC,
-	"let":    LET,
-	"true":   TRUE,
-	"false":  FALSE,
-	"if":     IF,
-	"else":   ELSE,
-	"return": RETURN,
+	"fn":      FUNC,
+	"let":     LET,
+	"true":    TRUE,
+	"false":   FALSE,
+	"null":    NULL,
+	"if":      IF,
+	"else":    ELSE,
+	"return":  RETURN,
+	"import":  IMPORT,
+	"from":    FROM,
+	"as":      AS,
+	"export":  EXPORT,
+	"throw":   THROW,
+	"try":     TRY,
+	"catch":   CATCH,
+	"finally": FINALLY,
+	"match":   MATCH,
+	"struct":  STRUCT,
+	"macro":   MACRO,
 }
 
 func GetKeywordByIdent(ident string) Type {
@@ -68,3 +111,15 @@ func GetKeywordByIdent(ident string) Type {
 
 	return IDENT
 }
+
+// Keywords Returns reserved words in alphabetical order
+func Keywords() []string {
+	words := make([]string, 0, len(keywords))
+	for word := range keywords {
+		words = append(words, word)
+	}
+
+	sort.Strings(words)
+
+	return words
+}
@@ -1,19 +1,29 @@
 package tokenizer
 
 import (
+	"strings"
+
 	"github.com/aeremic/cgo/token"
 )
 
+// mutatingNames Names of builtins called with trailing bang since they
+// modify their argument in place
+var mutatingNames = map[string]bool{
+	"append": true,
+}
+
 type Tokenizer struct {
 	input        string
 	position     int  // Current position in input
 	nextPosition int  // Position used for peeking after current position
 	ch           byte // Current position character
+	line         int  // Line of current character starting from 1
+	lineStart    int  // Position of the first character of current line
 }
 
 // New Constructor
 func New(input string) *Tokenizer {
-	t := &Tokenizer{input: input}
+	t := &Tokenizer{input: input, line: 1}
 	t.nextChar()
 
 	return t
@@ -23,6 +33,11 @@ func New(input string) *Tokenizer {
 
 // Return next character and advance input position
 func (t *Tokenizer) nextChar() {
+	if t.ch == '\n' {
+		t.line += 1
+		t.lineStart = t.nextPosition
+	}
+
 	if t.nextPosition >= len(t.input) {
 		t.ch = 0 // Set to ASCII NUL if end is reached
 	} else {
@@ -39,6 +54,8 @@ func (t *Tokenizer) NextToken() token.Token {
 
 	t.skipWhitespaces()
 
+	line, column := t.line, t.position-t.lineStart+1
+
 	// Read and create current token which will be returned
 	switch t.ch {
 	case '=':
@@ -47,6 +64,10 @@ func (t *Tokenizer) NextToken() token.Token {
 			parsedToken = token.Token{Type: token.EQUALS,
 				Literal: string(t.ch) + string(peekedChar)}
 			t.nextChar()
+		} else if peekedChar == '>' {
+			parsedToken = token.Token{Type: token.ARROW,
+				Literal: string(t.ch) + string(peekedChar)}
+			t.nextChar()
 		} else {
 			parsedToken = token.Token{Type: token.ASSIGN, Literal: string(t.ch)}
 		}
@@ -71,7 +92,12 @@ func (t *Tokenizer) NextToken() token.Token {
 	case '+':
 		parsedToken = token.Token{Type: token.PLUS, Literal: string(t.ch)}
 	case '-':
-		parsedToken = token.Token{Type: token.MINUS, Literal: string(t.ch)}
+		if t.peekChar() == '>' {
+			parsedToken = token.Token{Type: token.RETURNS, Literal: "->"}
+			t.nextChar()
+		} else {
+			parsedToken = token.Token{Type: token.MINUS, Literal: string(t.ch)}
+		}
 	case '!':
 		peekedChar := t.peekChar()
 		if peekedChar == '=' {
@@ -81,7 +107,50 @@ func (t *Tokenizer) NextToken() token.Token {
 		} else {
 			parsedToken = token.Token{Type: token.BANG, Literal: string(t.ch)}
 		}
+	case '?':
+		peekedChar := t.peekChar()
+		if peekedChar == '?' {
+			parsedToken = token.Token{Type: token.COALESCE,
+				Literal: string(t.ch) + string(peekedChar)}
+			t.nextChar()
+		} else if peekedChar == '.' {
+			parsedToken = token.Token{Type: token.OPTIONAL,
+				Literal: string(t.ch) + string(peekedChar)}
+			t.nextChar()
+		} else {
+			parsedToken = token.Token{Type: token.ILLEGAL, Literal: string(t.ch)}
+		}
+	case '.':
+		if t.peekChar() == '.' && t.peekCharAt(2) == '.' {
+			t.nextChar()
+			t.nextChar()
+			parsedToken = token.Token{Type: token.ELLIPSIS, Literal: "..."}
+		} else if t.peekChar() == '.' && t.peekCharAt(2) == '=' {
+			t.nextChar()
+			t.nextChar()
+			parsedToken = token.Token{Type: token.RANGE_INCL, Literal: "..="}
+		} else if t.peekChar() == '.' {
+			t.nextChar()
+			parsedToken = token.Token{Type: token.RANGE, Literal: ".."}
+		} else {
+			parsedToken = token.Token{Type: token.DOT, Literal: string(t.ch)}
+		}
+	case '|':
+		if t.peekChar() == '>' {
+			t.nextChar()
+			parsedToken = token.Token{Type: token.PIPE, Literal: "|>"}
+		} else {
+			parsedToken = token.Token{Type: token.ILLEGAL, Literal: string(t.ch)}
+		}
 	case '/':
+		if t.peekChar() == '/' {
+			parsedToken = token.Token{Type: token.COMMENT, Literal: t.readComment(),
+				Line: line, Column: column}
+
+			// Early return since readComment stops at the line break
+			return parsedToken
+		}
+
 		parsedToken = token.Token{Type: token.SLASH, Literal: string(t.ch)}
 	case '*':
 		parsedToken = token.Token{Type: token.ASTERISK, Literal: string(t.ch)}
@@ -100,6 +169,7 @@ func (t *Tokenizer) NextToken() token.Token {
 		if isChLetter(t.ch) {
 			parsedToken.Literal = t.readIdentifier()
 			parsedToken.Type = token.GetKeywordByIdent(parsedToken.Literal)
+			parsedToken.Line, parsedToken.Column = line, column
 
 			// Early return since moving char
 			// since moving char pointer is not needed after readIdentifier call
@@ -108,6 +178,7 @@ func (t *Tokenizer) NextToken() token.Token {
 		} else if isChDigit(t.ch) {
 			parsedToken.Literal = t.readNumber()
 			parsedToken.Type = token.INT
+			parsedToken.Line, parsedToken.Column = line, column
 
 			// Early return since moving char
 			// since moving char pointer is not needed after readNumber call
@@ -118,6 +189,8 @@ func (t *Tokenizer) NextToken() token.Token {
 		parsedToken = token.Token{Type: token.ILLEGAL, Literal: string(t.ch)}
 	}
 
+	parsedToken.Line, parsedToken.Column = line, column
+
 	// Go to next char
 	t.nextChar()
 
@@ -138,12 +211,27 @@ func (t *Tokenizer) peekChar() byte {
 	}
 }
 
+// peekCharAt Returns character at given offset from current position
+func (t *Tokenizer) peekCharAt(offset int) byte {
+	if t.position+offset >= len(t.input) {
+		return 0
+	}
+
+	return t.input[t.position+offset]
+}
+
 func (t *Tokenizer) readIdentifier() string {
 	initialPosition := t.position
 	for isChLetter(t.ch) {
 		t.nextChar()
 	}
 
+	// Trailing bang is part of mutating builtin names e.g. append!
+	// unless it starts != operator
+	if t.ch == '!' && t.peekChar() != '=' && mutatingNames[t.input[initialPosition:t.position]] {
+		t.nextChar()
+	}
+
 	return t.input[initialPosition:t.position]
 }
 
@@ -156,6 +244,16 @@ func (t *Tokenizer) readNumber() string {
 	return t.input[initialPosition:t.position]
 }
 
+// readComment Reads comment up to the end of line without trailing whitespace
+func (t *Tokenizer) readComment() string {
+	initialPosition := t.position
+	for t.ch != '\n' && t.ch != 0 {
+		t.nextChar()
+	}
+
+	return strings.TrimRight(t.input[initialPosition:t.position], " \t\r")
+}
+
 func (t *Tokenizer) readString() string {
 	position := t.position + 1
 	for {
@@ -51,6 +51,15 @@ func TestRead(t *testing.T) {
 		[1, 2];
 
 		{"foo": "bar"};
+
+		append!(a, 1);
+		a!=b
+		a ?? b?.[c] ?
+		match (a) { _ => b }
+		1..2..=3
+		a |> b |
+		a.b
+		fn() -> a - b
 	`
 
 	expectedTokens := []struct {
@@ -152,6 +161,54 @@ func TestRead(t *testing.T) {
 		{token.STRING, "bar"},
 		{token.RBRACE, "}"},
 		{token.SEMICOLON, ";"},
+
+		{token.IDENT, "append!"},
+		{token.LPAREN, "("},
+		{token.IDENT, "a"},
+		{token.COMMA, ","},
+		{token.INT, "1"},
+		{token.RPAREN, ")"},
+		{token.SEMICOLON, ";"},
+		{token.IDENT, "a"},
+		{token.NOT_EQUALS, "!="},
+		{token.IDENT, "b"},
+		{token.IDENT, "a"},
+		{token.COALESCE, "??"},
+		{token.IDENT, "b"},
+		{token.OPTIONAL, "?."},
+		{token.LBRACKET, "["},
+		{token.IDENT, "c"},
+		{token.RBRACKET, "]"},
+		{token.ILLEGAL, "?"},
+		{token.MATCH, "match"},
+		{token.LPAREN, "("},
+		{token.IDENT, "a"},
+		{token.RPAREN, ")"},
+		{token.LBRACE, "{"},
+		{token.IDENT, "_"},
+		{token.ARROW, "=>"},
+		{token.IDENT, "b"},
+		{token.RBRACE, "}"},
+		{token.INT, "1"},
+		{token.RANGE, ".."},
+		{token.INT, "2"},
+		{token.RANGE_INCL, "..="},
+		{token.INT, "3"},
+		{token.IDENT, "a"},
+		{token.PIPE, "|>"},
+		{token.IDENT, "b"},
+		{token.ILLEGAL, "|"},
+		{token.IDENT, "a"},
+		{token.DOT, "."},
+		{token.IDENT, "b"},
+		{token.FUNC, "fn"},
+		{token.LPAREN, "("},
+		{token.RPAREN, ")"},
+		{token.RETURNS, "->"},
+		{token.IDENT, "a"},
+		{token.MINUS, "-"},
+		{token.IDENT, "b"},
+		{token.EOF, ""},
 	}
 
 	tokenizer := New(input)
@@ -169,3 +226,83 @@ func TestRead(t *testing.T) {
 		}
 	}
 }
+
+func TestPositionsAndComments(t *testing.T) {
+	input := "let x = 5; // five\n\n  x / 2 //\n// done"
+
+	expectedTokens := []token.Token{
+		{Type: token.LET, Literal: "let", Line: 1, Column: 1},
+		{Type: token.IDENT, Literal: "x", Line: 1, Column: 5},
+		{Type: token.ASSIGN, Literal: "=", Line: 1, Column: 7},
+		{Type: token.INT, Literal: "5", Line: 1, Column: 9},
+		{Type: token.SEMICOLON, Literal: ";", Line: 1, Column: 10},
+		{Type: token.COMMENT, Literal: "// five", Line: 1, Column: 12},
+		{Type: token.IDENT, Literal: "x", Line: 3, Column: 3},
+		{Type: token.SLASH, Literal: "/", Line: 3, Column: 5},
+		{Type: token.INT, Literal: "2", Line: 3, Column: 7},
+		{Type: token.COMMENT, Literal: "//", Line: 3, Column: 9},
+		{Type: token.COMMENT, Literal: "// done", Line: 4, Column: 1},
+		{Type: token.EOF, Literal: "", Line: 4, Column: 8},
+	}
+
+	tokenizer := New(input)
+	for i, expectedToken := range expectedTokens {
+		parsedToken := tokenizer.NextToken()
+
+		if parsedToken != expectedToken {
+			t.Fatalf("expectedTokens[%d] - Token is wrong. Expected %+v, received %+v",
+				i, expectedToken, parsedToken)
+		}
+	}
+}
+
+func TestBangSuffix(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []token.Token
+	}{
+		{"append!(a)", []token.Token{
+			{Type: token.IDENT, Literal: "append!"},
+			{Type: token.LPAREN, Literal: "("},
+			{Type: token.IDENT, Literal: "a"},
+			{Type: token.RPAREN, Literal: ")"},
+		}},
+		{"a.append!", []token.Token{
+			{Type: token.IDENT, Literal: "a"},
+			{Type: token.DOT, Literal: "."},
+			{Type: token.IDENT, Literal: "append!"},
+		}},
+		{"append!=b", []token.Token{
+			{Type: token.IDENT, Literal: "append"},
+			{Type: token.NOT_EQUALS, Literal: "!="},
+			{Type: token.IDENT, Literal: "b"},
+		}},
+		{"let x! = 1", []token.Token{
+			{Type: token.LET, Literal: "let"},
+			{Type: token.IDENT, Literal: "x"},
+			{Type: token.BANG, Literal: "!"},
+			{Type: token.ASSIGN, Literal: "="},
+			{Type: token.INT, Literal: "1"},
+		}},
+		{"appends!", []token.Token{
+			{Type: token.IDENT, Literal: "appends"},
+			{Type: token.BANG, Literal: "!"},
+		}},
+		{"!append", []token.Token{
+			{Type: token.BANG, Literal: "!"},
+			{Type: token.IDENT, Literal: "append"},
+		}},
+	}
+
+	for _, tt := range tests {
+		tokenizer := New(tt.input)
+		for i, expectedToken := range append(tt.expected, token.Token{Type: token.EOF}) {
+			parsedToken := tokenizer.NextToken()
+
+			if parsedToken.Type != expectedToken.Type || parsedToken.Literal != expectedToken.Literal {
+				t.Fatalf("%q tokens[%d] - Token is wrong. Expected %s %q, received %s %q",
+					tt.input, i, expectedToken.Type, expectedToken.Literal, parsedToken.Type, parsedToken.Literal)
+			}
+		}
+	}
+}
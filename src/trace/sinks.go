@@ -0,0 +1,170 @@
+package trace
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"time"
+)
+
+// writer Buffered output remembering the first write error, later writes are skipped
+type writer struct {
+	out *bufio.Writer
+	err error
+}
+
+func newWriter(w io.Writer) writer {
+	return writer{out: bufio.NewWriter(w)}
+}
+
+func (w *writer) write(b []byte) {
+	if w.err == nil {
+		_, w.err = w.out.Write(b)
+	}
+}
+
+func (w *writer) close() error {
+	if w.err == nil {
+		w.err = w.out.Flush()
+	}
+
+	return w.err
+}
+
+// details Fields of the event specific to its kind
+func details(event Event) map[string]interface{} {
+	fields := map[string]interface{}{}
+
+	switch event.Kind {
+	case ENTER:
+		fields["args"] = event.Arguments
+	case EXIT, BUILTIN:
+		if event.Kind == BUILTIN {
+			fields["args"] = event.Arguments
+		}
+
+		if event.Error != "" {
+			fields["error"] = event.Error
+			fields["error_kind"] = event.ErrorKind
+		} else {
+			fields["result"] = event.Result
+		}
+	case BIND:
+		fields["name"] = event.Name
+		fields["value"] = event.Value
+	case ERROR:
+		fields["error"] = event.Error
+		fields["error_kind"] = event.ErrorKind
+	}
+
+	if event.File != "" {
+		fields["file"] = event.File
+		fields["line"] = event.Line
+	}
+
+	return fields
+}
+
+// JSONLines Sink writing each event as a JSON object on its own line
+type JSONLines struct {
+	writer
+}
+
+// NewJSONLines Constructor of sink writing to w
+func NewJSONLines(w io.Writer) *JSONLines {
+	return &JSONLines{newWriter(w)}
+}
+
+func (j *JSONLines) Trace(event Event) {
+	fields := details(event)
+	fields["time"] = event.Time.Format(time.RFC3339Nano)
+	fields["event"] = event.Kind
+	fields["depth"] = event.Depth
+
+	if event.Function != "" {
+		fields["function"] = event.Function
+	}
+
+	if event.Kind == EXIT || event.Kind == BUILTIN {
+		fields["duration_ns"] = event.Duration.Nanoseconds()
+	}
+
+	content, _ := json.Marshal(fields)
+	j.write(append(content, '\n'))
+}
+
+func (j *JSONLines) Close() error {
+	return j.close()
+}
+
+// Chrome Sink writing trace event format read by chrome://tracing and
+// Perfetto. User function calls become duration events, builtin calls
+// complete events and bindings and errors instant events.
+type Chrome struct {
+	writer
+	start  time.Time // Start of the first event, timestamps are relative to it
+	events int
+}
+
+// NewChrome Constructor of sink writing to w
+func NewChrome(w io.Writer) *Chrome {
+	return &Chrome{writer: newWriter(w)}
+}
+
+func (c *Chrome) Trace(event Event) {
+	begin := event.Time
+	if event.Kind == BUILTIN {
+		begin = begin.Add(-event.Duration)
+	}
+
+	if c.events == 0 {
+		c.start = begin
+	}
+
+	fields := map[string]interface{}{
+		"cat":  event.Kind,
+		"pid":  1,
+		"tid":  1,
+		"ts":   microseconds(begin.Sub(c.start)),
+		"args": details(event),
+	}
+
+	switch event.Kind {
+	case ENTER:
+		fields["ph"], fields["name"] = "B", event.Function
+	case EXIT:
+		fields["ph"], fields["name"] = "E", event.Function
+	case BUILTIN:
+		fields["ph"], fields["name"] = "X", event.Function
+		fields["dur"] = microseconds(event.Duration)
+	case BIND:
+		fields["ph"], fields["name"], fields["s"] = "i", "let "+event.Name, "t"
+	case ERROR:
+		fields["ph"], fields["name"], fields["s"] = "i", event.ErrorKind, "t"
+	}
+
+	separator := ",\n"
+	if c.events == 0 {
+		separator = "[\n"
+	}
+
+	c.events++
+
+	content, _ := json.Marshal(fields)
+	c.write(append([]byte(separator), content...))
+}
+
+// Close Ends the array of events, empty traces are written as an empty array
+func (c *Chrome) Close() error {
+	if c.events == 0 {
+		c.write([]byte("[\n"))
+	}
+
+	c.write([]byte("\n]\n"))
+
+	return c.close()
+}
+
+func microseconds(d time.Duration) float64 {
+	return float64(d.Nanoseconds()) / 1000
+}
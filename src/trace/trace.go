@@ -0,0 +1,45 @@
+package trace
+
+import "time"
+
+// Kind What happened during evaluation
+type Kind string
+
+const (
+	ENTER   Kind = "enter"   // User function was called
+	EXIT    Kind = "exit"    // User function returned
+	BUILTIN Kind = "builtin" // Builtin function returned
+	ERROR   Kind = "error"   // Error was raised, caught ones included
+	BIND    Kind = "bind"    // Let statement bound a name
+)
+
+// Event Step of evaluation. Values are recorded in their printed form, so
+// later changes of mutable values don't affect recorded events.
+type Event struct {
+	Kind      Kind
+	Time      time.Time
+	Depth     int    // Number of user functions being applied, called one excluded
+	Function  string // Called function, for enter, exit and builtin events
+	File      string // Source file of the definition or let statement, empty for builtins
+	Line      int
+	Arguments []string      // Call arguments, keyword ones as name=value
+	Result    string        // Returned value, for exit and builtin events
+	Duration  time.Duration // Time the call took, for exit and builtin events
+	Name      string        // Bound name, for bind events
+	Value     string        // Bound value, for bind events
+	Error     string        // Error message, for error events and failed calls
+	ErrorKind string        // Kind of the error, e.g. TypeError
+}
+
+// Tracer Receives events in order they happen. Evaluation waits for Trace to
+// return, tracers should be quick or buffer.
+type Tracer interface {
+	Trace(event Event)
+}
+
+// Sink Tracer writing events to an output, Close writes any pending output
+// and returns the first write error
+type Sink interface {
+	Tracer
+	Close() error
+}
@@ -0,0 +1,119 @@
+package trace
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+var start = time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+// events Call of double with a builtin call, a binding and an error
+func events() []Event {
+	return []Event{
+		{Kind: ENTER, Time: start, Function: "double", File: "/src/main.cgo", Line: 1, Arguments: []string{"2"}},
+		{Kind: BUILTIN, Time: start.Add(30 * time.Microsecond), Depth: 1, Function: "len", Arguments: []string{"abc"},
+			Result: "3", Duration: 10 * time.Microsecond},
+		{Kind: ERROR, Time: start.Add(40 * time.Microsecond), Depth: 1, Error: "boom", ErrorKind: "Error"},
+		{Kind: EXIT, Time: start.Add(50 * time.Microsecond), Function: "double", File: "/src/main.cgo", Line: 1,
+			Result: "4", Duration: 50 * time.Microsecond},
+		{Kind: BIND, Time: start.Add(60 * time.Microsecond), File: "/src/main.cgo", Line: 2, Name: "x", Value: "4"},
+	}
+}
+
+func TestJSONLines(t *testing.T) {
+	var out bytes.Buffer
+	sink := NewJSONLines(&out)
+	for _, event := range events() {
+		sink.Trace(event)
+	}
+
+	if err := sink.Close(); err != nil {
+		t.Fatalf("close failed: %s", err)
+	}
+
+	expected := []string{
+		`{"args":["2"],"depth":0,"event":"enter","file":"/src/main.cgo","function":"double","line":1,"time":"2024-05-01T12:00:00Z"}`,
+		`{"args":["abc"],"depth":1,"duration_ns":10000,"event":"builtin","function":"len","result":"3","time":"2024-05-01T12:00:00.00003Z"}`,
+		`{"depth":1,"error":"boom","error_kind":"Error","event":"error","time":"2024-05-01T12:00:00.00004Z"}`,
+		`{"depth":0,"duration_ns":50000,"event":"exit","file":"/src/main.cgo","function":"double","line":1,"result":"4","time":"2024-05-01T12:00:00.00005Z"}`,
+		`{"depth":0,"event":"bind","file":"/src/main.cgo","line":2,"name":"x","time":"2024-05-01T12:00:00.00006Z","value":"4"}`,
+	}
+
+	if out.String() != strings.Join(expected, "\n")+"\n" {
+		t.Errorf("wrong output.\ngot:\n%s\nwant:\n%s", out.String(), strings.Join(expected, "\n"))
+	}
+}
+
+func TestChrome(t *testing.T) {
+	var out bytes.Buffer
+	sink := NewChrome(&out)
+	for _, event := range events() {
+		sink.Trace(event)
+	}
+
+	if err := sink.Close(); err != nil {
+		t.Fatalf("close failed: %s", err)
+	}
+
+	var traced []map[string]interface{}
+	if err := json.Unmarshal(out.Bytes(), &traced); err != nil {
+		t.Fatalf("output isn't JSON array: %s\n%s", err, out.String())
+	}
+
+	expected := []string{"B double 0", "X len 20", "i Error 40", "E double 50", "i let x 60"}
+	if len(traced) != len(expected) {
+		t.Fatalf("wrong number of events. got=%d, want=%d", len(traced), len(expected))
+	}
+
+	for i, event := range traced {
+		got := fmt.Sprintf("%s %s %v", event["ph"], event["name"], event["ts"])
+		if got != expected[i] {
+			t.Errorf("events[%d] wrong. got=%q, want=%q", i, got, expected[i])
+		}
+	}
+
+	if dur := traced[1]["dur"]; dur != 10.0 {
+		t.Errorf("wrong duration of builtin call. got=%v", dur)
+	}
+
+	args := traced[3]["args"].(map[string]interface{})
+	if args["result"] != "4" || args["file"] != "/src/main.cgo" {
+		t.Errorf("wrong exit args. got=%v", args)
+	}
+}
+
+func TestChromeEmpty(t *testing.T) {
+	var out bytes.Buffer
+	if err := NewChrome(&out).Close(); err != nil {
+		t.Fatalf("close failed: %s", err)
+	}
+
+	var traced []interface{}
+	if err := json.Unmarshal(out.Bytes(), &traced); err != nil || len(traced) != 0 {
+		t.Errorf("empty trace isn't empty array: %q", out.String())
+	}
+}
+
+// failing Writer failing every write
+type failing struct{}
+
+func (failing) Write([]byte) (int, error) {
+	return 0, errors.New("disk full")
+}
+
+func TestSinkWriteError(t *testing.T) {
+	for _, sink := range []Sink{NewJSONLines(failing{}), NewChrome(failing{})} {
+		for _, event := range events() {
+			sink.Trace(event)
+		}
+
+		if err := sink.Close(); err == nil || err.Error() != "disk full" {
+			t.Errorf("%T: wrong close error. got=%v", sink, err)
+		}
+	}
+}
@@ -0,0 +1,743 @@
+package types
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/aeremic/cgo/ast"
+	"github.com/aeremic/cgo/token"
+	"github.com/aeremic/cgo/value"
+)
+
+// Error Type error found before evaluation
+type Error struct {
+	Message string
+	Line    int
+	Column  int
+}
+
+func (e Error) Error() string {
+	return fmt.Sprintf("%d:%d: %s", e.Line, e.Column, e.Message)
+}
+
+// Check Infers types of expressions and reports operations which would fail
+// at runtime. Unannotated parameters and names the checker can't follow are
+// of type any and checked only when evaluated. Errors are ordered by position.
+func Check(program *ast.ProgramRoot) []Error {
+	return checkProgram(program).errors
+}
+
+// Infer Returns types inferred for expressions and identifiers binding names
+// in the program. Quoted code isn't checked and is missing from the map.
+func Infer(program *ast.ProgramRoot) map[ast.Node]Type {
+	return checkProgram(program).types
+}
+
+func checkProgram(program *ast.ProgramRoot) *checker {
+	c := &checker{
+		scope:   newScope(nil),
+		structs: map[string]bool{},
+		types:   map[ast.Node]Type{},
+		errors:  []Error{},
+	}
+
+	ast.Inspect(program, func(node ast.Node) bool {
+		if statement, ok := node.(*ast.StructStatement); ok {
+			c.structs[statement.Name.Value] = true
+		}
+
+		return true
+	})
+
+	c.statements(program.Statements)
+
+	for len(c.deferred) > 0 {
+		next := c.deferred[0]
+		c.deferred = c.deferred[1:]
+		next()
+	}
+
+	sort.SliceStable(c.errors, func(i, j int) bool {
+		a, b := c.errors[i], c.errors[j]
+		return a.Line < b.Line || a.Line == b.Line && a.Column < b.Column
+	})
+
+	return c
+}
+
+// scope Types of names bound by a program, function call, catch block or match arm
+type scope struct {
+	outer *scope
+	names map[string]Type
+}
+
+func newScope(outer *scope) *scope {
+	return &scope{outer: outer, names: map[string]Type{}}
+}
+
+func (s *scope) lookup(name string) Type {
+	for current := s; current != nil; current = current.outer {
+		if t, ok := current.names[name]; ok {
+			return t
+		}
+	}
+
+	return Any
+}
+
+// checker Function bodies are checked after the code defining them, names
+// bound by then are visible to them. A name bound again with different type
+// becomes any, so bodies don't assume type it had at one point only.
+type checker struct {
+	scope      *scope
+	structs    map[string]bool // Names of structs declared anywhere in the program
+	returnType Type            // Annotated return type of the checked function body
+	types      map[ast.Node]Type
+	deferred   []func()
+	errors     []Error
+}
+
+// errorf Reports error at the first token of node
+func (c *checker) errorf(node ast.Node, format string, args ...interface{}) {
+	first, _ := ast.Span(node)
+	c.errorAt(first, format, args...)
+}
+
+func (c *checker) errorAt(position token.Token, format string, args ...interface{}) {
+	c.errors = append(c.errors, Error{
+		Message: fmt.Sprintf(format, args...),
+		Line:    position.Line,
+		Column:  position.Column,
+	})
+}
+
+func (c *checker) declare(name *ast.Identifier, t Type) {
+	c.types[name] = t
+
+	if previous, ok := c.scope.names[name.Value]; ok {
+		t = join(previous, t)
+	}
+
+	c.scope.names[name.Value] = t
+}
+
+// within Runs check in a new scope nested in outer
+func (c *checker) within(outer *scope, check func()) {
+	current := c.scope
+	c.scope = newScope(outer)
+	check()
+	c.scope = current
+}
+
+// annotation Returns type written in source
+func (c *checker) annotation(annotation ast.TypeExpression) Type {
+	switch annotation := annotation.(type) {
+	case *ast.NamedType:
+		if basic, ok := basicTypes[annotation.Name]; ok {
+			return basic
+		}
+
+		if c.structs[annotation.Name] {
+			return &Struct{Name: annotation.Name}
+		}
+
+		c.errorf(annotation, "unknown type: %s", annotation.Name)
+
+		return Any
+	case *ast.ArrayType:
+		return &Array{Element: c.annotation(annotation.Element)}
+	case *ast.DictType:
+		return &Dict{Key: c.annotation(annotation.Key), Value: c.annotation(annotation.Value)}
+	case *ast.FunctionType:
+		function := &Function{Parameters: []Type{}, Required: len(annotation.Parameters), Return: Any}
+		for _, parameter := range annotation.Parameters {
+			function.Parameters = append(function.Parameters, c.annotation(parameter))
+		}
+
+		if annotation.Return != nil {
+			function.Return = c.annotation(annotation.Return)
+		}
+
+		return function
+	default:
+		return Any
+	}
+}
+
+// statements Returns type of the last statement, value of blocks
+func (c *checker) statements(statements []ast.Statement) Type {
+	var result Type = Any
+	for _, statement := range statements {
+		result = c.statement(statement)
+	}
+
+	return result
+}
+
+func (c *checker) statement(statement ast.Statement) Type {
+	switch statement := statement.(type) {
+	case *ast.ExpressionStatement:
+		return c.expression(statement.Expression)
+	case *ast.LetStatement:
+		c.let(statement)
+	case *ast.ExportStatement:
+		c.let(statement.Statement)
+	case *ast.ReturnStatement:
+		returned := c.expression(statement.ReturnValue)
+		if c.returnType != nil && !Assignable(returned, c.returnType) {
+			c.errorf(statement.ReturnValue, "cannot use %s as %s in return", returned, c.returnType)
+		}
+	case *ast.ThrowStatement:
+		c.expression(statement.Value)
+	case *ast.ImportStatement:
+		c.importStatement(statement)
+	case *ast.StructStatement:
+		c.structStatement(statement)
+	}
+
+	return Any
+}
+
+func (c *checker) let(statement *ast.LetStatement) {
+	t := c.expression(statement.Value)
+
+	if statement.Pattern != nil {
+		c.bind(statement.Pattern, t)
+		return
+	}
+
+	if statement.Type != nil {
+		declared := c.annotation(statement.Type)
+		if !Assignable(t, declared) {
+			c.errorf(statement.Value, "cannot use %s as %s in let %s", t, declared, statement.Name.Value)
+		}
+
+		t = declared
+	}
+
+	c.declare(statement.Name, t)
+}
+
+// bind Declares names of the pattern matched against value of type t
+func (c *checker) bind(pattern ast.Pattern, t Type) {
+	switch pattern := pattern.(type) {
+	case *ast.Identifier:
+		c.declare(pattern, t)
+	case *ast.DefaultPattern:
+		c.bind(pattern.Target, join(t, c.expression(pattern.Default)))
+	case *ast.LiteralPattern:
+		c.expression(pattern.Value)
+	case *ast.TypePattern:
+		if pattern.Binding == nil {
+			return
+		}
+
+		var matched Type = Any
+		if basic, ok := basicTypes[pattern.Name]; ok {
+			matched = basic
+		} else if c.structs[pattern.Name] {
+			matched = &Struct{Name: pattern.Name}
+		}
+
+		c.bind(pattern.Binding, matched)
+	case *ast.ArrayPattern:
+		var element Type = Any
+		if array, ok := t.(*Array); ok {
+			element = array.Element
+		}
+
+		for _, p := range pattern.Elements {
+			c.bind(p, element)
+		}
+
+		if pattern.Rest != nil {
+			c.declare(pattern.Rest, &Array{Element: element})
+		}
+	case *ast.DictPattern:
+		var dictValue Type = Any
+		if dict, ok := t.(*Dict); ok {
+			dictValue = dict.Value
+		}
+
+		for _, entry := range pattern.Entries {
+			c.bind(entry.Value, dictValue)
+		}
+
+		if pattern.Rest != nil {
+			c.declare(pattern.Rest, &Dict{Key: String, Value: dictValue})
+		}
+	}
+}
+
+func (c *checker) importStatement(statement *ast.ImportStatement) {
+	if statement.Token.Type == token.FROM {
+		for _, name := range statement.Names {
+			c.declare(name, Any)
+		}
+
+		return
+	}
+
+	alias := statement.Alias
+	if alias == nil {
+		name := strings.TrimSuffix(filepath.Base(statement.Path.Value), ".cgo")
+		alias = &ast.Identifier{Token: statement.Token, Value: name}
+	}
+
+	c.declare(alias, Any)
+}
+
+// structStatement Declares constructor returning instances of the struct.
+// Field defaults and methods are checked like function bodies.
+func (c *checker) structStatement(statement *ast.StructStatement) {
+	if value.IsBuiltinType(statement.Name.Value) {
+		c.errorf(statement.Name, "struct name %s is reserved for builtin type", statement.Name.Value)
+	}
+
+	constructor := &Function{Parameters: []Type{}, Return: &Struct{Name: statement.Name.Value}}
+	for i, field := range statement.Fields {
+		constructor.Parameters = append(constructor.Parameters, Any)
+		if _, ok := field.(*ast.DefaultPattern); !ok {
+			constructor.Required = i + 1
+		}
+	}
+
+	c.declare(statement.Name, constructor)
+
+	outer := c.scope
+	c.deferred = append(c.deferred, func() {
+		c.within(outer, func() {
+			for _, field := range statement.Fields {
+				c.bind(field, Any)
+			}
+		})
+	})
+
+	for _, method := range statement.Methods {
+		c.function(method.Function)
+	}
+}
+
+// function Returns type of the function literal, its body is checked later
+func (c *checker) function(literal *ast.FunctionLiteral) Type {
+	function := &Function{Parameters: []Type{}, Variadic: literal.Rest != nil, Return: Any}
+	for i, parameter := range literal.Parameters {
+		var t Type = Any
+		if annotation := literal.ParameterType(i); annotation != nil {
+			t = c.annotation(annotation)
+		}
+
+		function.Parameters = append(function.Parameters, t)
+		if _, ok := parameter.(*ast.DefaultPattern); !ok {
+			function.Required = i + 1
+		}
+	}
+
+	var returnType Type
+	if literal.ReturnType != nil {
+		returnType = c.annotation(literal.ReturnType)
+		function.Return = returnType
+	}
+
+	outer := c.scope
+	c.deferred = append(c.deferred, func() {
+		c.within(outer, func() {
+			c.parameters(literal, function)
+
+			enclosing := c.returnType
+			c.returnType = returnType
+			c.body(literal.Body)
+			c.returnType = enclosing
+		})
+	})
+
+	return function
+}
+
+// parameters Binds parameters checking default values against annotations
+func (c *checker) parameters(literal *ast.FunctionLiteral, function *Function) {
+	for i, parameter := range literal.Parameters {
+		t := function.Parameters[i]
+
+		defaultPattern, ok := parameter.(*ast.DefaultPattern)
+		if !ok {
+			c.bind(parameter, t)
+			continue
+		}
+
+		defaultType := c.expression(defaultPattern.Default)
+		if literal.ParameterType(i) != nil && !Assignable(defaultType, t) {
+			c.errorf(defaultPattern.Default, "cannot use %s as %s in default of %s", defaultType, t,
+				defaultPattern.Target)
+		}
+
+		if literal.ParameterType(i) == nil {
+			t = Any
+		}
+
+		c.bind(defaultPattern.Target, t)
+	}
+
+	if literal.Rest != nil {
+		c.declare(literal.Rest, &Array{Element: Any})
+	}
+}
+
+// body Checks function body, value of the last expression is returned
+func (c *checker) body(body *ast.BlockStatement) {
+	result := c.statements(body.Statements)
+	if c.returnType == nil || len(body.Statements) == 0 {
+		return
+	}
+
+	last, ok := body.Statements[len(body.Statements)-1].(*ast.ExpressionStatement)
+	if ok && !Assignable(result, c.returnType) {
+		c.errorf(last, "cannot use %s as %s in return", result, c.returnType)
+	}
+}
+
+func (c *checker) block(block *ast.BlockStatement) Type {
+	if block == nil {
+		return Null
+	}
+
+	return c.statements(block.Statements)
+}
+
+func (c *checker) expression(expression ast.Expression) Type {
+	t := c.infer(expression)
+	c.types[expression] = t
+
+	return t
+}
+
+func (c *checker) infer(expression ast.Expression) Type {
+	switch expression := expression.(type) {
+	case *ast.Identifier:
+		return c.scope.lookup(expression.Value)
+	case *ast.IntegerLiteral:
+		return Int
+	case *ast.StringLiteral:
+		return String
+	case *ast.Boolean:
+		return Bool
+	case *ast.NullLiteral:
+		return Null
+	case *ast.PrefixExpression:
+		return c.prefix(expression)
+	case *ast.InfixExpression:
+		return c.infix(expression)
+	case *ast.IfExpression:
+		c.expression(expression.Condition)
+		consequence := c.block(expression.Consequence)
+
+		return join(consequence, c.block(expression.Alternative))
+	case *ast.FunctionLiteral:
+		return c.function(expression)
+	case *ast.CallExpression:
+		return c.call(expression)
+	case *ast.PipeExpression:
+		return c.pipe(expression)
+	case *ast.ArrayLiteral:
+		return &Array{Element: c.elements(expression.Elements)}
+	case *ast.DictLiteral:
+		if len(expression.Elements) == 0 {
+			return &Dict{Key: Any, Value: Any}
+		}
+
+		dict := &Dict{
+			Key:   c.expression(expression.Elements[0].Key),
+			Value: c.expression(expression.Elements[0].Value),
+		}
+
+		for _, element := range expression.Elements[1:] {
+			dict.Key = join(dict.Key, c.expression(element.Key))
+			dict.Value = join(dict.Value, c.expression(element.Value))
+		}
+
+		return dict
+	case *ast.IndexExpression:
+		return c.index(expression)
+	case *ast.MemberExpression:
+		c.expression(expression.Object)
+	case *ast.AssignExpression:
+		c.expression(expression.Target)
+		return c.expression(expression.Value)
+	case *ast.TryExpression:
+		c.within(c.scope, func() { c.block(expression.Block) })
+		if expression.Catch != nil {
+			c.within(c.scope, func() {
+				c.declare(expression.CatchParam, Any)
+				c.block(expression.Catch)
+			})
+		}
+
+		c.within(c.scope, func() { c.block(expression.Finally) })
+	case *ast.MatchExpression:
+		return c.match(expression)
+	case *ast.SpreadExpression:
+		c.expression(expression.Value)
+	case *ast.KeywordArgument:
+		c.expression(expression.Value)
+	}
+
+	return Any
+}
+
+func (c *checker) prefix(expression *ast.PrefixExpression) Type {
+	right := c.expression(expression.Right)
+
+	switch expression.Operator {
+	case "!":
+		return Bool
+	case "-":
+		if right != Any && right != Int {
+			c.errorf(expression, "unknown operator: -%s", right.Kind())
+		}
+
+		return Int
+	default:
+		return Any
+	}
+}
+
+// infix Mirrors evaluation of infix expressions for values of known types
+func (c *checker) infix(expression *ast.InfixExpression) Type {
+	left := c.expression(expression.Left)
+	right := c.expression(expression.Right)
+	operator := expression.Operator
+
+	switch {
+	case operator == "??":
+		if left == Null {
+			return right
+		}
+
+		if left != Any {
+			return left
+		}
+
+		return join(left, right)
+	case left == Any || right == Any:
+		if operator == "==" || operator == "!=" || operator == "<" || operator == ">" {
+			return Bool
+		}
+
+		return Any
+	case (left == Null || right == Null) && (operator == "==" || operator == "!="):
+		return Bool
+	case left.Kind() != right.Kind():
+		c.errorAt(expression.Token, "type mismatch: %s %s %s", left.Kind(), operator, right.Kind())
+		return Any
+	}
+
+	switch operator {
+	case "==", "!=":
+		return Bool
+	case "<", ">":
+		if left == Int || left == String {
+			return Bool
+		}
+	case "+":
+		if left == Int || left == String {
+			return left
+		}
+	case "-", "*", "/":
+		if left == Int {
+			return Int
+		}
+	case "..", "..=":
+		if left == Int {
+			return Range
+		}
+	}
+
+	c.errorAt(expression.Token, "unknown operator: %s %s %s", left.Kind(), operator, right.Kind())
+
+	return Any
+}
+
+// elements Returns type common to all elements, any for mixed or spread ones
+func (c *checker) elements(elements []ast.Expression) Type {
+	var common Type
+	for _, element := range elements {
+		t := c.expression(element)
+		if _, ok := element.(*ast.SpreadExpression); ok {
+			t = Any
+		}
+
+		if common == nil {
+			common = t
+		} else {
+			common = join(common, t)
+		}
+	}
+
+	if common == nil {
+		return Any
+	}
+
+	return common
+}
+
+func (c *checker) call(call *ast.CallExpression) Type {
+	if identifier, ok := call.Function.(*ast.Identifier); ok && identifier.Value == "quote" {
+		return Any
+	}
+
+	callee := c.expression(call.Function)
+	if call.Optional {
+		c.arguments(call.Arguments)
+		return Any
+	}
+
+	return c.apply(callee, c.arguments(call.Arguments), call)
+}
+
+// pipe Checks `x |> f(a)` as `f(x, a)` and `x |> f` as `f(x)`
+func (c *checker) pipe(pipe *ast.PipeExpression) Type {
+	left := argument{t: c.expression(pipe.Left), node: pipe.Left}
+
+	call, ok := pipe.Right.(*ast.CallExpression)
+	if !ok || call.Optional {
+		return c.apply(c.expression(pipe.Right), []argument{left}, pipe.Right)
+	}
+
+	callee := c.expression(call.Function)
+
+	return c.apply(callee, append([]argument{left}, c.arguments(call.Arguments)...), call)
+}
+
+type argument struct {
+	t    Type
+	node ast.Expression
+}
+
+// arguments Returns types of arguments, nil when spread or keyword arguments
+// make positions of arguments unknown
+func (c *checker) arguments(expressions []ast.Expression) []argument {
+	arguments := []argument{}
+	known := true
+	for _, expression := range expressions {
+		t := c.expression(expression)
+
+		switch expression.(type) {
+		case *ast.SpreadExpression, *ast.KeywordArgument:
+			known = false
+		}
+
+		arguments = append(arguments, argument{t: t, node: expression})
+	}
+
+	if !known {
+		return nil
+	}
+
+	return arguments
+}
+
+// apply Checks arguments against parameters of the callee and returns result type
+func (c *checker) apply(callee Type, arguments []argument, node ast.Node) Type {
+	if callee == Any {
+		return Any
+	}
+
+	function, ok := callee.(*Function)
+	if !ok {
+		c.errorf(node, "not a function: %s", callee.Kind())
+		return Any
+	}
+
+	if arguments == nil {
+		return function.Return
+	}
+
+	if len(arguments) < function.Required || len(arguments) > len(function.Parameters) && !function.Variadic {
+		c.errorf(node, "wrong number of arguments. got=%d, want=%d", len(arguments), len(function.Parameters))
+		return function.Return
+	}
+
+	for i, argument := range arguments {
+		if i < len(function.Parameters) && !Assignable(argument.t, function.Parameters[i]) {
+			c.errorf(argument.node, "cannot use %s as %s in argument %d", argument.t, function.Parameters[i], i+1)
+		}
+	}
+
+	return function.Return
+}
+
+// index Mirrors index operators supported at runtime for values of known types
+func (c *checker) index(expression *ast.IndexExpression) Type {
+	left := c.expression(expression.Left)
+
+	if slice, ok := expression.Index.(*ast.SliceIndex); ok {
+		for _, part := range []ast.Expression{slice.Start, slice.End, slice.Step} {
+			if part != nil {
+				c.expression(part)
+			}
+		}
+
+		if expression.Optional {
+			return Any
+		}
+
+		return left
+	}
+
+	index := c.expression(expression.Index)
+	if expression.Optional || left == Any {
+		return Any
+	}
+
+	switch left := left.(type) {
+	case *Array:
+		if index == Any || index == Int {
+			return left.Element
+		}
+	case *Dict:
+		return left.Value
+	case *Struct, *Function:
+	default:
+		if index == Any || index == Int {
+			switch left {
+			case String:
+				return String
+			case Range:
+				return Int
+			}
+		}
+	}
+
+	c.errorAt(expression.Token, "index operator not supported: %s", left.Kind())
+
+	return Any
+}
+
+func (c *checker) match(expression *ast.MatchExpression) Type {
+	c.expression(expression.Subject)
+
+	var result Type
+	for _, arm := range expression.Arms {
+		c.within(c.scope, func() {
+			c.bind(arm.Pattern, Any)
+			if arm.Guard != nil {
+				c.expression(arm.Guard)
+			}
+
+			body := c.expression(arm.Body)
+			if result == nil {
+				result = body
+			} else {
+				result = join(result, body)
+			}
+		})
+	}
+
+	if result == nil {
+		return Any
+	}
+
+	return result
+}
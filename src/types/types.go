@@ -0,0 +1,148 @@
+package types
+
+import (
+	"strings"
+
+	"github.com/aeremic/cgo/value"
+)
+
+// Type Static type of values an expression can evaluate to
+type Type interface {
+	String() string   // Type as written in annotations e.g. [int]
+	Kind() value.Type // Type of values at runtime, empty for any
+}
+
+// Basic Type without components, any is compatible with every type
+type Basic struct {
+	name string
+	kind value.Type
+}
+
+func (b *Basic) String() string {
+	return b.name
+}
+
+func (b *Basic) Kind() value.Type {
+	return b.kind
+}
+
+var (
+	Any    = &Basic{name: "any"}
+	Int    = &Basic{name: "int", kind: value.INTEGER}
+	String = &Basic{name: "string", kind: value.STRING}
+	Bool   = &Basic{name: "bool", kind: value.BOOLEAN}
+	Null   = &Basic{name: "null", kind: value.NULL}
+	Range  = &Basic{name: "range", kind: value.RANGE}
+)
+
+// basicTypes Basic types by names used in annotations
+var basicTypes = map[string]*Basic{
+	"any":    Any,
+	"int":    Int,
+	"string": String,
+	"bool":   Bool,
+	"null":   Null,
+	"range":  Range,
+}
+
+type Array struct {
+	Element Type
+}
+
+func (a *Array) String() string {
+	return "[" + a.Element.String() + "]"
+}
+
+func (a *Array) Kind() value.Type {
+	return value.ARRAY
+}
+
+type Dict struct {
+	Key   Type
+	Value Type
+}
+
+func (d *Dict) String() string {
+	return "{" + d.Key.String() + ": " + d.Value.String() + "}"
+}
+
+func (d *Dict) Kind() value.Type {
+	return value.DICT
+}
+
+type Function struct {
+	Parameters []Type
+	Required   int  // Number of leading parameters without default values
+	Variadic   bool // Extra arguments are collected by rest parameter
+	Return     Type
+}
+
+func (f *Function) String() string {
+	params := []string{}
+	for _, p := range f.Parameters {
+		params = append(params, p.String())
+	}
+
+	return "fn(" + strings.Join(params, ", ") + ") -> " + f.Return.String()
+}
+
+func (f *Function) Kind() value.Type {
+	return value.FUNCTION
+}
+
+// Struct Instance of struct declared under the name
+type Struct struct {
+	Name string
+}
+
+func (s *Struct) String() string {
+	return s.Name
+}
+
+func (s *Struct) Kind() value.Type {
+	return value.Type(s.Name)
+}
+
+// Assignable Reports whether value of type from can be used where type to
+// is expected. Any converts to and from every type.
+func Assignable(from, to Type) bool {
+	if from == Any || to == Any {
+		return true
+	}
+
+	switch to := to.(type) {
+	case *Array:
+		fromArray, ok := from.(*Array)
+		return ok && Assignable(fromArray.Element, to.Element)
+	case *Dict:
+		fromDict, ok := from.(*Dict)
+		return ok && Assignable(fromDict.Key, to.Key) && Assignable(fromDict.Value, to.Value)
+	case *Function:
+		fromFunction, ok := from.(*Function)
+		if !ok {
+			return false
+		}
+
+		for i, parameter := range to.Parameters {
+			if i < len(fromFunction.Parameters) && !Assignable(parameter, fromFunction.Parameters[i]) {
+				return false
+			}
+		}
+
+		return Assignable(fromFunction.Return, to.Return)
+	case *Struct:
+		fromStruct, ok := from.(*Struct)
+		return ok && fromStruct.Name == to.Name
+	default:
+		return from == to
+	}
+}
+
+// join Returns type covering values of both types
+func join(a, b Type) Type {
+	if a.String() == b.String() {
+		return a
+	}
+
+	return Any
+}
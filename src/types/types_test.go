@@ -0,0 +1,214 @@
+package types
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/aeremic/cgo/ast"
+	"github.com/aeremic/cgo/parser"
+	"github.com/aeremic/cgo/tokenizer"
+)
+
+func check(t *testing.T, input string) []string {
+	p := parser.New(tokenizer.New(input))
+
+	program := p.ParseProgram()
+	if len(p.Errors()) > 0 {
+		t.Fatalf("parser errors for %q: %v", input, p.Errors())
+	}
+
+	messages := []string{}
+	for _, err := range Check(program) {
+		messages = append(messages, err.Error())
+	}
+
+	return messages
+}
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		// Operators
+		{"1 + 2 * 3; \"a\" + \"b\"; 1 < 2; null == 1; 1..3", []string{}},
+		{"1 + \"a\"", []string{"1:3: type mismatch: INTEGER + STRING"}},
+		{"let x = 5; let y = \"s\"; x == y", []string{"1:27: type mismatch: INTEGER == STRING"}},
+		{"true + false", []string{"1:6: unknown operator: BOOLEAN + BOOLEAN"}},
+		{"\"a\" - \"b\"", []string{"1:5: unknown operator: STRING - STRING"}},
+		{"-\"a\"", []string{"1:1: unknown operator: -STRING"}},
+		{"[1] + [2]", []string{"1:5: unknown operator: ARRAY + ARRAY"}},
+		{"(null ?? 1) + 1; (1 ?? \"a\") + 1", []string{}},
+
+		// Annotated lets
+		{"let x: int = 5; let s: string = \"a\"; let n: null = null; let a: any = 1", []string{}},
+		{"let x: int = \"five\"", []string{"1:14: cannot use string as int in let x"}},
+		{"let x: int = null", []string{"1:14: cannot use null as int in let x"}},
+		{"let xs: [int] = [1, 2]; let e: [string] = []; let d: {string: int} = {\"a\": 1}", []string{}},
+		{"let xs: [int] = [1, \"a\"]; let ys: [int] = [\"a\"]", []string{
+			"1:43: cannot use [string] as [int] in let ys",
+		}},
+		{"let d: {string: int} = {\"a\": \"b\"}", []string{"1:24: cannot use {string: string} as {string: int} in let d"}},
+		{"let x: integer = 1", []string{"1:8: unknown type: integer"}},
+		{"let x: int = 1; x + \"a\"", []string{"1:19: type mismatch: INTEGER + STRING"}},
+
+		// Functions
+		{"let add = fn(a: int, b: int) -> int { a + b }; add(1, 2) + 3", []string{}},
+		{"let add = fn(a: int, b: int) -> int { a + b }; add(1, \"2\")", []string{
+			"1:55: cannot use string as int in argument 2",
+		}},
+		{"let add = fn(a: int, b: int) { a + b }; add(1)", []string{
+			"1:41: wrong number of arguments. got=1, want=2",
+		}},
+		{"let f = fn(a, b = 1, ...r) { a }; f(1); f(1, 2, 3, 4)", []string{}},
+		{"let f = fn(a: int = \"x\") { a }", []string{"1:21: cannot use string as int in default of a"}},
+		{"let f = fn() -> string { 1 }", []string{"1:26: cannot use int as string in return"}},
+		{"let f = fn(n: int) -> bool { if (n > 1) { return \"big\" }; true }", []string{
+			"1:50: cannot use string as bool in return",
+		}},
+		{"let f = fn(a: int) -> int { a }; let s: string = f(1)", []string{
+			"1:50: cannot use int as string in let s",
+		}},
+		{"let apply = fn(f: fn(int) -> int, x: int) -> int { f(x) }; apply(fn(x: int) -> int { x }, 1)", []string{}},
+		{"let apply = fn(f: fn(int) -> int) { f(1) }; apply(fn(s: string) -> string { s })", []string{
+			"1:51: cannot use fn(string) -> string as fn(int) -> int in argument 1",
+		}},
+		{"let f = fn(x: int) { x }; 1 |> f; \"a\" |> f", []string{"1:35: cannot use string as int in argument 1"}},
+		{"let f = fn(x: int, y: string) { x }; 1 |> f(\"a\"); 1 |> f(2)", []string{
+			"1:58: cannot use int as string in argument 2",
+		}},
+		{"5(1); \"f\"()", []string{"1:1: not a function: INTEGER", "1:7: not a function: STRING"}},
+		{"let f = fn(a: int) { a }; f(...[1]); f(a: \"x\")", []string{}},
+
+		// Bodies see names bound later, names bound again become any
+		{"let f = fn() { x + 1 }; let x = 1", []string{}},
+		{"let x = 1; let f = fn() { x + \"a\" }; let x = \"a\"", []string{}},
+		{"let f = fn(n: int) -> int { if (n < 2) { return 1 }; n * f(n - 1) }; f(5) + 1", []string{}},
+
+		// Unannotated code stays dynamic
+		{"let f = fn(a) { a + 1 }; f(\"x\")", []string{}},
+		{"let f = fn(a) { a }; f(1) + \"a\"", []string{}},
+		{"len([1]) + 1; puts(\"a\") + 1", []string{}},
+
+		// Indexes
+		{"let xs: [int] = [1]; xs[0] + 1; \"abc\"[0] + \"d\"; (1..3)[0] + 1", []string{}},
+		{"let xs = [1]; xs[0] + \"a\"", []string{"1:21: type mismatch: INTEGER + STRING"}},
+		{"[1][\"a\"]", []string{"1:4: index operator not supported: ARRAY"}},
+		{"5[0]", []string{"1:2: index operator not supported: INTEGER"}},
+		{"let d = {\"a\": 1}; d[\"a\"] + 1; d[\"b\"] + \"x\"", []string{"1:38: type mismatch: INTEGER + STRING"}},
+		{"let xs = [1, 2]; xs[0:1][0] + 1; xs?.[0] + \"a\"", []string{}},
+
+		// Patterns, structs, match and try
+		{"let [a, b] = [1, 2]; a + \"s\"", []string{"1:24: type mismatch: INTEGER + STRING"}},
+		{"let {name} = {\"name\": \"x\"}; name + \"y\"", []string{}},
+		{"struct P { x, y = 0 }; let p: P = P(1); let q: P = 1", []string{"1:52: cannot use int as P in let q"}},
+		{"struct DICT { x }", []string{"1:8: struct name DICT is reserved for builtin type"}},
+		{"struct P { x, y = 1 + \"a\", fn m(self) { self.x } }; P()", []string{
+			"1:21: type mismatch: INTEGER + STRING",
+			"1:53: wrong number of arguments. got=0, want=2",
+		}},
+		{"match (1) { int(n) => n + 1, _ => 1 + \"a\" }", []string{"1:37: type mismatch: INTEGER + STRING"}},
+		{"match (1) { null(n) => n + 1, _ => 0 }", []string{"1:26: type mismatch: NULL + INTEGER"}},
+		{"struct P { x } match (P(1)) { P(p) => p.x, _ => 0 }", []string{}},
+		{"try { 1 } catch (e) { e[\"message\"] } finally { null }", []string{}},
+		{"import \"lib\"; lib[\"x\"]; from \"m\" import f; f(1)", []string{}},
+		{"let m = macro(a) { quote(unquote(a) + 1) }; quote(1 + \"a\")", []string{}},
+	}
+
+	for _, test := range tests {
+		got := check(t, test.input)
+
+		if strings.Join(got, "\n") != strings.Join(test.expected, "\n") {
+			t.Errorf("wrong errors for %q.\ngot:\n%s\nwant:\n%s", test.input,
+				strings.Join(got, "\n"), strings.Join(test.expected, "\n"))
+		}
+	}
+}
+
+func TestAssignable(t *testing.T) {
+	intToInt := &Function{Parameters: []Type{Int}, Required: 1, Return: Int}
+	anyToInt := &Function{Parameters: []Type{Any}, Required: 1, Return: Int}
+
+	tests := []struct {
+		from     Type
+		to       Type
+		expected bool
+	}{
+		{Int, Int, true},
+		{Int, String, false},
+		{Null, Int, false},
+		{Any, Int, true},
+		{Int, Any, true},
+		{&Array{Element: Int}, &Array{Element: Int}, true},
+		{&Array{Element: Any}, &Array{Element: Int}, true},
+		{&Array{Element: String}, &Array{Element: Int}, false},
+		{&Dict{Key: String, Value: Int}, &Dict{Key: String, Value: Any}, true},
+		{&Dict{Key: String, Value: Int}, &Array{Element: Int}, false},
+		{anyToInt, intToInt, true},
+		{intToInt, &Function{Parameters: []Type{String}, Return: Int}, false},
+		{intToInt, &Function{Parameters: []Type{Int}, Return: String}, false},
+		{&Struct{Name: "P"}, &Struct{Name: "P"}, true},
+		{&Struct{Name: "P"}, &Struct{Name: "Q"}, false},
+	}
+
+	for _, test := range tests {
+		if Assignable(test.from, test.to) != test.expected {
+			t.Errorf("Assignable(%s, %s) != %v", test.from, test.to, test.expected)
+		}
+	}
+}
+
+func TestAnnotationTypes(t *testing.T) {
+	p := parser.New(tokenizer.New("let f: fn([int], {string: bool}) -> null = g"))
+	program := p.ParseProgram()
+
+	c := &checker{structs: map[string]bool{}}
+	annotated := c.annotation(program.Statements[0].(*ast.LetStatement).Type)
+
+	if annotated.String() != "fn([int], {string: bool}) -> null" {
+		t.Errorf("wrong type. got=%s", annotated)
+	}
+
+	if annotated.Kind() != "FUNCTION" {
+		t.Errorf("wrong kind. got=%s", annotated.Kind())
+	}
+}
+
+func TestInfer(t *testing.T) {
+	p := parser.New(tokenizer.New("let xs = [1, 2]; let f = fn(a: string, b) -> bool { b }; let [y] = xs; f(\"a\", y)"))
+	program := p.ParseProgram()
+	types := Infer(program)
+
+	names := map[string]string{}
+	ast.Inspect(program, func(node ast.Node) bool {
+		if identifier, ok := node.(*ast.Identifier); ok {
+			names[identifier.String()+fmt.Sprint(identifier.Token.Column)] = types[identifier].String()
+		}
+
+		return true
+	})
+
+	expected := map[string]string{
+		"xs5":  "[int]",
+		"f22":  "fn(string, any) -> bool",
+		"a29":  "string",
+		"b40":  "any",
+		"b53":  "any",
+		"y63":  "int",
+		"xs68": "[int]",
+		"f72":  "fn(string, any) -> bool",
+		"y79":  "int",
+	}
+
+	for name, expectedType := range expected {
+		if names[name] != expectedType {
+			t.Errorf("wrong type of %s. got=%s, want=%s", name, names[name], expectedType)
+		}
+	}
+
+	call := program.Statements[3].(*ast.ExpressionStatement).Expression
+	if types[call] != Bool {
+		t.Errorf("wrong type of call. got=%v", types[call])
+	}
+}
@@ -1,8 +1,11 @@
 package value
 
+import "sort"
+
 type Environment struct {
 	store map[string]Wrapper
 	outer *Environment
+	file  string // Source file of a module environment
 }
 
 func NewEnvironment() *Environment {
@@ -34,3 +37,36 @@ func (e *Environment) Set(name string, wrappedValue Wrapper) Wrapper {
 	e.store[name] = wrappedValue
 	return wrappedValue
 }
+
+// Names Returns names bound in this scope in alphabetical order, names of
+// outer scopes are left out
+func (e *Environment) Names() []string {
+	names := make([]string, 0, len(e.store))
+	for name := range e.store {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// Outer Returns scope enclosing this one, nil for top level scope
+func (e *Environment) Outer() *Environment {
+	return e.outer
+}
+
+// SetFile Marks environment as the top level scope of the given source file
+func (e *Environment) SetFile(path string) {
+	e.file = path
+}
+
+// File Returns source file the environment belongs to, walking outer scopes.
+// Empty when code doesn't come from a file (e.g. REPL).
+func (e *Environment) File() string {
+	if e.file == "" && e.outer != nil {
+		return e.outer.File()
+	}
+
+	return e.file
+}
@@ -0,0 +1,134 @@
+package value
+
+// visitedPair Identifies containers already being compared so
+// self-referencing arrays and dicts don't recurse forever
+type visitedPair struct {
+	left  Wrapper
+	right Wrapper
+}
+
+// Equal Reports whether two values are structurally equal.
+//
+// Integers, strings and booleans compare by value and all nulls are equal.
+// Arrays are equal when they have equal elements in the same order, dicts
+// when they hold the same keys mapped to equal values regardless of insertion
+// order and ranges when they produce the same elements. Struct instances are
+// equal when they come from the same declaration and have equal fields.
+// Functions, builtins and errors are only equal to themselves. Values of
+// different types are never equal.
+func Equal(left, right Wrapper) bool {
+	return equal(left, right, map[visitedPair]bool{})
+}
+
+func equal(left, right Wrapper, visited map[visitedPair]bool) bool {
+	if left == right {
+		return true
+	}
+
+	if left == nil || right == nil || left.Type() != right.Type() {
+		return false
+	}
+
+	switch left := left.(type) {
+	case *Integer:
+		return left.Value == right.(*Integer).Value
+	case *String:
+		return left.Value == right.(*String).Value
+	case *Boolean:
+		return left.Value == right.(*Boolean).Value
+	case *Null:
+		return true
+	case *ReturnValue:
+		return equal(left.Value, right.(*ReturnValue).Value, visited)
+	case *Array:
+		return equalArrays(left, right.(*Array), visited)
+	case *Dict:
+		return equalDicts(left, right.(*Dict), visited)
+	case *Range:
+		return equalRanges(left, right.(*Range))
+	case *Struct:
+		return equalStructs(left, right.(*Struct), visited)
+	default:
+		return false
+	}
+}
+
+func equalArrays(left, right *Array, visited map[visitedPair]bool) bool {
+	if len(left.Elements) != len(right.Elements) {
+		return false
+	}
+
+	// Pair under comparison is assumed equal, any difference is found elsewhere
+	pair := visitedPair{left: left, right: right}
+	if visited[pair] {
+		return true
+	}
+	visited[pair] = true
+
+	for i := range left.Elements {
+		if !equal(left.Elements[i], right.Elements[i], visited) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func equalDicts(left, right *Dict, visited map[visitedPair]bool) bool {
+	if left.Len() != right.Len() {
+		return false
+	}
+
+	pair := visitedPair{left: left, right: right}
+	if visited[pair] {
+		return true
+	}
+	visited[pair] = true
+
+	for key, leftElement := range left.Elements {
+		rightElement, ok := right.Get(key)
+		if !ok || !equal(leftElement.Key, rightElement.Key, visited) ||
+			!equal(leftElement.Value, rightElement.Value, visited) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func equalRanges(left, right *Range) bool {
+	length := left.Len()
+	if length != right.Len() {
+		return false
+	}
+
+	if length == 0 {
+		return true
+	}
+
+	if left.Start != right.Start {
+		return false
+	}
+
+	return length == 1 || left.Step == right.Step
+}
+
+func equalStructs(left, right *Struct, visited map[visitedPair]bool) bool {
+	if left.Definition != right.Definition {
+		return false
+	}
+
+	pair := visitedPair{left: left, right: right}
+	if visited[pair] {
+		return true
+	}
+	visited[pair] = true
+
+	for i := range left.Values {
+		if !equal(left.Values[i], right.Values[i], visited) {
+			return false
+		}
+	}
+
+	return true
+}
@@ -23,8 +23,45 @@ const (
 	BUILTIN  = "BUILTIN"
 	ARRAY    = "ARRAY"
 	DICT     = "DICT"
+	MODULE   = "MODULE"
+	RANGE    = "RANGE"
+	STRUCT   = "STRUCT" // Struct declaration, instances are typed by struct name
+	QUOTE    = "QUOTE"
+	MACRO    = "MACRO"
 )
 
+// builtinTypes Types of builtin values, struct instances can't take them
+var builtinTypes = map[Type]bool{
+	INTEGER: true, STRING: true, BOOLEAN: true, NULL: true, RETURN: true, ERROR: true, FUNCTION: true,
+	BUILTIN: true, ARRAY: true, DICT: true, MODULE: true, RANGE: true, STRUCT: true, QUOTE: true, MACRO: true,
+}
+
+// IsBuiltinType Reports whether the type name belongs to builtin values
+func IsBuiltinType(name string) bool {
+	return builtinTypes[Type(name)]
+}
+
+// patternTypes Types of values matched by type patterns in match arms
+var patternTypes = map[string][]Type{
+	"int":      {INTEGER},
+	"string":   {STRING},
+	"bool":     {BOOLEAN},
+	"null":     {NULL},
+	"array":    {ARRAY},
+	"dict":     {DICT},
+	"range":    {RANGE},
+	"function": {FUNCTION, BUILTIN},
+}
+
+// PatternTypes Returns types of values matched by the type pattern name.
+// Reports false for other names, patterns of struct types match instances
+// of the struct the name is bound to.
+func PatternTypes(name string) ([]Type, bool) {
+	types, ok := patternTypes[name]
+
+	return types, ok
+}
+
 type Wrapper interface {
 	Type() Type
 	Sprintf() string
@@ -120,8 +157,21 @@ func (rv *ReturnValue) Sprintf() string {
 	return rv.Value.Sprintf()
 }
 
+// Error kinds reported by the evaluator
+const (
+	RUNTIME_ERROR = "RuntimeError"
+	TYPE_ERROR    = "TypeError"
+	NAME_ERROR    = "NameError"
+	INDEX_ERROR   = "IndexError"
+	MATCH_ERROR   = "MatchError"
+	THROWN_ERROR  = "Error" // Default kind of values raised by throw
+)
+
 type Error struct {
 	Message string
+	Kind    string
+	Value   Wrapper  // Value given to throw, nil for evaluator errors
+	Stack   []string // Function names from innermost call outwards
 }
 
 func (e *Error) Type() Type {
@@ -133,7 +183,9 @@ func (e *Error) Sprintf() string {
 }
 
 type Function struct {
-	Parameters []*ast.Identifier
+	Name       string // Name of the let binding, empty for anonymous functions
+	Parameters []ast.Pattern
+	Rest       *ast.Identifier
 	Body       *ast.BlockStatement
 	Env        *Environment
 }
@@ -150,6 +202,10 @@ func (f *Function) Sprintf() string {
 		params = append(params, p.String())
 	}
 
+	if f.Rest != nil {
+		params = append(params, "..."+f.Rest.String())
+	}
+
 	out.WriteString("fn")
 	out.WriteString("(")
 	out.WriteString(strings.Join(params, ", "))
@@ -160,8 +216,51 @@ func (f *Function) Sprintf() string {
 	return out.String()
 }
 
+// Quote Unevaluated code produced by quote(expression)
+type Quote struct {
+	Node ast.Node
+}
+
+func (q *Quote) Type() Type {
+	return QUOTE
+}
+
+func (q *Quote) Sprintf() string {
+	return "QUOTE(" + q.Node.String() + ")"
+}
+
+type Macro struct {
+	Parameters []*ast.Identifier
+	Body       *ast.BlockStatement
+	Env        *Environment
+}
+
+func (m *Macro) Type() Type {
+	return MACRO
+}
+
+func (m *Macro) Sprintf() string {
+	var out bytes.Buffer
+
+	params := []string{}
+	for _, p := range m.Parameters {
+		params = append(params, p.String())
+	}
+
+	out.WriteString("macro")
+	out.WriteString("(")
+	out.WriteString(strings.Join(params, ", "))
+	out.WriteString(") {\n")
+	out.WriteString(m.Body.String())
+	out.WriteString("\n}")
+
+	return out.String()
+}
+
 type BuiltIn struct {
-	Fn BuiltInFunction
+	Fn     BuiltInFunction
+	Name   string    // Name the builtin or bound method is called by
+	Method *Function // User method applied by bound struct methods
 }
 
 func (bi *BuiltIn) Type() Type {
@@ -172,6 +271,10 @@ func (bi *BuiltIn) Sprintf() string {
 	return "builtin function"
 }
 
+// Array and Dict are reference values. Binding, passing as an argument or
+// capturing in a closure's Environment shares the same container, so in-place
+// mutations (index assignment, append!, set, delete) are visible through every
+// alias. Builtins like push and tail return new containers instead.
 type Array struct {
 	Elements []Wrapper
 }
@@ -181,11 +284,37 @@ func (a *Array) Type() Type {
 }
 
 func (a *Array) Sprintf() string {
+	return sprintf(a, map[Wrapper]bool{})
+}
+
+// sprintf Prints value, containers already being printed are printed as
+// [...], {...} or Name{...} so values containing themselves can be printed
+func sprintf(v Wrapper, printing map[Wrapper]bool) string {
+	switch v := v.(type) {
+	case *Array:
+		return v.sprintf(printing)
+	case *Dict:
+		return v.sprintf(printing)
+	case *Struct:
+		return v.sprintf(printing)
+	default:
+		return v.Sprintf()
+	}
+}
+
+func (a *Array) sprintf(printing map[Wrapper]bool) string {
+	if printing[a] {
+		return "[...]"
+	}
+
+	printing[a] = true
+	defer delete(printing, a)
+
 	var out bytes.Buffer
 
 	elements := []string{}
 	for _, element := range a.Elements {
-		elements = append(elements, element.Sprintf())
+		elements = append(elements, sprintf(element, printing))
 	}
 
 	out.WriteString("[")
@@ -195,6 +324,67 @@ func (a *Array) Sprintf() string {
 	return out.String()
 }
 
+// Range Integers from Start up to End by Step, End is included only in
+// inclusive ranges. Elements are computed on access so large ranges don't
+// allocate.
+type Range struct {
+	Start     int64
+	End       int64
+	Step      int64
+	Inclusive bool
+}
+
+func (r *Range) Type() Type {
+	return RANGE
+}
+
+func (r *Range) Sprintf() string {
+	operator := ".."
+	if r.Inclusive {
+		operator = "..="
+	}
+
+	if r.Step == 1 {
+		return fmt.Sprintf("%d%s%d", r.Start, operator, r.End)
+	}
+
+	return fmt.Sprintf("%d%s%d step %d", r.Start, operator, r.End, r.Step)
+}
+
+// Len Returns number of elements in the range. Distances are unsigned, so
+// ranges spanning most of int64 don't overflow.
+func (r *Range) Len() int64 {
+	var distance uint64
+	switch {
+	case r.Step > 0 && r.End >= r.Start:
+		distance = uint64(r.End) - uint64(r.Start)
+	case r.Step < 0 && r.Start >= r.End:
+		distance = uint64(r.Start) - uint64(r.End)
+	default:
+		return 0
+	}
+
+	step := uint64(r.Step)
+	if r.Step < 0 {
+		step = -step
+	}
+
+	if r.Inclusive {
+		return int64(distance/step + 1)
+	}
+
+	if distance == 0 {
+		return 0
+	}
+
+	return int64((distance-1)/step + 1)
+}
+
+// At Returns element at position i, which must be within the range
+func (r *Range) At(i int64) int64 {
+	return r.Start + i*r.Step
+}
+
 type DictElement struct {
 	Key   Wrapper
 	Value Wrapper
@@ -202,6 +392,73 @@ type DictElement struct {
 
 type Dict struct {
 	Elements map[HashKey]DictElement
+	keys     []HashKey // Insertion order of Elements
+}
+
+func NewDict() *Dict {
+	return &Dict{Elements: make(map[HashKey]DictElement)}
+}
+
+// Set Inserts or updates element. Updated keys keep their original position.
+func (d *Dict) Set(key HashKey, element DictElement) {
+	if _, exists := d.Elements[key]; !exists {
+		d.keys = append(d.keys, key)
+	}
+
+	d.Elements[key] = element
+}
+
+func (d *Dict) Get(key HashKey) (DictElement, bool) {
+	element, ok := d.Elements[key]
+	return element, ok
+}
+
+// Lookup Returns element stored under the hash key of key. Elements whose
+// keys only share the hash key aren't matched.
+func (d *Dict) Lookup(hashKey HashKey, key Wrapper) (DictElement, bool) {
+	element, ok := d.Elements[hashKey]
+	if !ok || !Equal(element.Key, key) {
+		return DictElement{}, false
+	}
+
+	return element, true
+}
+
+// Delete Removes element and returns it if present
+func (d *Dict) Delete(key HashKey) (DictElement, bool) {
+	element, ok := d.Elements[key]
+	if !ok {
+		return element, false
+	}
+
+	delete(d.Elements, key)
+	for i, k := range d.keys {
+		if k == key {
+			d.keys = append(d.keys[:i], d.keys[i+1:]...)
+			break
+		}
+	}
+
+	return element, true
+}
+
+func (d *Dict) Len() int {
+	return len(d.Elements)
+}
+
+// HashKeys Returns hash keys of elements in insertion order
+func (d *Dict) HashKeys() []HashKey {
+	return append([]HashKey{}, d.keys...)
+}
+
+// Pairs Returns elements in insertion order
+func (d *Dict) Pairs() []DictElement {
+	pairs := make([]DictElement, 0, len(d.keys))
+	for _, key := range d.keys {
+		pairs = append(pairs, d.Elements[key])
+	}
+
+	return pairs
 }
 
 func (d *Dict) Type() Type {
@@ -209,12 +466,23 @@ func (d *Dict) Type() Type {
 }
 
 func (d *Dict) Sprintf() string {
+	return sprintf(d, map[Wrapper]bool{})
+}
+
+func (d *Dict) sprintf(printing map[Wrapper]bool) string {
+	if printing[d] {
+		return "{...}"
+	}
+
+	printing[d] = true
+	defer delete(printing, d)
+
 	var out bytes.Buffer
 
 	elements := []string{}
-	for _, element := range d.Elements {
+	for _, element := range d.Pairs() {
 		elements = append(elements, fmt.Sprintf("%s: %s",
-			element.Key.Sprintf(), element.Value.Sprintf()))
+			sprintf(element.Key, printing), sprintf(element.Value, printing)))
 	}
 
 	out.WriteString("{")
@@ -223,3 +491,149 @@ func (d *Dict) Sprintf() string {
 
 	return out.String()
 }
+
+// StructType Declared struct, calling it constructs an instance
+type StructType struct {
+	Name       string
+	Fields     []string      // Field names in declaration order
+	Parameters []ast.Pattern // Constructor parameters, one per field with optional default
+	Methods    map[string]*Function
+	Env        *Environment // Scope field defaults are evaluated in
+}
+
+func (st *StructType) Type() Type {
+	return STRUCT
+}
+
+func (st *StructType) Sprintf() string {
+	return fmt.Sprintf("<struct %s>", st.Name)
+}
+
+// Struct Instance of a declared struct. Every struct is a type of its own.
+type Struct struct {
+	Definition *StructType
+	Values     []Wrapper // Field values in declaration order
+}
+
+func (s *Struct) Type() Type {
+	return Type(s.Definition.Name)
+}
+
+func (s *Struct) Sprintf() string {
+	return sprintf(s, map[Wrapper]bool{})
+}
+
+func (s *Struct) sprintf(printing map[Wrapper]bool) string {
+	if printing[s] {
+		return s.Definition.Name + "{...}"
+	}
+
+	printing[s] = true
+	defer delete(printing, s)
+
+	var out bytes.Buffer
+
+	fields := []string{}
+	for i, name := range s.Definition.Fields {
+		fields = append(fields, fmt.Sprintf("%s: %s", name, sprintf(s.Values[i], printing)))
+	}
+
+	out.WriteString(s.Definition.Name)
+	out.WriteString("{")
+	out.WriteString(strings.Join(fields, ", "))
+	out.WriteString("}")
+
+	return out.String()
+}
+
+// Field Returns value of the named field
+func (s *Struct) Field(name string) (Wrapper, bool) {
+	for i, field := range s.Definition.Fields {
+		if field == name {
+			return s.Values[i], true
+		}
+	}
+
+	return nil, false
+}
+
+// SetField Updates value of the named field, reports false for unknown fields
+func (s *Struct) SetField(name string, val Wrapper) bool {
+	for i, field := range s.Definition.Fields {
+		if field == name {
+			s.Values[i] = val
+			return true
+		}
+	}
+
+	return false
+}
+
+// hashKey Combines type name and hash keys of fields. Instances with
+// unhashable fields or fields referring back to the instance aren't hashable.
+func (s *Struct) hashKey(visiting map[*Struct]bool) (HashKey, bool) {
+	if visiting[s] {
+		return HashKey{}, false
+	}
+
+	visiting[s] = true
+	defer delete(visiting, s)
+
+	hash := fnv.New64()
+	hash.Write([]byte(s.Definition.Name))
+
+	for _, val := range s.Values {
+		key, ok := hashKeyOf(val, visiting)
+		if !ok {
+			return HashKey{}, false
+		}
+
+		fmt.Fprintf(hash, ";%s:%d", key.Type, key.Value)
+	}
+
+	return HashKey{Type: s.Type(), Value: hash.Sum64()}, true
+}
+
+// HashKeyOf Returns key the value is stored under in dicts, reports false
+// for values unusable as dict keys. Struct instances are usable when all
+// their fields are.
+func HashKeyOf(v Wrapper) (HashKey, bool) {
+	return hashKeyOf(v, map[*Struct]bool{})
+}
+
+func hashKeyOf(v Wrapper, visiting map[*Struct]bool) (HashKey, bool) {
+	switch v := v.(type) {
+	case *Struct:
+		return v.hashKey(visiting)
+	case Hashable:
+		return v.HashKey(), true
+	default:
+		return HashKey{}, false
+	}
+}
+
+// Module Evaluated source file. Only exported bindings are reachable.
+type Module struct {
+	Path    string
+	Env     *Environment
+	Exports []string // Exported names in declaration order
+}
+
+func (m *Module) Type() Type {
+	return MODULE
+}
+
+func (m *Module) Sprintf() string {
+	return fmt.Sprintf("<module %s>", m.Path)
+}
+
+// Export Returns exported binding by name
+func (m *Module) Export(name string) (Wrapper, bool) {
+	for _, exported := range m.Exports {
+		if exported == name {
+			return m.Env.Get(name)
+		}
+	}
+
+	return nil, false
+}